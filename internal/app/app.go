@@ -7,10 +7,12 @@ import (
 	"net/http"
 
 	"github.com/specialistvlad/burstgridgo/internal/ctxlog"
+	"github.com/specialistvlad/burstgridgo/internal/locals"
 	"github.com/specialistvlad/burstgridgo/internal/localsession"
 	"github.com/specialistvlad/burstgridgo/internal/model"
 	"github.com/specialistvlad/burstgridgo/internal/registry"
 	"github.com/specialistvlad/burstgridgo/internal/session"
+	"github.com/zclconf/go-cty/cty"
 )
 
 // App encapsulates the application's dependencies, configuration, and lifecycle.
@@ -19,6 +21,8 @@ type App struct {
 	outW       io.Writer
 	config     *Config
 	grid       *model.Grid
+	variables  cty.Value
+	locals     *locals.Values
 	registry   *registry.Registry
 	httpServer *http.Server
 }
@@ -49,19 +53,35 @@ func (app *App) Run() error {
 	logger.Debug("Checking the presence of model...")
 	if app.grid == nil {
 		logger.Debug("Model is not loaded, loading default model...")
-		app.LoadModules()
+		if err := app.LoadModules(); err != nil {
+			return fmt.Errorf("failed to load modules: %w", err)
+		}
+		if err := app.ValidateModules(); err != nil {
+			return err
+		}
 	}
 
 	if err := app.LoadGrids(); err != nil {
 		return fmt.Errorf("failed to load grids: %w", err)
 	}
+	if err := app.ResolveVariables(); err != nil {
+		return err
+	}
+	if err := app.EvaluateLocals(); err != nil {
+		return err
+	}
 
 	// This section is now updated to use our new session-based architecture.
 	logger.Debug("Initializing session factory for a local run...")
-	var factory session.SessionFactory = &localsession.SessionFactory{}
+	var factory session.SessionFactory = &localsession.SessionFactory{
+		Workers:   app.config.WorkerCount,
+		CacheDir:  app.config.CacheDir,
+		Variables: app.variables,
+		Locals:    app.locals,
+	}
 
 	logger.Debug("Creating new execution session...")
-	s, err := factory.NewSession(app.ctx, app.grid, app.registry.Handlers())
+	s, err := factory.NewSession(app.ctx, app.grid, app.registry)
 	if err != nil {
 		return fmt.Errorf("failed to create execution session: %w", err)
 	}
@@ -99,3 +119,8 @@ func (a *App) Registry() *registry.Registry {
 func (a *App) Grid() *model.Grid {
 	return a.grid
 }
+
+// Variables returns the resolved values of the grid's variables. This is primarily for integration testing.
+func (a *App) Variables() cty.Value {
+	return a.variables
+}
@@ -11,6 +11,9 @@ type Config struct {
 	LogLevel        string
 	HealthcheckPort int
 	WorkerCount     int
+	CacheDir        string   // persistent step output cache; empty means the user cache directory
+	Vars            []string // grid variable assignments, name=value
+	VarFiles        []string // files of grid variable assignments
 }
 
 func NewConfig(cfg Config) (*Config, error) {
@@ -2,11 +2,15 @@ package app
 
 import (
 	"fmt"
+	"os"
 
 	"github.com/specialistvlad/burstgridgo/internal/ctxlog"
+	"github.com/specialistvlad/burstgridgo/internal/funcs"
 	"github.com/specialistvlad/burstgridgo/internal/handlers"
+	"github.com/specialistvlad/burstgridgo/internal/locals"
 	"github.com/specialistvlad/burstgridgo/internal/model"
 	"github.com/specialistvlad/burstgridgo/internal/registry"
+	"github.com/specialistvlad/burstgridgo/internal/variables"
 
 	prnt "github.com/specialistvlad/burstgridgo/modules/print"
 )
@@ -32,6 +36,18 @@ func (app *App) LoadModules() error {
 	return app.registry.LoadGridsRecursively(app.ctx, app.config.ModulesPath)
 }
 
+// ValidateModules checks that every loaded runner manifest matches the Go
+// handler registered for it. It must be called after LoadModules.
+func (app *App) ValidateModules() error {
+	logger := ctxlog.FromContext(app.ctx)
+	logger.Debug("Validating module contracts...")
+
+	if err := app.registry.ValidateContracts(app.ctx); err != nil {
+		return fmt.Errorf("module validation failed: %w", err)
+	}
+	return nil
+}
+
 func (app *App) LoadGrids() error {
 	logger := ctxlog.FromContext(app.ctx)
 	logger.Debug("Loading grids...", "grid_path", app.config.GridPath)
@@ -46,9 +62,51 @@ func (app *App) LoadGrids() error {
 	if err != nil {
 		return fmt.Errorf("failed to load grid: %w", err)
 	}
+	if diags := funcs.Check(grid); diags.HasErrors() {
+		return fmt.Errorf("failed to load grid: %w", diags)
+	}
 
 	app.grid = grid
 	logger.Info("Grids loaded successfully.", "steps_found", len(grid.Steps))
 
 	return nil
 }
+
+// ResolveVariables determines the values of the grid's variables from the
+// environment and the configured --var and --var-file options. It must be
+// called after LoadGrids.
+func (app *App) ResolveVariables() error {
+	logger := ctxlog.FromContext(app.ctx)
+	logger.Debug("Resolving variables...", "declared", len(app.grid.Variables))
+
+	vars, diags := variables.Resolve(app.grid.Variables, variables.Sources{
+		Env:   os.Environ(),
+		Vars:  app.config.Vars,
+		Files: app.config.VarFiles,
+	})
+	if diags.HasErrors() {
+		return fmt.Errorf("failed to resolve variables: %w", diags)
+	}
+
+	app.variables = vars
+	return nil
+}
+
+// EvaluateLocals orders the grid's locals and evaluates those that do not read
+// step outputs. It must be called after ResolveVariables.
+func (app *App) EvaluateLocals() error {
+	logger := ctxlog.FromContext(app.ctx)
+	logger.Debug("Evaluating locals...", "declared", len(app.grid.Locals))
+
+	set, diags := locals.Analyze(app.grid.Locals)
+	if diags.HasErrors() {
+		return fmt.Errorf("failed to evaluate locals: %w", diags)
+	}
+	vals, diags := set.Evaluate(app.variables)
+	if diags.HasErrors() {
+		return fmt.Errorf("failed to evaluate locals: %w", diags)
+	}
+
+	app.locals = vals
+	return nil
+}
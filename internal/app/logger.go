@@ -3,6 +3,8 @@ package app
 import (
 	"io"
 	"log/slog"
+
+	"github.com/specialistvlad/burstgridgo/internal/ctxlog"
 )
 
 // newLogger creates and configures a new slog.Logger instance. It does not
@@ -31,5 +33,6 @@ func newLogger(levelStr, formatStr string, outW io.Writer) *slog.Logger {
 		handler = slog.NewTextHandler(outW, handlerOpts)
 	}
 
-	return slog.New(handler)
+	// Marked sensitive values are redacted from everything the engine logs.
+	return slog.New(ctxlog.NewRedactingHandler(handler, nil))
 }
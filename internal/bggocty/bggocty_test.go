@@ -0,0 +1,126 @@
+package bggocty
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zclconf/go-cty/cty"
+)
+
+type sampleInput struct {
+	URL     string            `bggo:"url"`
+	Retries int               `bggo:"retries"`
+	Headers map[string]string `bggo:"headers"`
+	Payload any               `bggo:"payload"`
+	Ignored string
+}
+
+func TestDecodeInputs(t *testing.T) {
+	var in sampleInput
+	err := DecodeInputs(map[string]cty.Value{
+		"url":     cty.StringVal("https://example.com"),
+		"retries": cty.NumberIntVal(3),
+		"headers": cty.MapVal(map[string]cty.Value{"Accept": cty.StringVal("json")}),
+		"payload": cty.ObjectVal(map[string]cty.Value{
+			"ids":  cty.TupleVal([]cty.Value{cty.NumberIntVal(1), cty.NumberFloatVal(2.5)}),
+			"flag": cty.True,
+		}),
+	}, &in)
+	require.NoError(t, err)
+
+	assert.Equal(t, "https://example.com", in.URL)
+	assert.Equal(t, 3, in.Retries)
+	assert.Equal(t, map[string]string{"Accept": "json"}, in.Headers)
+	assert.Equal(t, map[string]any{"ids": []any{int64(1), 2.5}, "flag": true}, in.Payload)
+}
+
+func TestDecodeInputs_NullLeavesZeroValue(t *testing.T) {
+	in := sampleInput{URL: "keep"}
+	require.NoError(t, DecodeInputs(map[string]cty.Value{"url": cty.NullVal(cty.String)}, &in))
+	assert.Equal(t, "keep", in.URL)
+}
+
+func TestDecodeInputs_Errors(t *testing.T) {
+	var in sampleInput
+	assert.ErrorContains(t, DecodeInputs(map[string]cty.Value{"nope": cty.True}, &in), `input "nope" has no matching field`)
+	assert.ErrorContains(t, DecodeInputs(map[string]cty.Value{"retries": cty.StringVal("x")}, &in), `input "retries"`)
+	assert.ErrorContains(t, DecodeInputs(map[string]cty.Value{"url": cty.UnknownVal(cty.String)}, &in), "not known")
+	assert.Error(t, DecodeInputs(nil, in), "target must be a pointer")
+}
+
+func TestInputFields(t *testing.T) {
+	fields, err := InputFields(reflectTypeOf[sampleInput]())
+	require.NoError(t, err)
+	var names []string
+	for _, f := range fields {
+		names = append(names, f.Name)
+	}
+	assert.Equal(t, []string{"headers", "payload", "retries", "url"}, names)
+
+	type dup struct {
+		A string `bggo:"x"`
+		B string `bggo:"x"`
+	}
+	_, err = InputFields(reflectTypeOf[dup]())
+	assert.ErrorContains(t, err, "both map to")
+}
+
+type sampleOutput struct {
+	Status int               `cty:"status"`
+	Body   any               `cty:"body"`
+	Tags   []string          `cty:"tags"`
+	Meta   map[string]string `cty:"meta"`
+	hidden string
+}
+
+func TestToValue(t *testing.T) {
+	val, err := ToValue(&sampleOutput{
+		Status: 200,
+		Body:   map[string]any{"ok": true},
+		Tags:   []string{"a"},
+		Meta:   map[string]string{"k": "v"},
+	})
+	require.NoError(t, err)
+
+	want := cty.ObjectVal(map[string]cty.Value{
+		"status": cty.NumberIntVal(200),
+		"body":   cty.ObjectVal(map[string]cty.Value{"ok": cty.True}),
+		"tags":   cty.TupleVal([]cty.Value{cty.StringVal("a")}),
+		"meta":   cty.ObjectVal(map[string]cty.Value{"k": cty.StringVal("v")}),
+	})
+	assert.True(t, want.RawEquals(val), "got %#v", val)
+
+	null, err := ToValue(nil)
+	require.NoError(t, err)
+	assert.True(t, null.IsNull())
+}
+
+func TestToOutput(t *testing.T) {
+	declared := map[string]cty.Type{
+		"status": cty.Number,
+		"tags":   cty.List(cty.String),
+	}
+
+	out, err := ToOutput(map[string]any{"status": 201, "tags": []string{"x"}}, declared)
+	require.NoError(t, err)
+	assert.True(t, out.GetAttr("tags").Type().Equals(cty.List(cty.String)))
+
+	out, err = ToOutput(nil, declared)
+	require.NoError(t, err)
+	assert.True(t, out.GetAttr("status").IsNull())
+
+	_, err = ToOutput(map[string]any{"status": 1}, declared)
+	assert.ErrorContains(t, err, `did not return declared output "tags"`)
+
+	_, err = ToOutput(map[string]any{"status": "nan", "tags": []string{}}, declared)
+	assert.ErrorContains(t, err, `output "status"`)
+
+	_, err = ToOutput("plain", declared)
+	assert.ErrorContains(t, err, "expected an object")
+}
+
+func reflectTypeOf[T any]() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
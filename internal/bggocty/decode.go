@@ -0,0 +1,191 @@
+// Package bggocty translates between the engine's cty values and the pure Go
+// structs used by modules (see ADR-008).
+//
+// # Input Direction (cty → Go)
+//
+// A module's Input struct maps configuration keys to Go fields with the `bggo`
+// struct tag:
+//
+//	type Input struct {
+//	    URL     string `bggo:"url"`
+//	    Retries int    `bggo:"retries"`
+//	    Payload any    `bggo:"payload"`
+//	}
+//
+// DecodeInputs fills such a struct from a map of evaluated argument values.
+// Typed fields are populated with gocty; fields of interface type receive a
+// plain Go representation (string, float64 or int64, bool, []any, map[string]any).
+//
+// # Output Direction (Go → cty)
+//
+// ToValue converts whatever a handler returned into a cty.Value. Structs are
+// read through their `cty` tags, matching the output contract from ADR-008.
+package bggocty
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/gocty"
+)
+
+// InputTag is the struct tag used to map configuration keys to Input fields.
+const InputTag = "bggo"
+
+// InputField describes one tagged field of an Input struct.
+type InputField struct {
+	Name  string       // configuration key from the bggo tag
+	Index []int        // field index path for reflect.Value.FieldByIndex
+	Type  reflect.Type // Go type of the field
+}
+
+// InputFields returns every field of struct type t that carries a bggo tag,
+// sorted by configuration key. Pointer types are dereferenced.
+func InputFields(t reflect.Type) ([]InputField, error) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("input type %s is not a struct", t)
+	}
+
+	var fields []InputField
+	seen := make(map[string]string)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag, ok := f.Tag.Lookup(InputTag)
+		if !ok {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		if !f.IsExported() {
+			return nil, fmt.Errorf("field %s.%s has a %s tag but is not exported", t.Name(), f.Name, InputTag)
+		}
+		if prev, dup := seen[name]; dup {
+			return nil, fmt.Errorf("fields %s and %s of %s both map to %q", prev, f.Name, t.Name(), name)
+		}
+		seen[name] = f.Name
+		fields = append(fields, InputField{Name: name, Index: f.Index, Type: f.Type})
+	}
+
+	sort.Slice(fields, func(i, j int) bool { return fields[i].Name < fields[j].Name })
+	return fields, nil
+}
+
+// DecodeInputs populates the struct pointed to by target from values, using
+// the bggo tags on its fields. Null values leave the field at its zero value.
+// A value whose key has no matching field is an error.
+func DecodeInputs(values map[string]cty.Value, target any) error {
+	rv := reflect.ValueOf(target)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return fmt.Errorf("decode target must be a non-nil pointer, got %T", target)
+	}
+
+	fields, err := InputFields(rv.Type())
+	if err != nil {
+		return err
+	}
+	byName := make(map[string]InputField, len(fields))
+	for _, f := range fields {
+		byName[f.Name] = f
+	}
+
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	elem := rv.Elem()
+	for _, key := range keys {
+		f, ok := byName[key]
+		if !ok {
+			return fmt.Errorf("input %q has no matching field in %s", key, elem.Type())
+		}
+		if err := decodeValue(values[key], elem.FieldByIndex(f.Index)); err != nil {
+			return fmt.Errorf("input %q: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// decodeValue stores val into the settable dst.
+func decodeValue(val cty.Value, dst reflect.Value) error {
+	if val.IsNull() {
+		return nil
+	}
+	if !val.IsWhollyKnown() {
+		return fmt.Errorf("value is not known")
+	}
+	val, _ = val.UnmarkDeep()
+
+	if dst.Kind() == reflect.Interface {
+		native, err := ToNative(val)
+		if err != nil {
+			return err
+		}
+		if native != nil {
+			dst.Set(reflect.ValueOf(native))
+		}
+		return nil
+	}
+	return gocty.FromCtyValue(val, dst.Addr().Interface())
+}
+
+// ToNative converts a known, unmarked cty.Value into plain Go values: string,
+// bool, int64 (for whole numbers that fit) or float64, []any and map[string]any.
+func ToNative(val cty.Value) (any, error) {
+	if val.IsNull() {
+		return nil, nil
+	}
+	if !val.IsKnown() {
+		return nil, fmt.Errorf("value is not known")
+	}
+
+	ty := val.Type()
+	switch {
+	case ty == cty.String:
+		return val.AsString(), nil
+	case ty == cty.Bool:
+		return val.True(), nil
+	case ty == cty.Number:
+		bf := val.AsBigFloat()
+		if bf.IsInt() {
+			if i, acc := bf.Int64(); acc == big.Exact {
+				return i, nil
+			}
+		}
+		f, _ := bf.Float64()
+		return f, nil
+	case ty.IsListType() || ty.IsSetType() || ty.IsTupleType():
+		out := make([]any, 0, val.LengthInt())
+		for it := val.ElementIterator(); it.Next(); {
+			_, v := it.Element()
+			nv, err := ToNative(v)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, nv)
+		}
+		return out, nil
+	case ty.IsMapType() || ty.IsObjectType():
+		out := make(map[string]any, val.LengthInt())
+		for it := val.ElementIterator(); it.Next(); {
+			k, v := it.Element()
+			nv, err := ToNative(v)
+			if err != nil {
+				return nil, err
+			}
+			out[k.AsString()] = nv
+		}
+		return out, nil
+	}
+	return nil, fmt.Errorf("cannot convert value of type %s", ty.FriendlyName())
+}
@@ -0,0 +1,159 @@
+package bggocty
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+)
+
+// OutputTag is the struct tag used to name attributes of returned structs.
+const OutputTag = "cty"
+
+var ctyValueType = reflect.TypeOf(cty.Value{})
+
+// ToValue converts an arbitrary Go value into a cty.Value, inferring its type.
+//
+//   - nil, nil pointers and nil interfaces become cty.NullVal(cty.DynamicPseudoType)
+//   - structs become objects; only fields with a `cty` tag are included
+//   - maps with string keys become objects, slices and arrays become tuples
+//   - a cty.Value is returned unchanged
+func ToValue(v any) (cty.Value, error) {
+	return toValue(reflect.ValueOf(v), nil)
+}
+
+func toValue(rv reflect.Value, path cty.Path) (cty.Value, error) {
+	if !rv.IsValid() {
+		return cty.NullVal(cty.DynamicPseudoType), nil
+	}
+	if rv.Type() == ctyValueType {
+		return rv.Interface().(cty.Value), nil
+	}
+
+	switch rv.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if rv.IsNil() {
+			return cty.NullVal(cty.DynamicPseudoType), nil
+		}
+		return toValue(rv.Elem(), path)
+	case reflect.String:
+		return cty.StringVal(rv.String()), nil
+	case reflect.Bool:
+		return cty.BoolVal(rv.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return cty.NumberIntVal(rv.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return cty.NumberUIntVal(rv.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return cty.NumberFloatVal(rv.Float()), nil
+	case reflect.Slice, reflect.Array:
+		if rv.Kind() == reflect.Slice && rv.IsNil() {
+			return cty.EmptyTupleVal, nil
+		}
+		elems := make([]cty.Value, rv.Len())
+		for i := range elems {
+			ev, err := toValue(rv.Index(i), path.Index(cty.NumberIntVal(int64(i))))
+			if err != nil {
+				return cty.NilVal, err
+			}
+			elems[i] = ev
+		}
+		return cty.TupleVal(elems), nil
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			return cty.NilVal, path.NewErrorf("map keys must be strings, got %s", rv.Type().Key())
+		}
+		attrs := make(map[string]cty.Value, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			key := iter.Key().String()
+			av, err := toValue(iter.Value(), path.GetAttr(key))
+			if err != nil {
+				return cty.NilVal, err
+			}
+			attrs[key] = av
+		}
+		return cty.ObjectVal(attrs), nil
+	case reflect.Struct:
+		return structToValue(rv, path)
+	}
+	return cty.NilVal, path.NewErrorf("unsupported Go type %s", rv.Type())
+}
+
+func structToValue(rv reflect.Value, path cty.Path) (cty.Value, error) {
+	t := rv.Type()
+	attrs := make(map[string]cty.Value)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag, ok := f.Tag.Lookup(OutputTag)
+		if !ok || !f.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		av, err := toValue(rv.Field(i), path.GetAttr(name))
+		if err != nil {
+			return cty.NilVal, err
+		}
+		attrs[name] = av
+	}
+	return cty.ObjectVal(attrs), nil
+}
+
+// ToOutput converts a handler result into an object whose attributes match the
+// declared output types exactly. A nil result yields an object of null values.
+func ToOutput(v any, declared map[string]cty.Type) (cty.Value, error) {
+	val, err := ToValue(v)
+	if err != nil {
+		return cty.NilVal, err
+	}
+
+	names := make([]string, 0, len(declared))
+	for name := range declared {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	attrs := make(map[string]cty.Value, len(declared))
+	if val.IsNull() {
+		for _, name := range names {
+			attrs[name] = cty.NullVal(declared[name])
+		}
+		return cty.ObjectVal(attrs), nil
+	}
+
+	ty := val.Type()
+	if !ty.IsObjectType() && !ty.IsMapType() {
+		return cty.NilVal, fmt.Errorf("handler returned %s, expected an object", ty.FriendlyName())
+	}
+
+	returned := val.AsValueMap()
+	var undeclared []string
+	for name := range returned {
+		if _, ok := declared[name]; !ok {
+			undeclared = append(undeclared, name)
+		}
+	}
+	if len(undeclared) > 0 {
+		sort.Strings(undeclared)
+		return cty.NilVal, fmt.Errorf("handler returned undeclared output(s): %s", strings.Join(undeclared, ", "))
+	}
+
+	for _, name := range names {
+		av, ok := returned[name]
+		if !ok {
+			return cty.NilVal, fmt.Errorf("handler did not return declared output %q", name)
+		}
+		cv, err := convert.Convert(av, declared[name])
+		if err != nil {
+			return cty.NilVal, fmt.Errorf("output %q: %w", name, err)
+		}
+		attrs[name] = cv
+	}
+	return cty.ObjectVal(attrs), nil
+}
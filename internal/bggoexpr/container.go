@@ -19,6 +19,7 @@ type Container struct {
 	// Caching fields for analysis results
 	references      []hcl.Traversal
 	calledFunctions []string
+	callRanges      map[string]hcl.Range
 }
 
 // NewContainer creates a new, empty expression container.
@@ -51,13 +52,14 @@ func (c *Container) analyze() {
 		// The actual extraction doesn't need a lock because Do() is atomic.
 		// However, we need to read c.expressions safely.
 		c.mu.RLock()
-		refs, funcs := extractReferencesAndFunctions(c.expressions...)
+		refs, funcs, ranges := extractReferencesAndFunctions(c.expressions...)
 		c.mu.RUnlock()
 
 		// But we need a write lock to update the result fields.
 		c.mu.Lock()
 		c.references = refs
 		c.calledFunctions = funcs
+		c.callRanges = ranges
 		c.mu.Unlock()
 	})
 }
@@ -77,3 +79,20 @@ func (c *Container) CalledFunctions() []string {
 	defer c.mu.RUnlock()
 	return c.calledFunctions
 }
+
+// SplatSources returns the traversals that are the direct source of a splat
+// in the expressions (see the SplatSources function).
+func (c *Container) SplatSources() []hcl.Traversal {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return SplatSources(c.expressions...)
+}
+
+// CallRange returns the source range of the name in the first call to the
+// named function, or a zero range if the function is not called.
+func (c *Container) CallRange(name string) hcl.Range {
+	c.analyze()
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.callRanges[name]
+}
@@ -119,3 +119,39 @@ func TestContainer_EdgeCases(t *testing.T) {
 		require.Equal(t, "var.a", bggohcl.TraversalKey(c.References()[0]))
 	})
 }
+
+func TestContainer_CallRange(t *testing.T) {
+	c := bggoexpr.NewContainer()
+	c.Add(
+		parseExpr(t, `jsondecode(var.raw).items`),
+		parseExpr(t, `{ (lower("K")) = "${join(",", [for x in var.xs : trim(x)])}" }`),
+		parseExpr(t, `upper(upper("a"))`),
+	)
+
+	require.Equal(t, []string{"join", "jsondecode", "lower", "trim", "upper"}, c.CalledFunctions())
+
+	rng := c.CallRange("upper")
+	require.Equal(t, hcl.Pos{Line: 1, Column: 1, Byte: 0}, rng.Start)
+	require.Equal(t, hcl.Pos{Line: 1, Column: 6, Byte: 5}, rng.End, "the range covers the function name only")
+	require.Equal(t, "test.hcl", rng.Filename)
+
+	require.Equal(t, hcl.Range{}, c.CallRange("missing"))
+}
+
+func TestContainer_SplatSources(t *testing.T) {
+	c := bggoexpr.NewContainer()
+	c.Add(
+		parseExpr(t, `step.http.fanout[*].output`),
+		parseExpr(t, `length(values(step.http.keyed)[*].output)`),
+		parseExpr(t, `step.http.single.output`),
+		parseExpr(t, `[for s in step.http.all.*.output : s]`),
+	)
+
+	sources := c.SplatSources()
+	keys := make([]string, 0, len(sources))
+	for _, s := range sources {
+		keys = append(keys, bggohcl.TraversalKey(s))
+	}
+	require.Equal(t, []string{"step.http.fanout", "step.http.all"}, keys,
+		"only traversals splatted directly are sources")
+}
@@ -0,0 +1,156 @@
+package bggoexpr
+
+import (
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+)
+
+// The Eval* helpers evaluate a single step attribute into a Go value. Each
+// reports ok=false when the expression is nil or evaluates to null, so that
+// callers can fall back to a default, and describes a value of the wrong type
+// as an "Invalid <name> value" diagnostic pointing at the expression.
+
+// EvalString evaluates expr as a string.
+func EvalString(expr hcl.Expression, ctx *hcl.EvalContext, name string) (string, bool, hcl.Diagnostics) {
+	val, ok, diags := evalAs(expr, ctx, name, cty.String)
+	if !ok {
+		return "", false, diags
+	}
+	return val.AsString(), true, diags
+}
+
+// EvalBool evaluates expr as a bool.
+func EvalBool(expr hcl.Expression, ctx *hcl.EvalContext, name string) (bool, bool, hcl.Diagnostics) {
+	val, ok, diags := evalAs(expr, ctx, name, cty.Bool)
+	if !ok {
+		return false, false, diags
+	}
+	return val.True(), true, diags
+}
+
+// EvalNumber evaluates expr as a float64.
+func EvalNumber(expr hcl.Expression, ctx *hcl.EvalContext, name string) (float64, bool, hcl.Diagnostics) {
+	val, ok, diags := evalAs(expr, ctx, name, cty.Number)
+	if !ok {
+		return 0, false, diags
+	}
+	f, _ := val.AsBigFloat().Float64()
+	return f, true, diags
+}
+
+// EvalInt evaluates expr as a whole number.
+func EvalInt(expr hcl.Expression, ctx *hcl.EvalContext, name string) (int, bool, hcl.Diagnostics) {
+	val, ok, diags := evalAs(expr, ctx, name, cty.Number)
+	if !ok {
+		return 0, false, diags
+	}
+	bf := val.AsBigFloat()
+	i, acc := bf.Int64()
+	if acc != big.Exact {
+		return 0, false, diags.Append(invalid(expr, name, fmt.Sprintf("must be a whole number, got %s", bf.Text('f', -1))))
+	}
+	return int(i), true, diags
+}
+
+// EvalStrings evaluates expr as a list of strings.
+func EvalStrings(expr hcl.Expression, ctx *hcl.EvalContext, name string) ([]string, bool, hcl.Diagnostics) {
+	val, ok, diags := evalAs(expr, ctx, name, cty.List(cty.String))
+	if !ok {
+		return nil, false, diags
+	}
+	out := make([]string, 0, val.LengthInt())
+	for it := val.ElementIterator(); it.Next(); {
+		_, v := it.Element()
+		if v.IsNull() {
+			return nil, false, diags.Append(invalid(expr, name, "must not contain null elements"))
+		}
+		out = append(out, v.AsString())
+	}
+	return out, true, diags
+}
+
+// EvalDuration evaluates expr as a duration. Strings use Go duration syntax
+// (e.g. "500ms", "1m30s"); numbers are taken as seconds.
+func EvalDuration(expr hcl.Expression, ctx *hcl.EvalContext, name string) (time.Duration, bool, hcl.Diagnostics) {
+	val, ok, diags := eval(expr, ctx, name)
+	if !ok {
+		return 0, false, diags
+	}
+	d, err := ToDuration(val)
+	if err != nil {
+		return 0, false, diags.Append(invalid(expr, name, err.Error()))
+	}
+	return d, true, diags
+}
+
+// ToDuration converts a known, non-null string or number into a duration.
+func ToDuration(val cty.Value) (time.Duration, error) {
+	switch val.Type() {
+	case cty.String:
+		d, err := time.ParseDuration(val.AsString())
+		if err != nil {
+			return 0, fmt.Errorf("must be a duration such as \"500ms\" or \"2m\": %w", err)
+		}
+		if d < 0 {
+			return 0, fmt.Errorf("must not be negative")
+		}
+		return d, nil
+	case cty.Number:
+		secs, _ := val.AsBigFloat().Float64()
+		if secs < 0 {
+			return 0, fmt.Errorf("must not be negative")
+		}
+		return time.Duration(secs * float64(time.Second)), nil
+	default:
+		return 0, fmt.Errorf("must be a duration string or a number of seconds, got %s", val.Type().FriendlyName())
+	}
+}
+
+// eval evaluates expr and reports ok=false for nil expressions, null values
+// and errors. Marks, such as sensitivity, are removed: they do not change how
+// an attribute is interpreted.
+func eval(expr hcl.Expression, ctx *hcl.EvalContext, name string) (cty.Value, bool, hcl.Diagnostics) {
+	if expr == nil {
+		return cty.NilVal, false, nil
+	}
+	val, diags := expr.Value(ctx)
+	if diags.HasErrors() || val.IsNull() {
+		return cty.NilVal, false, diags
+	}
+	val, _ = val.UnmarkDeep()
+	if !val.IsWhollyKnown() {
+		return cty.NilVal, false, diags.Append(invalid(expr, name, "the value is not known yet"))
+	}
+	return val, true, diags
+}
+
+// evalAs evaluates expr and converts the result to ty.
+func evalAs(expr hcl.Expression, ctx *hcl.EvalContext, name string, ty cty.Type) (cty.Value, bool, hcl.Diagnostics) {
+	val, ok, diags := eval(expr, ctx, name)
+	if !ok {
+		return cty.NilVal, false, diags
+	}
+	converted, err := convert.Convert(val, ty)
+	if err != nil {
+		return cty.NilVal, false, diags.Append(invalid(expr, name, fmt.Sprintf("%s is required: %s", ty.FriendlyName(), err)))
+	}
+	return converted, true, diags
+}
+
+func invalid(expr hcl.Expression, name, detail string) *hcl.Diagnostic {
+	summary := "Invalid value"
+	if name != "" {
+		summary = fmt.Sprintf("Invalid %s value", name)
+	}
+	return &hcl.Diagnostic{
+		Severity: hcl.DiagError,
+		Summary:  summary,
+		Detail:   fmt.Sprintf("Inappropriate value: %s.", detail),
+		Subject:  expr.Range().Ptr(),
+	}
+}
@@ -0,0 +1,67 @@
+package bggoexpr_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/specialistvlad/burstgridgo/internal/bggoexpr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestEvalDuration(t *testing.T) {
+	testCases := []struct {
+		src     string
+		want    time.Duration
+		wantOK  bool
+		wantErr bool
+	}{
+		{src: `"1m30s"`, want: 90 * time.Second, wantOK: true},
+		{src: `2.5`, want: 2500 * time.Millisecond, wantOK: true},
+		{src: `null`},
+		{src: `"soon"`, wantErr: true},
+		{src: `"-1s"`, wantErr: true},
+		{src: `true`, wantErr: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.src, func(t *testing.T) {
+			d, ok, diags := bggoexpr.EvalDuration(parseExpr(t, tc.src), nil, "delay")
+			assert.Equal(t, tc.wantErr, diags.HasErrors(), diags.Error())
+			assert.Equal(t, tc.wantOK, ok)
+			assert.Equal(t, tc.want, d)
+			if tc.wantErr {
+				assert.Equal(t, "Invalid delay value", diags[0].Summary)
+			}
+		})
+	}
+}
+
+func TestEvalTyped(t *testing.T) {
+	ctx := &hcl.EvalContext{Variables: map[string]cty.Value{
+		"var": cty.ObjectVal(map[string]cty.Value{"n": cty.NumberIntVal(4)}),
+	}}
+
+	n, ok, diags := bggoexpr.EvalInt(parseExpr(t, `var.n + 1`), ctx, "attempts")
+	require.False(t, diags.HasErrors(), diags.Error())
+	assert.True(t, ok)
+	assert.Equal(t, 5, n)
+
+	_, _, diags = bggoexpr.EvalInt(parseExpr(t, `1.5`), ctx, "attempts")
+	assert.True(t, diags.HasErrors())
+
+	s, ok, diags := bggoexpr.EvalString(parseExpr(t, `var.n`), ctx, "key")
+	require.False(t, diags.HasErrors(), diags.Error())
+	assert.True(t, ok)
+	assert.Equal(t, "4", s)
+
+	list, ok, diags := bggoexpr.EvalStrings(parseExpr(t, `["a", "b"]`), ctx, "retry_on")
+	require.False(t, diags.HasErrors(), diags.Error())
+	assert.True(t, ok)
+	assert.Equal(t, []string{"a", "b"}, list)
+
+	_, ok, diags = bggoexpr.EvalBool(nil, ctx, "enabled")
+	assert.False(t, ok)
+	assert.False(t, diags.HasErrors())
+}
@@ -27,10 +27,11 @@ type Expressioner interface {
 
 // extractReferencesAndFunctions walks through HCL expressions to find all unique
 // variable traversals and function calls. The returned slices are sorted to
-// ensure a deterministic order.
-func extractReferencesAndFunctions(exprs ...hcl.Expression) ([]hcl.Traversal, []string) {
+// ensure a deterministic order. The returned map holds the name range of the
+// first call to each function.
+func extractReferencesAndFunctions(exprs ...hcl.Expression) ([]hcl.Traversal, []string, map[string]hcl.Range) {
 	traversals := make(map[string]hcl.Traversal)
-	functions := make(map[string]struct{})
+	functions := make(map[string]hcl.Range)
 
 	for _, expr := range exprs {
 		if expr == nil {
@@ -68,18 +69,20 @@ func extractReferencesAndFunctions(exprs ...hcl.Expression) ([]hcl.Traversal, []
 	}
 	sort.Strings(functionSlice) // Sort for deterministic output
 
-	return traversalSlice, functionSlice
+	return traversalSlice, functionSlice, functions
 }
 
 // walkForFunctions recursively walks the AST, looking only for function calls.
-func walkForFunctions(expr hclsyntax.Expression, functions map[string]struct{}) {
-	// (Implementation from before, kept the same)
+// It records the name range of the first call to each function.
+func walkForFunctions(expr hclsyntax.Expression, functions map[string]hcl.Range) {
 	if expr == nil {
 		return
 	}
 	switch e := expr.(type) {
 	case *hclsyntax.FunctionCallExpr:
-		functions[e.Name] = struct{}{}
+		if _, seen := functions[e.Name]; !seen {
+			functions[e.Name] = e.NameRange
+		}
 		for _, arg := range e.Args {
 			walkForFunctions(arg, functions)
 		}
@@ -98,6 +101,8 @@ func walkForFunctions(expr hclsyntax.Expression, functions map[string]struct{})
 		}
 	case *hclsyntax.TemplateWrapExpr:
 		walkForFunctions(e.Wrapped, functions)
+	case *hclsyntax.TemplateJoinExpr:
+		walkForFunctions(e.Tuple, functions)
 	case *hclsyntax.TupleConsExpr:
 		for _, item := range e.Exprs {
 			walkForFunctions(item, functions)
@@ -112,6 +117,10 @@ func walkForFunctions(expr hclsyntax.Expression, functions map[string]struct{})
 		walkForFunctions(e.KeyExpr, functions)
 		walkForFunctions(e.ValExpr, functions)
 		walkForFunctions(e.CondExpr, functions)
+	case *hclsyntax.ObjectConsKeyExpr:
+		walkForFunctions(e.Wrapped, functions)
+	case *hclsyntax.RelativeTraversalExpr:
+		walkForFunctions(e.Source, functions)
 	case *hclsyntax.IndexExpr:
 		walkForFunctions(e.Collection, functions)
 		walkForFunctions(e.Key, functions)
@@ -123,6 +132,28 @@ func walkForFunctions(expr hclsyntax.Expression, functions map[string]struct{})
 	}
 }
 
+// SplatSources returns the traversals that are the direct source of a splat,
+// such as `step.http_request.fanout` in `step.http_request.fanout[*].output`,
+// in source order.
+func SplatSources(exprs ...hcl.Expression) []hcl.Traversal {
+	var sources []hcl.Traversal
+	for _, expr := range exprs {
+		syntaxExpr, ok := expr.(hclsyntax.Expression)
+		if !ok {
+			continue
+		}
+		hclsyntax.VisitAll(syntaxExpr, func(n hclsyntax.Node) hcl.Diagnostics {
+			if splat, ok := n.(*hclsyntax.SplatExpr); ok {
+				if src, ok := splat.Source.(*hclsyntax.ScopeTraversalExpr); ok {
+					sources = append(sources, src.Traversal)
+				}
+			}
+			return nil
+		})
+	}
+	return sources
+}
+
 // parseBlock provides a generic way to find and decode a unique HCL block.
 // It uses a generic type 'T' which must be a pointer to a struct that
 // implements the Expressioner interface.
@@ -2,68 +2,406 @@ package builder
 
 import (
 	"context"
+	"fmt"
+	"slices"
+	"sort"
 
+	"github.com/hashicorp/hcl/v2"
+	"github.com/specialistvlad/burstgridgo/internal/bggohcl"
 	"github.com/specialistvlad/burstgridgo/internal/ctxlog"
+	"github.com/specialistvlad/burstgridgo/internal/funcs"
 	"github.com/specialistvlad/burstgridgo/internal/graph"
+	"github.com/specialistvlad/burstgridgo/internal/locals"
 	"github.com/specialistvlad/burstgridgo/internal/node"
+	"github.com/specialistvlad/burstgridgo/internal/registry"
 	"github.com/specialistvlad/burstgridgo/internal/task"
+	"github.com/zclconf/go-cty/cty"
 )
 
 // DefaultBuilder is the reference implementation of the Builder interface.
 //
-// # Current Status: Stubbed
+// # Algorithm
 //
-// This implementation currently returns an empty Task with no input resolution (placeholder).
-// A complete implementation would:
+// For each node:
 //
-//  1. Extract node configuration and arguments from node.Config
-//  2. Use internal/bggoexpr to identify expressions (e.g., `step.first.output.value`)
-//  3. For each expression referencing another node:
-//     a. Parse the node ID (e.g., "step.first")
-//     b. Query graph.Node() or underlying nodestore for the output
-//     c. Navigate the output structure to get the referenced field (e.g., "value")
-//  4. Evaluate expressions using go-cty's evaluation context
-//  5. Build a map[string]any with all resolved values
-//  6. Return Task{Node: n, ResolvedInputs: resolvedMap}
+//  1. Build an hcl.EvalContext whose `step` variable holds the outputs of the
+//     node's completed dependencies, shaped as `step.<runner>.<name>.output`
+//     (or `step.<runner>.<name>[<i>].output` for instanced steps), plus the
+//     grid's `var.<name>` and `local.<name>` values and `count.index` or
+//     `each.key`/`each.value` for instanced nodes
+//  2. Look up the node's runner definition in the registry
+//  3. Evaluate every expression in the step's `arguments` block
+//  4. Reject arguments the runner does not declare as inputs
+//  5. Fill omitted (or null) arguments from the input's `default`, and report
+//     required inputs that have neither
+//  6. Convert each value to the input's declared cty type
+//  7. Return Task{Node: n, ResolvedInputs: values, EvalContext: evalCtx}
+//
+// Every problem found is collected and returned as hcl.Diagnostics, so a
+// single build reports all argument errors with their source ranges.
 //
 // # Example Resolution
 //
 // Given node config:
 //
-//	arguments = {
-//	  url      = "https://api.example.com"
+//	arguments {
+//	  url        = "https://api.example.com"
 //	  auth_token = step.get_token.first.output.token
-//	  retry    = local.max_retries
 //	}
 //
 // Builder would:
-//  1. Identify `step.get_token.first.output.token` expression
-//  2. Query graph for node "step.get_token.first"
-//  3. Retrieve its output: {token: "abc123", expires: 3600}
-//  4. Extract .token field: "abc123"
-//  5. Resolve local.max_retries from evaluation context
-//  6. Return ResolvedInputs:
+//  1. Read the output of "step.get_token.first": {token: "abc123", expires: 3600}
+//  2. Evaluate step.get_token.first.output.token → "abc123"
+//  3. Apply the runner's default for any omitted input (e.g., retries = 3)
+//  4. Return ResolvedInputs:
 //     {
-//       url: "https://api.example.com",
-//       auth_token: "abc123",
-//       retry: 3
+//     url: "https://api.example.com",
+//     auth_token: "abc123",
+//     retries: 3
 //     }
 //
 // # Thread-Safety
 //
 // Thread-safety is guaranteed by:
-//   - No shared mutable state (stateless)
+//   - No shared mutable state (the registry is read-only after loading)
 //   - Delegating to thread-safe graph interface for queries
-type DefaultBuilder struct{}
+type DefaultBuilder struct {
+	registry  *registry.Registry
+	variables cty.Value
+	locals    *locals.Values
+}
+
+// Option configures a DefaultBuilder.
+type Option func(*DefaultBuilder)
 
-// New creates a new default builder.
-func New() Builder {
-	return &DefaultBuilder{}
+// WithVariables sets the values exposed to expressions as `var.<name>`, as
+// returned by variables.Resolve. Without it `var` is an empty object.
+func WithVariables(vars cty.Value) Option {
+	return func(b *DefaultBuilder) {
+		b.variables = vars
+	}
+}
+
+// WithLocals sets the values exposed to expressions as `local.<name>`, as
+// returned by locals.Set.Evaluate. Locals that read step outputs are
+// evaluated for each node that uses them. Without it `local` is an empty
+// object.
+func WithLocals(vals *locals.Values) Option {
+	return func(b *DefaultBuilder) {
+		b.locals = vals
+	}
+}
+
+// New creates a new default builder that resolves runner definitions from reg.
+func New(reg *registry.Registry, opts ...Option) Builder {
+	b := &DefaultBuilder{registry: reg, variables: cty.EmptyObjectVal}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
 }
 
 // Build implements the Builder interface.
 func (b *DefaultBuilder) Build(ctx context.Context, n *node.Node, g graph.Graph) (*task.Task, error) {
 	logger := ctxlog.FromContext(ctx)
-	logger.Debug("builder.Build called (placeholder)", "node", n.ID.String())
-	return &task.Task{Node: n, ResolvedInputs: make(map[string]any)}, nil
+	logger.Debug("Building task.", "node", n.ID.String())
+
+	evalCtx, err := b.EvalContext(ctx, n, g)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &task.Task{Node: n, ResolvedInputs: make(map[string]cty.Value), EvalContext: evalCtx}
+	if n.Config == nil {
+		// Nodes that were not compiled from a step have nothing to resolve.
+		return t, nil
+	}
+
+	if b.registry == nil {
+		return nil, fmt.Errorf("no registry configured to resolve runner %q", n.Type)
+	}
+	runner, ok := b.registry.Runner(n.Type)
+	if !ok {
+		return nil, hcl.Diagnostics{{
+			Severity: hcl.DiagError,
+			Summary:  "Unknown runner",
+			Detail:   fmt.Sprintf("Step %s uses runner %q, which is not defined by any loaded module.", n.ID.String(), n.Type),
+			Subject:  n.Config.DeclRange.Ptr(),
+		}}
+	}
+
+	var diags hcl.Diagnostics
+	args := n.Config.Arguments
+
+	for _, name := range sortedKeys(args) {
+		expr := args[name]
+		if _, declared := runner.Inputs[name]; !declared {
+			diags = diags.Append(&hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Unsupported argument",
+				Detail:   fmt.Sprintf("Runner %q does not declare an input named %q.", n.Type, name),
+				Subject:  expr.Range().Ptr(),
+			})
+		}
+	}
+
+	for _, name := range sortedKeys(runner.Inputs) {
+		def := runner.Inputs[name]
+
+		val := cty.NullVal(def.Type)
+		expr, provided := args[name]
+		if provided {
+			v, valDiags := expr.Value(evalCtx)
+			diags = append(diags, valDiags...)
+			if valDiags.HasErrors() {
+				continue
+			}
+			val = v
+		}
+
+		if val.IsNull() {
+			if def.Default == nil {
+				subject := n.Config.DeclRange.Ptr()
+				if provided {
+					subject = expr.Range().Ptr()
+				}
+				diags = diags.Append(&hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Missing required argument",
+					Detail:   fmt.Sprintf("The argument %q is required by runner %q, but no value was given.", name, n.Type),
+					Subject:  subject,
+				})
+				continue
+			}
+			val = *def.Default
+		}
+
+		converted, err := bggohcl.ConvertToType(val, def.Type, def.TypeDefaults)
+		if err != nil {
+			d := &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid argument type",
+				Detail:   fmt.Sprintf("Inappropriate value for argument %q: %s is required, but got %s: %s.", name, def.Type.FriendlyName(), val.Type().FriendlyName(), err),
+			}
+			if provided {
+				d.Subject = expr.Range().Ptr()
+			}
+			diags = diags.Append(d)
+			continue
+		}
+		t.ResolvedInputs[name] = converted
+	}
+
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	return t, nil
+}
+
+// EvalContext implements the Builder interface.
+//
+// Outputs of singular steps are exposed as `step.<runner>.<name>.output`.
+// Outputs of instanced steps are exposed as a list ordered by `count.index`,
+// or as a map keyed by `each.key`, so `step.<runner>.<name>[0].output` resolves
+// to a single instance and `step.<runner>.<name>[*].output` fans in over every
+// instance. A splat only iterates lists, so `for_each` instances fan in with
+// `values(step.<runner>.<name>)[*].output`, in key order. The grid's variables
+// are exposed as `var.<name>` and its locals as `local.<name>`; locals that
+// read step outputs are evaluated here, against the node's dependencies.
+// Instanced nodes also see `count.index`, or `each.key` and `each.value`.
+func (b *DefaultBuilder) EvalContext(ctx context.Context, n *node.Node, g graph.Graph) (*hcl.EvalContext, error) {
+	deps, err := g.DependenciesOf(ctx, n.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve dependencies of %s: %w", n.ID.String(), err)
+	}
+
+	// runner type -> step name -> {output = ...} or its instances
+	steps := make(map[string]map[string]cty.Value)
+	instances := make(map[string]map[string]*instanceSet)
+	for _, dep := range deps {
+		path := dep.ID.Path
+		if len(path) != 3 || path[0].Name != "step" {
+			continue
+		}
+		runnerType, name := path[1].Name, path[2].Name
+		if dep.Placeholder {
+			// An expanded dynamic step: its instances are dependencies too and
+			// fill the set, which stays empty when nothing was expanded.
+			set := instancesOf(instances, runnerType, name)
+			set.forEach = dep.Config != nil && dep.Config.ForEach != nil
+			continue
+		}
+		output, err := b.outputOf(ctx, g, dep)
+		if err != nil {
+			return nil, err
+		}
+		val := cty.ObjectVal(map[string]cty.Value{"output": output})
+
+		if dep.Instance == nil {
+			if steps[runnerType] == nil {
+				steps[runnerType] = make(map[string]cty.Value)
+			}
+			steps[runnerType][name] = val
+			continue
+		}
+		instancesOf(instances, runnerType, name).add(dep.Instance, val)
+	}
+
+	for runnerType, names := range instances {
+		if steps[runnerType] == nil {
+			steps[runnerType] = make(map[string]cty.Value)
+		}
+		for name, set := range names {
+			steps[runnerType][name] = set.value()
+		}
+	}
+
+	var refs []hcl.Traversal
+	var localNames []string
+	if n.Config != nil {
+		refs = n.Config.Expressions.References()
+		localNames = locals.Referenced(refs)
+		if b.locals != nil {
+			refs = append(slices.Clone(refs), b.locals.Set().StepReferences(localNames)...)
+		}
+	}
+	// A reference to an instanced step that expanded into no instances has no
+	// dependency to read; it evaluates to an empty collection.
+	for _, trav := range refs {
+		runnerType, name, ok := stepRef(trav)
+		if !ok {
+			continue
+		}
+		if _, present := steps[runnerType][name]; present {
+			continue
+		}
+		if steps[runnerType] == nil {
+			steps[runnerType] = make(map[string]cty.Value)
+		}
+		steps[runnerType][name] = cty.EmptyTupleVal
+	}
+
+	byRunner := make(map[string]cty.Value, len(steps))
+	for runnerType, names := range steps {
+		byRunner[runnerType] = cty.ObjectVal(names)
+	}
+
+	vars := map[string]cty.Value{
+		"step": cty.ObjectVal(byRunner),
+		"var":  b.variables,
+	}
+	if inst := n.Instance; inst != nil {
+		if inst.ForEach {
+			vars["each"] = cty.ObjectVal(map[string]cty.Value{"key": inst.Key, "value": inst.Value})
+		} else {
+			vars["count"] = cty.ObjectVal(map[string]cty.Value{"index": cty.NumberIntVal(int64(inst.Index))})
+		}
+	}
+
+	evalCtx := funcs.EvalContext(vars)
+	vars["local"] = cty.EmptyObjectVal
+	if b.locals != nil {
+		local, diags := b.locals.Object(evalCtx, localNames)
+		if diags.HasErrors() {
+			return nil, diags
+		}
+		vars["local"] = local
+	}
+	return evalCtx, nil
+}
+
+// stepRef extracts the runner type and step name from a `step.<runner>.<name>`
+// traversal.
+func stepRef(trav hcl.Traversal) (runnerType, name string, ok bool) {
+	if len(trav) < 3 || trav.RootName() != "step" {
+		return "", "", false
+	}
+	r, ok1 := trav[1].(hcl.TraverseAttr)
+	s, ok2 := trav[2].(hcl.TraverseAttr)
+	if !ok1 || !ok2 {
+		return "", "", false
+	}
+	return r.Name, s.Name, true
+}
+
+// instanceSet gathers the completed instances of one instanced dependency.
+type instanceSet struct {
+	forEach bool
+	byIndex map[int]cty.Value
+	byKey   map[string]cty.Value
+}
+
+// instancesOf returns the instance set for a step, creating it if needed.
+func instancesOf(sets map[string]map[string]*instanceSet, runnerType, name string) *instanceSet {
+	if sets[runnerType] == nil {
+		sets[runnerType] = make(map[string]*instanceSet)
+	}
+	set := sets[runnerType][name]
+	if set == nil {
+		set = &instanceSet{}
+		sets[runnerType][name] = set
+	}
+	return set
+}
+
+func (s *instanceSet) add(inst *node.Instance, val cty.Value) {
+	if inst.ForEach {
+		s.forEach = true
+		if s.byKey == nil {
+			s.byKey = make(map[string]cty.Value)
+		}
+		s.byKey[inst.Key.AsString()] = val
+		return
+	}
+	if s.byIndex == nil {
+		s.byIndex = make(map[int]cty.Value)
+	}
+	s.byIndex[inst.Index] = val
+}
+
+// value returns the instances as an object keyed by for_each key, or as a
+// tuple ordered by count index. Gaps left by instances the node does not
+// depend on are filled with a null output so that indexes stay aligned.
+func (s *instanceSet) value() cty.Value {
+	if s.forEach {
+		return cty.ObjectVal(s.byKey)
+	}
+	size := 0
+	for i := range s.byIndex {
+		size = max(size, i+1)
+	}
+	elems := make([]cty.Value, size)
+	for i := range elems {
+		val, ok := s.byIndex[i]
+		if !ok {
+			val = cty.ObjectVal(map[string]cty.Value{"output": cty.NullVal(cty.DynamicPseudoType)})
+		}
+		elems[i] = val
+	}
+	return cty.TupleVal(elems)
+}
+
+// outputOf returns the recorded output of a dependency as a cty.Value.
+func (b *DefaultBuilder) outputOf(ctx context.Context, g graph.Graph, dep *node.Node) (cty.Value, error) {
+	raw, err := g.NodeOutput(ctx, dep.ID)
+	if err != nil {
+		return cty.NilVal, fmt.Errorf("failed to read output of %s: %w", dep.ID.String(), err)
+	}
+	switch v := raw.(type) {
+	case nil:
+		return cty.NullVal(cty.DynamicPseudoType), nil
+	case cty.Value:
+		return v, nil
+	default:
+		return cty.NilVal, fmt.Errorf("output of %s has unexpected type %T", dep.ID.String(), raw)
+	}
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
 }
@@ -0,0 +1,461 @@
+package builder
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/specialistvlad/burstgridgo/internal/compiler"
+	"github.com/specialistvlad/burstgridgo/internal/ctxlog"
+	"github.com/specialistvlad/burstgridgo/internal/graph"
+	"github.com/specialistvlad/burstgridgo/internal/handlers"
+	"github.com/specialistvlad/burstgridgo/internal/inmemorystore"
+	"github.com/specialistvlad/burstgridgo/internal/inmemorytopology"
+	"github.com/specialistvlad/burstgridgo/internal/locals"
+	"github.com/specialistvlad/burstgridgo/internal/model"
+	"github.com/specialistvlad/burstgridgo/internal/node"
+	"github.com/specialistvlad/burstgridgo/internal/nodeid"
+	"github.com/specialistvlad/burstgridgo/internal/registry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zclconf/go-cty/cty"
+)
+
+const manifest = `
+runner "source" {
+  output "token" {
+    type = string
+  }
+  lifecycle {
+    on_run = "OnRunSource"
+  }
+}
+
+runner "http" {
+  input "url" {
+    type = string
+  }
+  input "retries" {
+    type    = number
+    default = 3
+  }
+  input "verbose" {
+    type    = bool
+    default = false
+  }
+  lifecycle {
+    on_run = "OnRunHTTP"
+  }
+}
+
+runner "deploy" {
+  input "targets" {
+    type = list(object({
+      url     = string
+      retries = optional(number, 3)
+    }))
+  }
+  lifecycle {
+    on_run = "OnRunDeploy"
+  }
+}
+`
+
+func testContext() context.Context {
+	return ctxlog.WithLogger(context.Background(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+// setup loads the manifest and grid, compiles the grid and returns a builder
+// together with the populated graph.
+func setup(t *testing.T, gridSrc string, opts ...Option) (Builder, graph.Graph) {
+	t.Helper()
+	ctx := testContext()
+
+	modulesDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(modulesDir, "manifest.hcl"), []byte(manifest), 0644))
+	reg := registry.New(handlers.New())
+	require.NoError(t, reg.LoadGridsRecursively(ctx, modulesDir))
+
+	gridDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(gridDir, "main.hcl"), []byte(gridSrc), 0644))
+	grid, err := model.LoadGridsRecursively(ctx, gridDir)
+	require.NoError(t, err)
+
+	topo := inmemorytopology.New()
+	diags := compiler.Compile(ctx, grid, topo)
+	require.False(t, diags.HasErrors(), diags.Error())
+
+	return New(reg, opts...), graph.New(topo, inmemorystore.New())
+}
+
+func mustNode(t *testing.T, g graph.Graph, id string) *node.Node {
+	t.Helper()
+	addr, err := nodeid.Parse(id)
+	require.NoError(t, err)
+	n, ok := g.Node(testContext(), *addr)
+	require.True(t, ok, "node %s not found", id)
+	return n
+}
+
+// assertValues compares cty maps by value rather than by Go representation.
+func assertValues(t *testing.T, want, got map[string]cty.Value) {
+	t.Helper()
+	require.Len(t, got, len(want))
+	for k, w := range want {
+		assert.True(t, w.RawEquals(got[k]), "%s: want %#v, got %#v", k, w, got[k])
+	}
+}
+
+func TestBuild_ResolvesUpstreamOutputsAndDefaults(t *testing.T) {
+	b, g := setup(t, `
+		step "source" "auth" {}
+		step "http" "call" {
+			arguments {
+				url     = "https://example.com/?token=${step.source.auth.output.token}"
+				verbose = "true"
+			}
+		}
+	`)
+	ctx := testContext()
+	src := mustNode(t, g, "step.source.auth")
+	require.NoError(t, g.MarkCompleted(ctx, src.ID, cty.ObjectVal(map[string]cty.Value{
+		"token": cty.StringVal("abc123"),
+	})))
+
+	tk, err := b.Build(ctx, mustNode(t, g, "step.http.call"), g)
+	require.NoError(t, err)
+
+	assertValues(t, map[string]cty.Value{
+		"url":     cty.StringVal("https://example.com/?token=abc123"),
+		"retries": cty.NumberIntVal(3),
+		"verbose": cty.True,
+	}, tk.ResolvedInputs)
+	require.NotNil(t, tk.EvalContext)
+	assert.True(t, tk.EvalContext.Variables["step"].Type().IsObjectType())
+}
+
+func TestBuild_NullArgumentUsesDefault(t *testing.T) {
+	b, g := setup(t, `
+		step "http" "call" {
+			arguments {
+				url     = "https://example.com"
+				retries = null
+			}
+		}
+	`)
+
+	tk, err := b.Build(testContext(), mustNode(t, g, "step.http.call"), g)
+	require.NoError(t, err)
+	assert.True(t, cty.NumberIntVal(3).RawEquals(tk.ResolvedInputs["retries"]))
+}
+
+func TestBuild_ReportsAllDiagnostics(t *testing.T) {
+	b, g := setup(t, `
+		step "http" "call" {
+			arguments {
+				retries = "many"
+				colour  = "blue"
+			}
+		}
+	`)
+
+	_, err := b.Build(testContext(), mustNode(t, g, "step.http.call"), g)
+	require.Error(t, err)
+
+	var diags hcl.Diagnostics
+	require.ErrorAs(t, err, &diags)
+	require.Len(t, diags, 3)
+
+	summaries := map[string]*hcl.Diagnostic{}
+	for _, d := range diags {
+		summaries[d.Summary] = d
+	}
+
+	unsupported := summaries["Unsupported argument"]
+	require.NotNil(t, unsupported)
+	assert.Contains(t, unsupported.Detail, `"colour"`)
+	assert.Equal(t, 5, unsupported.Subject.Start.Line)
+
+	invalid := summaries["Invalid argument type"]
+	require.NotNil(t, invalid)
+	assert.Contains(t, invalid.Detail, `"retries"`)
+	assert.Equal(t, 4, invalid.Subject.Start.Line)
+
+	missing := summaries["Missing required argument"]
+	require.NotNil(t, missing)
+	assert.Contains(t, missing.Detail, `"url"`)
+	assert.Equal(t, 2, missing.Subject.Start.Line)
+}
+
+func TestBuild_UnknownRunner(t *testing.T) {
+	b, g := setup(t, `step "nope" "call" {}`)
+
+	_, err := b.Build(testContext(), mustNode(t, g, "step.nope.call"), g)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Unknown runner")
+}
+
+func TestBuild_ReferenceToMissingOutputAttribute(t *testing.T) {
+	b, g := setup(t, `
+		step "source" "auth" {}
+		step "http" "call" {
+			arguments {
+				url = step.source.auth.output.missing
+			}
+		}
+	`)
+	ctx := testContext()
+	src := mustNode(t, g, "step.source.auth")
+	require.NoError(t, g.MarkCompleted(ctx, src.ID, cty.ObjectVal(map[string]cty.Value{
+		"token": cty.StringVal("abc123"),
+	})))
+
+	_, err := b.Build(ctx, mustNode(t, g, "step.http.call"), g)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Unsupported attribute")
+}
+
+func TestBuild_CountAndEachVariables(t *testing.T) {
+	b, g := setup(t, `
+		step "http" "counted" {
+			count = 3
+			arguments {
+				url = "https://example.com/delay/${count.index + 1}"
+			}
+		}
+		step "http" "keyed" {
+			for_each = { eu = "https://eu.example.com", us = "https://us.example.com" }
+			arguments {
+				url = "${each.value}/${each.key}"
+			}
+		}
+	`)
+	ctx := testContext()
+
+	tk, err := b.Build(ctx, mustNode(t, g, "step.http.counted[2]"), g)
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/delay/3", tk.ResolvedInputs["url"].AsString())
+
+	tk, err = b.Build(ctx, mustNode(t, g, `step.http.keyed["us"]`), g)
+	require.NoError(t, err)
+	assert.Equal(t, "https://us.example.com/us", tk.ResolvedInputs["url"].AsString())
+}
+
+func TestBuild_Variables(t *testing.T) {
+	grid := `
+		variable "host" {}
+		variable "retries" {}
+		step "http" "call" {
+			arguments {
+				url     = "https://${var.host}/"
+				retries = var.retries
+			}
+		}
+	`
+	b, g := setup(t, grid, WithVariables(cty.ObjectVal(map[string]cty.Value{
+		"host":    cty.StringVal("example.com"),
+		"retries": cty.NumberIntVal(5),
+	})))
+
+	tk, err := b.Build(testContext(), mustNode(t, g, "step.http.call"), g)
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/", tk.ResolvedInputs["url"].AsString())
+	assert.True(t, cty.NumberIntVal(5).RawEquals(tk.ResolvedInputs["retries"]))
+
+	b, g = setup(t, grid)
+	_, err = b.Build(testContext(), mustNode(t, g, "step.http.call"), g)
+	require.Error(t, err, "without values, var is an empty object")
+	assert.Contains(t, err.Error(), "Unsupported attribute")
+}
+
+func TestBuild_Locals(t *testing.T) {
+	grid := `
+		variable "host" {}
+		locals {
+			base = "https://${var.host}"
+			url  = "${local.base}/?token=${step.source.auth.output.token}"
+		}
+		step "source" "auth" {}
+		step "http" "call" {
+			arguments {
+				url = local.url
+			}
+		}
+	`
+	vars := cty.ObjectVal(map[string]cty.Value{"host": cty.StringVal("example.com")})
+	ctx := testContext()
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.hcl"), []byte(grid), 0644))
+	parsed, err := model.LoadGridsRecursively(ctx, dir)
+	require.NoError(t, err)
+	set, diags := locals.Analyze(parsed.Locals)
+	require.False(t, diags.HasErrors(), diags.Error())
+	vals, diags := set.Evaluate(vars)
+	require.False(t, diags.HasErrors(), diags.Error())
+
+	b, g := setup(t, grid, WithVariables(vars), WithLocals(vals))
+	src := mustNode(t, g, "step.source.auth")
+	require.NoError(t, g.MarkCompleted(ctx, src.ID, cty.ObjectVal(map[string]cty.Value{
+		"token": cty.StringVal("abc123"),
+	})))
+
+	tk, err := b.Build(ctx, mustNode(t, g, "step.http.call"), g)
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/?token=abc123", tk.ResolvedInputs["url"].AsString())
+}
+
+func TestBuild_Functions(t *testing.T) {
+	b, g := setup(t, `
+		step "source" "auth" {}
+		step "http" "call" {
+			arguments {
+				url = format("https://example.com/?token=%s", urlencode(step.source.auth.output.token))
+			}
+		}
+	`)
+	ctx := testContext()
+	src := mustNode(t, g, "step.source.auth")
+	require.NoError(t, g.MarkCompleted(ctx, src.ID, cty.ObjectVal(map[string]cty.Value{
+		"token": cty.StringVal("a b&c"),
+	})))
+
+	tk, err := b.Build(ctx, mustNode(t, g, "step.http.call"), g)
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/?token=a+b%26c", tk.ResolvedInputs["url"].AsString())
+}
+
+func TestBuild_ComplexInputTypes(t *testing.T) {
+	b, g := setup(t, `
+		step "deploy" "all" {
+			arguments {
+				targets = [
+					{ url = "https://a.example.com" },
+					{ url = "https://b.example.com", retries = 5 },
+				]
+			}
+		}
+	`)
+
+	tk, err := b.Build(testContext(), mustNode(t, g, "step.deploy.all"), g)
+	require.NoError(t, err)
+	targets := tk.ResolvedInputs["targets"]
+	require.True(t, targets.Type().IsListType(), "the tuple argument is converted to the declared list type")
+	assert.True(t, cty.NumberIntVal(3).Equals(targets.Index(cty.NumberIntVal(0)).GetAttr("retries")).True(), "optional attributes take their defaults")
+	assert.True(t, cty.NumberIntVal(5).Equals(targets.Index(cty.NumberIntVal(1)).GetAttr("retries")).True())
+}
+
+func TestBuild_ResolvesSingleInstanceOutput(t *testing.T) {
+	b, g := setup(t, `
+		step "source" "auth" {
+			count = 3
+		}
+		step "http" "call" {
+			arguments {
+				url = "https://example.com/?token=${step.source.auth[1].output.token}"
+			}
+		}
+	`)
+	ctx := testContext()
+	src := mustNode(t, g, "step.source.auth[1]")
+	require.NoError(t, g.MarkCompleted(ctx, src.ID, cty.ObjectVal(map[string]cty.Value{
+		"token": cty.StringVal("second"),
+	})))
+
+	tk, err := b.Build(ctx, mustNode(t, g, "step.http.call"), g)
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/?token=second", tk.ResolvedInputs["url"].AsString())
+}
+
+// evalIn evaluates src in the evaluation context built for node id.
+func evalIn(t *testing.T, b Builder, g graph.Graph, id, src string) cty.Value {
+	t.Helper()
+	evalCtx, err := b.EvalContext(testContext(), mustNode(t, g, id), g)
+	require.NoError(t, err)
+	expr, diags := hclsyntax.ParseExpression([]byte(src), "test.hcl", hcl.InitialPos)
+	require.False(t, diags.HasErrors(), diags.Error())
+	val, diags := expr.Value(evalCtx)
+	require.False(t, diags.HasErrors(), diags.Error())
+	return val
+}
+
+func completeWithToken(t *testing.T, g graph.Graph, id, token string) {
+	t.Helper()
+	require.NoError(t, g.MarkCompleted(testContext(), mustNode(t, g, id).ID, cty.ObjectVal(map[string]cty.Value{
+		"token": cty.StringVal(token),
+	})))
+}
+
+func TestEvalContext_SplatFanIn(t *testing.T) {
+	b, g := setup(t, `
+		step "source" "auth" {
+			count = 3
+		}
+		step "source" "keyed" {
+			for_each = ["eu", "us"]
+		}
+		step "source" "none" {
+			count = 0
+		}
+		step "http" "collect" {
+			depends_on = [step.source.auth, step.source.keyed, step.source.none]
+		}
+	`)
+	for i, token := range []string{"a", "b", "c"} {
+		completeWithToken(t, g, fmt.Sprintf("step.source.auth[%d]", i), token)
+	}
+	completeWithToken(t, g, `step.source.keyed["eu"]`, "eu-token")
+	completeWithToken(t, g, `step.source.keyed["us"]`, "us-token")
+
+	tokens := evalIn(t, b, g, "step.http.collect", "step.source.auth[*].output.token")
+	assert.True(t, cty.TupleVal([]cty.Value{
+		cty.StringVal("a"), cty.StringVal("b"), cty.StringVal("c"),
+	}).RawEquals(tokens), "got %#v", tokens)
+
+	keyed := evalIn(t, b, g, "step.http.collect", `step.source.keyed["us"].output.token`)
+	assert.Equal(t, "us-token", keyed.AsString())
+
+	keyedTokens := evalIn(t, b, g, "step.http.collect", "values(step.source.keyed)[*].output.token")
+	assert.True(t, cty.TupleVal([]cty.Value{
+		cty.StringVal("eu-token"), cty.StringVal("us-token"),
+	}).RawEquals(keyedTokens), "for_each instances fan in through values(), in key order; got %#v", keyedTokens)
+
+	none := evalIn(t, b, g, "step.http.collect", "step.source.none[*].output")
+	assert.Equal(t, 0, none.LengthInt())
+}
+
+func TestEvalContext_SplatOverDynamicGroup(t *testing.T) {
+	b, g := setup(t, `
+		step "source" "config" {}
+		step "source" "auth" {
+			count = step.source.config.output.token == "" ? 0 : 2
+		}
+		step "http" "collect" {
+			arguments {
+				url = step.source.auth[*].output.token
+			}
+		}
+	`)
+	ctx := testContext()
+	completeWithToken(t, g, "step.source.config", "x")
+
+	placeholder := mustNode(t, g, "step.source.auth")
+	evalCtx, err := b.EvalContext(ctx, placeholder, g)
+	require.NoError(t, err)
+	instances, err := compiler.Instances(placeholder, evalCtx)
+	require.NoError(t, err)
+	require.NoError(t, g.Expand(ctx, placeholder.ID, instances))
+	require.NoError(t, g.MarkCompleted(ctx, placeholder.ID, nil))
+	completeWithToken(t, g, "step.source.auth[0]", "first")
+	completeWithToken(t, g, "step.source.auth[1]", "second")
+
+	tokens := evalIn(t, b, g, "step.http.collect", "step.source.auth[*].output.token")
+	assert.True(t, cty.TupleVal([]cty.Value{
+		cty.StringVal("first"), cty.StringVal("second"),
+	}).RawEquals(tokens), "got %#v", tokens)
+}
@@ -48,14 +48,15 @@
 //
 // # Typical Implementation
 //
-// See DefaultBuilder for the reference implementation. A complete implementation
-// would use internal/bggoexpr to evaluate expressions against a context containing
-// all completed node outputs.
+// See DefaultBuilder for the reference implementation. It evaluates each step
+// argument against an hcl.EvalContext holding the outputs of the node's completed
+// dependencies, then checks the result against the runner's declared inputs.
 package builder
 
 import (
 	"context"
 
+	"github.com/hashicorp/hcl/v2"
 	"github.com/specialistvlad/burstgridgo/internal/graph"
 	"github.com/specialistvlad/burstgridgo/internal/node"
 	"github.com/specialistvlad/burstgridgo/internal/task"
@@ -110,11 +111,16 @@ type Builder interface {
 	//   - Task with fully-resolved inputs
 	//   - Error if expression resolution fails or dependencies are missing
 	//
-	// # Current Implementation Note
+	// Thread-safety: Must be safe to call concurrently for different nodes.
+	Build(ctx context.Context, n *node.Node, g graph.Graph) (*task.Task, error)
+
+	// EvalContext returns the evaluation context used to resolve the node's
+	// expressions. It exposes the outputs of the node's completed dependencies
+	// under `step.<runner>.<name>.output`.
 	//
-	// DefaultBuilder currently returns an empty Task with no input resolution (placeholder).
-	// A complete implementation would use internal/bggoexpr to evaluate expressions.
+	// Build uses it for arguments; other components use it to evaluate step
+	// attributes that are resolved at run time.
 	//
 	// Thread-safety: Must be safe to call concurrently for different nodes.
-	Build(ctx context.Context, n *node.Node, g graph.Graph) (*task.Task, error)
+	EvalContext(ctx context.Context, n *node.Node, g graph.Graph) (*hcl.EvalContext, error)
 }
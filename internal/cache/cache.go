@@ -0,0 +1,94 @@
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+// Scopes accepted by the `scope` attribute of `cache` and `dedupe` blocks.
+const (
+	ScopeRun    = "run"
+	ScopeGlobal = "global"
+)
+
+// Entry is a cached step output.
+type Entry struct {
+	Output cty.Value
+
+	// ExpiresAt is when the entry stops being served; zero means never.
+	ExpiresAt time.Time
+}
+
+// Expired reports whether the entry has expired at now.
+func (e Entry) Expired(now time.Time) bool {
+	return !e.ExpiresAt.IsZero() && !now.Before(e.ExpiresAt)
+}
+
+// Store is a cache backend. Implementations must be safe for concurrent use.
+type Store interface {
+	// Get returns the entry stored under key. Expired entries are reported as
+	// missing and may be removed.
+	Get(ctx context.Context, key string) (Entry, bool, error)
+
+	// Put stores an entry under key, replacing any previous one.
+	Put(ctx context.Context, key string, entry Entry) error
+}
+
+// Key derives the cache key of a step from its evaluated `key` (possibly
+// empty), its runner type and its resolved inputs.
+func Key(userKey, runnerType string, inputs map[string]cty.Value) (string, error) {
+	inputHash, err := HashInputs(inputs)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.New()
+	for _, part := range []string{userKey, runnerType, inputHash} {
+		fmt.Fprintf(sum, "%d:%s;", len(part), part)
+	}
+	return hex.EncodeToString(sum.Sum(nil)), nil
+}
+
+// HashInputs returns a stable hash of a step's resolved inputs. Inputs are
+// hashed by name in sorted order, each with its type, so that equal values of
+// different types hash differently. Marks do not affect the hash.
+func HashInputs(inputs map[string]cty.Value) (string, error) {
+	names := make([]string, 0, len(inputs))
+	for name := range inputs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	sum := sha256.New()
+	for _, name := range names {
+		val, _ := inputs[name].UnmarkDeep()
+		typ, err := ctyjson.MarshalType(val.Type())
+		if err != nil {
+			return "", fmt.Errorf("input %q: %w", name, err)
+		}
+		js, err := ctyjson.Marshal(val, val.Type())
+		if err != nil {
+			return "", fmt.Errorf("input %q: %w", name, err)
+		}
+		fmt.Fprintf(sum, "%d:%s;%d:%s;%d:%s;", len(name), name, len(typ), typ, len(js), js)
+	}
+	return hex.EncodeToString(sum.Sum(nil)), nil
+}
+
+// DefaultDir returns the directory used by the on-disk cache when none is
+// configured: a "burstgridgo" directory in the user's cache directory, or
+// ".burstgridgo-cache" in the working directory if there is none.
+func DefaultDir() string {
+	if dir, err := os.UserCacheDir(); err == nil {
+		return filepath.Join(dir, "burstgridgo")
+	}
+	return ".burstgridgo-cache"
+}
@@ -0,0 +1,133 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/specialistvlad/burstgridgo/internal/sensitive"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestStores(t *testing.T) {
+	output := cty.ObjectVal(map[string]cty.Value{
+		"body":   cty.StringVal("ok"),
+		"status": cty.NumberIntVal(200),
+		"tags":   cty.ListVal([]cty.Value{cty.StringVal("a")}),
+	})
+
+	backends := []struct {
+		name string
+		new  func(t *testing.T, now func() time.Time) Store
+	}{
+		{
+			name: "memory",
+			new: func(t *testing.T, now func() time.Time) Store {
+				m := NewMemory()
+				m.now = now
+				return m
+			},
+		},
+		{
+			name: "disk",
+			new: func(t *testing.T, now func() time.Time) Store {
+				d := NewDisk(t.TempDir())
+				d.now = now
+				return d
+			},
+		},
+	}
+	for _, b := range backends {
+		t.Run(b.name, func(t *testing.T) {
+			ctx := context.Background()
+			clock := time.Unix(1000, 0)
+			s := b.new(t, func() time.Time { return clock })
+
+			_, ok, err := s.Get(ctx, "missing")
+			require.NoError(t, err)
+			assert.False(t, ok)
+
+			require.NoError(t, s.Put(ctx, "forever", Entry{Output: output}))
+			require.NoError(t, s.Put(ctx, "short", Entry{Output: output, ExpiresAt: clock.Add(time.Minute)}))
+
+			got, ok, err := s.Get(ctx, "forever")
+			require.NoError(t, err)
+			require.True(t, ok)
+			assert.True(t, output.RawEquals(got.Output), "got %#v", got.Output)
+
+			_, ok, err = s.Get(ctx, "short")
+			require.NoError(t, err)
+			assert.True(t, ok, "entry is served before its TTL")
+
+			clock = clock.Add(time.Minute)
+			_, ok, err = s.Get(ctx, "short")
+			require.NoError(t, err)
+			assert.False(t, ok, "entry expires at its TTL")
+			_, ok, err = s.Get(ctx, "forever")
+			require.NoError(t, err)
+			assert.True(t, ok)
+		})
+	}
+}
+
+func TestDisk_PersistsAcrossInstances(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir() + "/nested/cache"
+	require.NoError(t, NewDisk(dir).Put(ctx, "k", Entry{Output: cty.StringVal("v")}))
+
+	got, ok, err := NewDisk(dir).Get(ctx, "k")
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, cty.StringVal("v"), got.Output)
+}
+
+func TestDisk_Sensitive(t *testing.T) {
+	ctx := context.Background()
+	d := NewDisk(t.TempDir())
+	output := cty.ObjectVal(map[string]cty.Value{
+		"user":  cty.StringVal("alice"),
+		"token": cty.StringVal("s3cr3t").Mark(sensitive.Mark),
+	})
+	require.NoError(t, d.Put(ctx, "k", Entry{Output: output}))
+
+	got, ok, err := d.Get(ctx, "k")
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.True(t, got.Output.HasMark(sensitive.Mark), "sensitivity survives the round trip")
+	unmarked, _ := got.Output.Unmark()
+	assert.Equal(t, cty.StringVal("s3cr3t"), unmarked.GetAttr("token"))
+}
+
+func TestKey(t *testing.T) {
+	inputs := map[string]cty.Value{"url": cty.StringVal("https://example.com"), "n": cty.NumberIntVal(1)}
+	base, err := Key("", "http", inputs)
+	require.NoError(t, err)
+
+	same, err := Key("", "http", map[string]cty.Value{"n": cty.NumberIntVal(1), "url": cty.StringVal("https://example.com")})
+	require.NoError(t, err)
+	assert.Equal(t, base, same, "input order does not matter")
+
+	marked, err := Key("", "http", map[string]cty.Value{"url": cty.StringVal("https://example.com").Mark(sensitive.Mark), "n": cty.NumberIntVal(1)})
+	require.NoError(t, err)
+	assert.Equal(t, base, marked, "marks do not matter")
+
+	variants := map[string]func() (string, error){
+		"user key":    func() (string, error) { return Key("v2", "http", inputs) },
+		"runner type": func() (string, error) { return Key("", "grpc", inputs) },
+		"input value": func() (string, error) {
+			return Key("", "http", map[string]cty.Value{"url": cty.StringVal("https://example.org"), "n": cty.NumberIntVal(1)})
+		},
+		"input type": func() (string, error) {
+			return Key("", "http", map[string]cty.Value{"url": cty.StringVal("https://example.com"), "n": cty.StringVal("1")})
+		},
+	}
+	for name, fn := range variants {
+		t.Run(name, func(t *testing.T) {
+			k, err := fn()
+			require.NoError(t, err)
+			assert.NotEqual(t, base, k)
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/specialistvlad/burstgridgo/internal/sensitive"
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+// Disk is a Store that keeps one JSON file per entry in a directory, so that
+// entries survive across runs. The directory is created on first write and,
+// since outputs may be sensitive, is readable by its owner only. An output
+// with sensitive parts is read back as sensitive as a whole.
+type Disk struct {
+	dir string
+	now func() time.Time
+}
+
+// NewDisk creates an on-disk store rooted at dir.
+func NewDisk(dir string) *Disk {
+	return &Disk{dir: dir, now: time.Now}
+}
+
+// diskEntry is the file format of an entry.
+type diskEntry struct {
+	Type      json.RawMessage `json:"type"`
+	Output    json.RawMessage `json:"output"`
+	ExpiresAt time.Time       `json:"expires_at,omitzero"`
+	Sensitive bool            `json:"sensitive,omitempty"`
+}
+
+func (d *Disk) path(key string) string {
+	return filepath.Join(d.dir, key+".json")
+}
+
+// Get implements Store.
+func (d *Disk) Get(ctx context.Context, key string) (Entry, bool, error) {
+	data, err := os.ReadFile(d.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return Entry{}, false, nil
+	}
+	if err != nil {
+		return Entry{}, false, fmt.Errorf("failed to read cache entry: %w", err)
+	}
+
+	var de diskEntry
+	if err := json.Unmarshal(data, &de); err != nil {
+		return Entry{}, false, fmt.Errorf("failed to decode cache entry: %w", err)
+	}
+	if !de.ExpiresAt.IsZero() && !d.now().Before(de.ExpiresAt) {
+		// Best effort: an expired entry is a miss whether or not it is removed.
+		_ = os.Remove(d.path(key))
+		return Entry{}, false, nil
+	}
+	typ, err := ctyjson.UnmarshalType(de.Type)
+	if err != nil {
+		return Entry{}, false, fmt.Errorf("failed to decode cache entry type: %w", err)
+	}
+	output, err := ctyjson.Unmarshal(de.Output, typ)
+	if err != nil {
+		return Entry{}, false, fmt.Errorf("failed to decode cache entry output: %w", err)
+	}
+	if de.Sensitive {
+		output = output.Mark(sensitive.Mark)
+	}
+	return Entry{Output: output, ExpiresAt: de.ExpiresAt}, true, nil
+}
+
+// Put implements Store. The entry is written to a temporary file and renamed
+// into place, so concurrent readers never see a partial entry.
+func (d *Disk) Put(ctx context.Context, key string, entry Entry) error {
+	output := entry.Output
+	if output == cty.NilVal {
+		output = cty.NullVal(cty.DynamicPseudoType)
+	}
+	marked := sensitive.Marked(output)
+	output, _ = output.UnmarkDeep()
+	typ, err := ctyjson.MarshalType(output.Type())
+	if err != nil {
+		return fmt.Errorf("failed to encode cache entry type: %w", err)
+	}
+	js, err := ctyjson.Marshal(output, output.Type())
+	if err != nil {
+		return fmt.Errorf("failed to encode cache entry output: %w", err)
+	}
+	data, err := json.Marshal(diskEntry{Type: typ, Output: js, ExpiresAt: entry.ExpiresAt, Sensitive: marked})
+	if err != nil {
+		return fmt.Errorf("failed to encode cache entry: %w", err)
+	}
+
+	if err := os.MkdirAll(d.dir, 0o700); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(d.dir, key+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), d.path(key)); err != nil {
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,18 @@
+// Package cache stores step outputs so that a step whose inputs have not
+// changed can be completed without running it again.
+//
+//	step "http_request" "token" {
+//	  cache {
+//	    enabled = true                 # default true
+//	    key     = "token-${var.env}"   # optional, added to the runner type and input hash
+//	    ttl     = "15m"                # optional; entries never expire without it
+//	    scope   = "global"             # run | global (default)
+//	    restore = true                 # false runs the step and refreshes the entry
+//	  }
+//	}
+//
+// Entries are addressed by Key, a hash of the evaluated `key`, the runner
+// type and the step's resolved inputs. A "run" scope uses a Memory store that
+// lives as long as the run; a "global" scope uses a Disk store that persists
+// entries across runs under a configurable directory.
+package cache
@@ -0,0 +1,42 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Memory is an in-process Store. Its entries are lost when the process exits.
+type Memory struct {
+	mu      sync.Mutex
+	entries map[string]Entry
+	now     func() time.Time
+}
+
+// NewMemory creates an empty in-memory store.
+func NewMemory() *Memory {
+	return &Memory{entries: make(map[string]Entry), now: time.Now}
+}
+
+// Get implements Store.
+func (m *Memory) Get(ctx context.Context, key string) (Entry, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry, ok := m.entries[key]
+	if !ok {
+		return Entry{}, false, nil
+	}
+	if entry.Expired(m.now()) {
+		delete(m.entries, key)
+		return Entry{}, false, nil
+	}
+	return entry, true, nil
+}
+
+// Put implements Store.
+func (m *Memory) Put(ctx context.Context, key string, entry Entry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries[key] = entry
+	return nil
+}
@@ -21,6 +21,20 @@ func (e *ExitError) Error() string {
 	return e.Message
 }
 
+// stringList is a flag.Value collecting every occurrence of a repeatable flag.
+type stringList []string
+
+// String implements flag.Value.
+func (l *stringList) String() string {
+	return strings.Join(*l, ", ")
+}
+
+// Set implements flag.Value.
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
 // Parse processes command-line arguments. It returns a populated AppConfig,
 // a boolean indicating if the program should exit cleanly, or an ExitError.
 func Parse(args []string, output io.Writer) (*app.Config, bool, error) {
@@ -52,6 +66,10 @@ Options:
 	logLevelFlag := flagSet.String("log-level", "info", "Set the logging level. Options: 'debug', 'info', 'warn', 'error'.")
 	workersFlag := flagSet.Int("workers", 10, "Number of concurrent workers for the executor.")
 	modulesPathFlag := flagSet.String("modules-path", "modules", "Path to the directory containing module definitions.")
+	cacheDirFlag := flagSet.String("cache-dir", "", "Directory for the persistent step output cache and idempotency records. Defaults to the user cache directory.")
+	var varFlags, varFileFlags stringList
+	flagSet.Var(&varFlags, "var", "Set a grid variable as name=value. May be repeated; overrides BGGO_VAR_<name> environment variables.")
+	flagSet.Var(&varFileFlags, "var-file", "Set grid variables from an HCL or JSON file of name = value pairs. May be repeated; overrides --var.")
 
 	if err := flagSet.Parse(args); err != nil {
 		if err == flag.ErrHelp {
@@ -98,6 +116,9 @@ Options:
 		LogFormat:       logFormat,
 		LogLevel:        logLevel,
 		WorkerCount:     *workersFlag,
+		CacheDir:        *cacheDirFlag,
+		Vars:            varFlags,
+		VarFiles:        varFileFlags,
 	})
 
 	if err != nil {
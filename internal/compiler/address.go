@@ -0,0 +1,38 @@
+package compiler
+
+import (
+	"github.com/hashicorp/hcl/v2"
+	"github.com/specialistvlad/burstgridgo/internal/nodeid"
+)
+
+// stepAddress returns the canonical node address of a step.
+func stepAddress(runnerType, name string) nodeid.Address {
+	return nodeid.Address{Path: []nodeid.PathSegment{
+		nodeid.NewPathSegment(stepRoot),
+		nodeid.NewPathSegment(runnerType),
+		nodeid.NewPathSegment(name),
+	}}
+}
+
+// traversalStepAddress extracts the step address from a traversal such as
+// `step.http_request.first.output.body`. Anything after the step name is
+// ignored. It reports false if the traversal does not name a step.
+func traversalStepAddress(trav hcl.Traversal) (nodeid.Address, bool) {
+	if len(trav) < 3 || trav.RootName() != stepRoot {
+		return nodeid.Address{}, false
+	}
+	runner, ok := trav[1].(hcl.TraverseAttr)
+	if !ok {
+		return nodeid.Address{}, false
+	}
+	name, ok := trav[2].(hcl.TraverseAttr)
+	if !ok {
+		return nodeid.Address{}, false
+	}
+	return stepAddress(runner.Name, name.Name), true
+}
+
+// plain reports whether a path segment has neither an index nor a key.
+func plain(seg nodeid.PathSegment) bool {
+	return !seg.HasIndex() && !seg.HasKey()
+}
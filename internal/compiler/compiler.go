@@ -0,0 +1,587 @@
+// Package compiler turns a parsed model.Grid into an executable DAG stored in a
+// topologystore.Store.
+//
+// # Responsibilities
+//
+//   - **Node Creation:** One node.Node per step, addressed as `step.<runner>.<name>`
+//   - **Instancing:** Steps with a static `count` or `for_each` are expanded into
+//     one node per instance, addressed as `step.<runner>.<name>[<i>]`; for_each
+//     instances are ordered by key. A `count` or `for_each` that references
+//     other steps yields a single placeholder node that the executor expands
+//     at runtime (see Instances)
+//   - **Explicit Edges:** Derived from each step's `depends_on` list
+//   - **Implicit Edges:** Derived from `step.<runner>.<name>...` references found
+//     in the step's expressions (e.g., `step.http_request.first.output.body`),
+//     including those made through the `local.<name>` values it uses
+//   - **Validation:** Rejecting duplicate steps, references to unknown steps,
+//     undeclared variables, locals or resources, step references in a
+//     resource's `env`, and dependency cycles (between steps or between
+//     locals) with HCL diagnostics that point at the offending source
+//
+// # Accepted depends_on Forms
+//
+// Each element of `depends_on` may be either a traversal or a string:
+//
+//	depends_on = [
+//	  step.http_request.first,     // traversal
+//	  "step.http_request.second",  // fully-qualified string
+//	  "http_request.third",        // shorthand string, "step." is implied
+//	  "http_request.fourth[7]",    // a single instance of a `count` step
+//	  "http_request.fifth[\"eu\"]",  // a single instance of a `for_each` step
+//	]
+//
+// Referencing an instanced step without an index (e.g., `step.http_request.fourth`
+// or `step.http_request.fourth[*].output`) depends on every instance. Shorthand
+// access such as `step.http_request.fourth.output` is rejected as ambiguous.
+//
+// The instances of a `for_each` step are keyed by string, and a splat only
+// iterates lists, so `step.http_request.fifth[*].output` is rejected too; fan
+// in with `values(step.http_request.fifth)[*].output` instead.
+//
+// # All-or-Nothing
+//
+// Compile validates the whole grid before touching the store. If any error
+// diagnostic is produced the store is left unchanged, so a session never starts
+// with a partially-built graph.
+package compiler
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/specialistvlad/burstgridgo/internal/bggoexpr"
+	"github.com/specialistvlad/burstgridgo/internal/ctxlog"
+	"github.com/specialistvlad/burstgridgo/internal/locals"
+	"github.com/specialistvlad/burstgridgo/internal/model"
+	"github.com/specialistvlad/burstgridgo/internal/node"
+	"github.com/specialistvlad/burstgridgo/internal/nodeid"
+	"github.com/specialistvlad/burstgridgo/internal/topologystore"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// stepRoot is the traversal root name and address prefix for steps.
+const stepRoot = "step"
+
+// varRoot is the traversal root name of grid variables.
+const varRoot = "var"
+
+// localRoot is the traversal root name of grid locals.
+const localRoot = "local"
+
+// resourceRoot is the traversal root name of grid resources.
+const resourceRoot = "resource"
+
+// edge is a dependency of `to` on `from`, together with the source range of the
+// expression that declared it.
+type edge struct {
+	from, to string
+	rng      hcl.Range
+}
+
+// compilation holds the intermediate state of a single Compile call.
+type compilation struct {
+	groups map[string]*group // keyed by step address
+	steps  []string          // step addresses in declaration order
+	nodes  map[string]*node.Node
+	order  []string // node IDs in declaration order
+	edges  []edge
+	vars   map[string]struct{} // declared variable names
+	res    map[string]struct{} // declared resource addresses
+	locals *locals.Set
+	diags  hcl.Diagnostics
+}
+
+// Compile validates the grid and, if it is valid, populates store with one node
+// per step instance and one edge per dependency.
+func Compile(ctx context.Context, grid *model.Grid, store topologystore.Store) hcl.Diagnostics {
+	logger := ctxlog.FromContext(ctx)
+
+	c := &compilation{
+		groups: make(map[string]*group),
+		nodes:  make(map[string]*node.Node),
+		vars:   make(map[string]struct{}, len(grid.Variables)),
+		res:    make(map[string]struct{}, len(grid.Resources)),
+	}
+	for _, v := range grid.Variables {
+		c.vars[v.Name] = struct{}{}
+	}
+	for _, r := range grid.Resources {
+		c.res[r.Address()] = struct{}{}
+	}
+	c.locals, c.diags = locals.Analyze(grid.Locals)
+	if c.diags.HasErrors() {
+		return c.diags
+	}
+	c.addNodes(grid)
+	if c.diags.HasErrors() {
+		return c.diags
+	}
+	for _, id := range c.steps {
+		c.addEdges(c.groups[id])
+	}
+	if c.diags.HasErrors() {
+		return c.diags
+	}
+	for _, l := range grid.Locals {
+		c.checkReferences(l.Expr.Variables())
+		c.checkSplats(bggoexpr.SplatSources(l.Expr))
+	}
+	for _, r := range grid.Resources {
+		c.checkResourceEnv(r)
+	}
+	for _, id := range c.steps {
+		c.checkReferences(c.groups[id].step.Expressions.References())
+		c.checkSplats(c.groups[id].step.Expressions.SplatSources())
+	}
+	if c.diags.HasErrors() {
+		return c.diags
+	}
+	c.checkCycles()
+	if c.diags.HasErrors() {
+		return c.diags
+	}
+
+	for _, id := range c.order {
+		n := c.nodes[id]
+		if err := store.AddNode(ctx, n); err != nil {
+			return c.diags.Append(storeDiag(n, err))
+		}
+	}
+	for _, e := range c.edges {
+		if err := store.AddDependency(ctx, c.nodes[e.from].ID, c.nodes[e.to].ID); err != nil {
+			return c.diags.Append(&hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Failed to add dependency",
+				Detail:   err.Error(),
+				Subject:  e.rng.Ptr(),
+			})
+		}
+	}
+
+	logger.Debug("Grid compiled.", "steps", len(c.steps), "nodes", len(c.order), "edges", len(c.edges))
+	return c.diags
+}
+
+// addNodes expands every step into its nodes and rejects duplicate steps.
+func (c *compilation) addNodes(grid *model.Grid) {
+	for _, step := range grid.Steps {
+		addr := stepAddress(step.RunnerType, step.Name)
+		id := addr.String()
+		if prev, exists := c.groups[id]; exists {
+			c.diags = c.diags.Append(&hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Duplicate step",
+				Detail: fmt.Sprintf("A step named %q with runner %q was already declared at %s.",
+					step.Name, step.RunnerType, prev.step.DeclRange.String()),
+				Subject: step.DeclRange.Ptr(),
+			})
+			continue
+		}
+
+		g, diags := expand(step)
+		c.diags = append(c.diags, diags...)
+		if g == nil {
+			continue
+		}
+		c.groups[id] = g
+		c.steps = append(c.steps, id)
+		for _, n := range g.nodes {
+			nid := n.ID.String()
+			c.nodes[nid] = n
+			c.order = append(c.order, nid)
+		}
+	}
+}
+
+// target is a resolved dependency: the node IDs it stands for and the source
+// range of the expression that declared it.
+type target struct {
+	ids []string
+	rng hcl.Range
+}
+
+// addEdges collects every dependency of a step, from depends_on, expression
+// references and the step references of the locals it uses, and records them
+// on each of the step's nodes.
+func (c *compilation) addEdges(g *group) {
+	targets := c.dependsOnTargets(g, g.step.DependsOn)
+	refs := slices.Clone(g.step.Expressions.References())
+	refs = append(refs, c.locals.StepReferences(locals.Referenced(refs))...)
+	for _, trav := range refs {
+		if trav.RootName() != stepRoot {
+			continue
+		}
+		if t, ok := c.resolveTraversal(g, trav); ok {
+			targets = append(targets, t)
+		}
+	}
+
+	for _, n := range g.nodes {
+		seen := make(map[string]struct{})
+		for _, t := range targets {
+			for _, id := range t.ids {
+				if _, dup := seen[id]; dup {
+					continue
+				}
+				seen[id] = struct{}{}
+				n.Dependencies = append(n.Dependencies, id)
+				c.edges = append(c.edges, edge{from: id, to: n.ID.String(), rng: t.rng})
+			}
+		}
+	}
+}
+
+// checkReferences rejects `var.<name>`, `local.<name>` and
+// `resource.<type>.<name>` references to undeclared variables, locals and
+// resources.
+func (c *compilation) checkReferences(refs []hcl.Traversal) {
+	for _, trav := range refs {
+		switch trav.RootName() {
+		case varRoot:
+			c.checkVariable(trav)
+		case localRoot:
+			if d := c.locals.CheckReference(trav); d != nil {
+				c.diags = c.diags.Append(d)
+			}
+		case resourceRoot:
+			c.checkResource(trav)
+		}
+	}
+}
+
+// checkResource rejects a `resource.<type>.<name>` traversal to an undeclared
+// resource.
+func (c *compilation) checkResource(trav hcl.Traversal) {
+	typ, name, ok := model.ParseResourceReference(trav)
+	if !ok {
+		c.diags = c.diags.Append(&hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Invalid resource reference",
+			Detail:   "A reference to a resource must be of the form resource.<type>.<name>.",
+			Subject:  trav.SourceRange().Ptr(),
+		})
+		return
+	}
+	addr := fmt.Sprintf("%s.%s.%s", resourceRoot, typ, name)
+	if _, declared := c.res[addr]; !declared {
+		c.diags = c.diags.Append(&hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Reference to undeclared resource",
+			Detail:   fmt.Sprintf("A resource named %s has not been declared. Declare it with a `resource` block.", addr),
+			Subject:  trav.SourceRange().Ptr(),
+		})
+	}
+}
+
+// checkResourceEnv validates the references in a resource's `env`. It is
+// evaluated for each step that uses the resource, but a resource is not a node
+// of the graph, so it may refer to variables and locals but not to steps,
+// directly or through a local.
+func (c *compilation) checkResourceEnv(r *model.Resource) {
+	if r.Env == nil {
+		return
+	}
+	refs := r.Env.Variables()
+	for _, trav := range refs {
+		switch trav.RootName() {
+		case varRoot:
+		case localRoot:
+			if len(c.locals.StepReferences(locals.Referenced([]hcl.Traversal{trav}))) > 0 {
+				c.diags = c.diags.Append(invalidResourceEnvRef(r, trav))
+			}
+		default:
+			c.diags = c.diags.Append(invalidResourceEnvRef(r, trav))
+		}
+	}
+	c.checkReferences(refs)
+}
+
+// invalidResourceEnvRef reports a reference that a resource's `env` may not
+// make.
+func invalidResourceEnvRef(r *model.Resource, trav hcl.Traversal) *hcl.Diagnostic {
+	return &hcl.Diagnostic{
+		Severity: hcl.DiagError,
+		Summary:  "Invalid reference in resource env",
+		Detail:   fmt.Sprintf("The env of %s may only refer to variables and to locals that do not depend on steps.", r.Address()),
+		Subject:  trav.SourceRange().Ptr(),
+	}
+}
+
+// checkSplats rejects splats over `for_each` steps. Their instances are an
+// object keyed by `each.key`, which a splat would treat as a single element.
+func (c *compilation) checkSplats(sources []hcl.Traversal) {
+	for _, trav := range sources {
+		if trav.RootName() != stepRoot || len(trav) != 3 {
+			continue
+		}
+		addr, ok := traversalStepAddress(trav)
+		if !ok {
+			continue
+		}
+		g, ok := c.groups[addr.String()]
+		if !ok || !g.forEach {
+			continue
+		}
+		c.diags = c.diags.Append(&hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Splat over a for_each step",
+			Detail: fmt.Sprintf("The instances of %s are keyed by string, so a splat cannot iterate them. Use values(%s)[*] to fan in over every instance, in key order.",
+				g.id, g.id),
+			Subject: trav.SourceRange().Ptr(),
+		})
+	}
+}
+
+// checkVariable rejects a `var.<name>` traversal to an undeclared variable.
+func (c *compilation) checkVariable(trav hcl.Traversal) {
+	if len(trav) >= 2 {
+		if attr, ok := trav[1].(hcl.TraverseAttr); ok {
+			if _, declared := c.vars[attr.Name]; declared {
+				return
+			}
+			c.diags = c.diags.Append(&hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Reference to undeclared variable",
+				Detail:   fmt.Sprintf("A variable named %q has not been declared. Declare it with a `variable` block.", attr.Name),
+				Subject:  trav.SourceRange().Ptr(),
+			})
+			return
+		}
+	}
+	c.diags = c.diags.Append(&hcl.Diagnostic{
+		Severity: hcl.DiagError,
+		Summary:  "Invalid variable reference",
+		Detail:   "A reference to a variable must be of the form var.<name>.",
+		Subject:  trav.SourceRange().Ptr(),
+	})
+}
+
+// resolveTraversal resolves a `step.<runner>.<name>...` traversal made from
+// step g into the nodes it refers to:
+//
+//   - `step.r.n[i]` or `step.r.n["key"]` refers to a single instance
+//   - `step.r.n` refers to every instance (e.g., the source of a splat)
+//   - `step.r.n.output` refers to a singular step; on an instanced step this
+//     shorthand is ambiguous and rejected (see ADR-012)
+func (c *compilation) resolveTraversal(from *group, trav hcl.Traversal) (target, bool) {
+	rng := trav.SourceRange()
+	addr, ok := traversalStepAddress(trav)
+	if !ok {
+		c.diags = c.diags.Append(&hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Invalid step reference",
+			Detail:   "A step reference must have the form step.<runner>.<name>.",
+			Subject:  rng.Ptr(),
+		})
+		return target{}, false
+	}
+	g, ok := c.lookup(from, addr.String(), rng)
+	if !ok {
+		return target{}, false
+	}
+
+	if len(trav) > 3 {
+		switch step := trav[3].(type) {
+		case hcl.TraverseIndex:
+			return c.instance(g, step.Key, rng)
+		case hcl.TraverseAttr:
+			if g.instanced {
+				detail := fmt.Sprintf("Step %s has multiple instances, so %s.%s is ambiguous. Select an instance with an index such as %s[0], or use a splat such as %s[*].%s.",
+					g.id, g.id, step.Name, g.id, g.id, step.Name)
+				if g.forEach {
+					detail = fmt.Sprintf("Step %s has multiple instances, so %s.%s is ambiguous. Select an instance with a key such as %s[\"key\"], or fan in with values(%s)[*].%s.",
+						g.id, g.id, step.Name, g.id, g.id, step.Name)
+				}
+				c.diags = c.diags.Append(&hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Missing instance key",
+					Detail:   detail,
+					Subject:  rng.Ptr(),
+				})
+				return target{}, false
+			}
+		}
+	}
+	return target{ids: g.ids(), rng: rng}, true
+}
+
+// lookup returns the step group with the given address, reporting a
+// diagnostic if no such step is declared.
+func (c *compilation) lookup(from *group, id string, rng hcl.Range) (*group, bool) {
+	g, ok := c.groups[id]
+	if !ok {
+		c.diags = c.diags.Append(&hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Reference to undeclared step",
+			Detail:   fmt.Sprintf("Step %s depends on %s, which is not declared in the grid.", from.id, id),
+			Subject:  rng.Ptr(),
+		})
+	}
+	return g, ok
+}
+
+// instance resolves a single instance of g selected by key: a number for
+// `count` steps or a string for `for_each` steps. Instances of a dynamic step
+// do not exist yet, so the reference depends on its placeholder and the key is
+// checked when the expression is evaluated.
+func (c *compilation) instance(g *group, key cty.Value, rng hcl.Range) (target, bool) {
+	if g.dynamic {
+		return target{ids: g.ids(), rng: rng}, true
+	}
+	i, err := g.instanceIndex(key)
+	if err != nil {
+		c.diags = c.diags.Append(&hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Invalid instance reference",
+			Detail:   err.Error(),
+			Subject:  rng.Ptr(),
+		})
+		return target{}, false
+	}
+	return target{ids: []string{g.nodes[i].ID.String()}, rng: rng}, true
+}
+
+// dependsOnTargets resolves the elements of a depends_on list into node IDs.
+func (c *compilation) dependsOnTargets(from *group, expr hcl.Expression) []target {
+	if expr == nil {
+		return nil
+	}
+	elems, diags := hcl.ExprList(expr)
+	c.diags = append(c.diags, diags...)
+
+	var targets []target
+	for _, elem := range elems {
+		if trav, travDiags := hcl.AbsTraversalForExpr(elem); !travDiags.HasErrors() {
+			if trav.RootName() != stepRoot {
+				c.diags = c.diags.Append(invalidDependsOn(elem))
+				continue
+			}
+			if t, ok := c.resolveTraversal(from, trav); ok {
+				t.rng = elem.Range()
+				targets = append(targets, t)
+			}
+			continue
+		}
+
+		val, valDiags := elem.Value(nil)
+		if valDiags.HasErrors() || !val.Type().Equals(cty.String) || val.IsNull() || !val.IsKnown() {
+			c.diags = c.diags.Append(invalidDependsOn(elem))
+			continue
+		}
+		raw := val.AsString()
+		if !strings.HasPrefix(raw, stepRoot+".") {
+			raw = stepRoot + "." + raw
+		}
+		addr, err := nodeid.Parse(raw)
+		if err != nil || len(addr.Path) != 3 || !plain(addr.Path[0]) || !plain(addr.Path[1]) {
+			c.diags = c.diags.Append(invalidDependsOn(elem))
+			continue
+		}
+
+		last := addr.Path[2]
+		base := stepAddress(addr.Path[1].Name, last.Name)
+		g, ok := c.lookup(from, base.String(), elem.Range())
+		if !ok {
+			continue
+		}
+		if plain(last) {
+			targets = append(targets, target{ids: g.ids(), rng: elem.Range()})
+			continue
+		}
+		key := cty.NumberIntVal(int64(last.Index))
+		if last.HasKey() {
+			key = cty.StringVal(last.Key)
+		}
+		if t, ok := c.instance(g, key, elem.Range()); ok {
+			targets = append(targets, t)
+		}
+	}
+	return targets
+}
+
+// checkCycles reports every dependency cycle in the compiled graph. Each cycle
+// is reported once, at the range of the edge that closes it.
+func (c *compilation) checkCycles() {
+	const (
+		white = iota // not visited
+		grey         // on the current DFS path
+		black        // fully explored
+	)
+
+	// Adjacency from dependent to dependency, sorted for deterministic output.
+	deps := make(map[string][]edge)
+	for _, e := range c.edges {
+		deps[e.to] = append(deps[e.to], e)
+	}
+	for _, list := range deps {
+		sort.Slice(list, func(i, j int) bool { return list[i].from < list[j].from })
+	}
+
+	color := make(map[string]int, len(c.order))
+	var path []string
+
+	var visit func(id string)
+	visit = func(id string) {
+		color[id] = grey
+		path = append(path, id)
+		for _, e := range deps[id] {
+			switch color[e.from] {
+			case white:
+				visit(e.from)
+			case grey:
+				c.diags = c.diags.Append(cycleDiag(path, e))
+			}
+		}
+		path = path[:len(path)-1]
+		color[id] = black
+	}
+
+	ids := append([]string(nil), c.order...)
+	sort.Strings(ids)
+	for _, id := range ids {
+		if color[id] == white {
+			visit(id)
+		}
+	}
+}
+
+// cycleDiag builds a diagnostic for the cycle closed by e on the current path.
+func cycleDiag(path []string, e edge) *hcl.Diagnostic {
+	start := 0
+	for i, id := range path {
+		if id == e.from {
+			start = i
+			break
+		}
+	}
+	cycle := append(append([]string(nil), path[start:]...), e.from)
+
+	return &hcl.Diagnostic{
+		Severity: hcl.DiagError,
+		Summary:  "Dependency cycle",
+		Detail:   fmt.Sprintf("Steps depend on each other in a cycle (each depends on the next): %s.", strings.Join(cycle, " -> ")),
+		Subject:  e.rng.Ptr(),
+	}
+}
+
+func invalidDependsOn(expr hcl.Expression) *hcl.Diagnostic {
+	return &hcl.Diagnostic{
+		Severity: hcl.DiagError,
+		Summary:  "Invalid depends_on entry",
+		Detail:   `Each depends_on entry must be a step reference such as step.<runner>.<name> or a string like "<runner>.<name>".`,
+		Subject:  expr.Range().Ptr(),
+	}
+}
+
+func storeDiag(n *node.Node, err error) *hcl.Diagnostic {
+	return &hcl.Diagnostic{
+		Severity: hcl.DiagError,
+		Summary:  "Failed to add step to graph",
+		Detail:   err.Error(),
+		Subject:  n.Config.DeclRange.Ptr(),
+	}
+}
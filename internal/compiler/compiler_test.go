@@ -0,0 +1,758 @@
+package compiler
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/specialistvlad/burstgridgo/internal/ctxlog"
+	"github.com/specialistvlad/burstgridgo/internal/inmemorytopology"
+	"github.com/specialistvlad/burstgridgo/internal/model"
+	"github.com/specialistvlad/burstgridgo/internal/node"
+	"github.com/specialistvlad/burstgridgo/internal/nodeid"
+	"github.com/specialistvlad/burstgridgo/internal/sensitive"
+	"github.com/specialistvlad/burstgridgo/internal/topologystore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func testContext() context.Context {
+	return ctxlog.WithLogger(context.Background(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+// loadGrid writes src to a temporary grid file and parses it.
+func loadGrid(t *testing.T, src string) *model.Grid {
+	t.Helper()
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.hcl"), []byte(src), 0644))
+	grid, err := model.LoadGridsRecursively(testContext(), dir)
+	require.NoError(t, err)
+	return grid
+}
+
+// compile compiles src into a fresh in-memory topology store.
+func compile(t *testing.T, src string) (topologystore.Store, hcl.Diagnostics) {
+	t.Helper()
+	store := inmemorytopology.New()
+	diags := Compile(testContext(), loadGrid(t, src), store)
+	return store, diags
+}
+
+// depsOf returns the sorted dependency IDs of id.
+func depsOf(t *testing.T, store topologystore.Store, id string) []string {
+	t.Helper()
+	addr, err := nodeid.Parse(id)
+	require.NoError(t, err)
+	deps, err := store.DependenciesOf(testContext(), *addr)
+	require.NoError(t, err)
+	ids := make([]string, 0, len(deps))
+	for _, d := range deps {
+		ids = append(ids, d.String())
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func TestCompile_CreatesOneNodePerStep(t *testing.T) {
+	store, diags := compile(t, `
+		step "print" "a" {}
+		step "http_request" "b" {}
+	`)
+	require.False(t, diags.HasErrors(), diags.Error())
+
+	nodes := store.AllNodes(testContext())
+	require.Len(t, nodes, 2)
+
+	addr, _ := nodeid.Parse("step.http_request.b")
+	n, ok := store.GetNode(testContext(), *addr)
+	require.True(t, ok)
+	assert.Equal(t, "http_request", n.Type)
+	require.NotNil(t, n.Config)
+	assert.Equal(t, "b", n.Config.Name)
+	assert.Empty(t, n.Dependencies)
+}
+
+func TestCompile_DependsOnForms(t *testing.T) {
+	store, diags := compile(t, `
+		step "print" "a" {}
+		step "print" "b" {}
+		step "print" "c" {}
+		step "print" "d" {
+			depends_on = [step.print.a, "step.print.b", "print.c"]
+		}
+	`)
+	require.False(t, diags.HasErrors(), diags.Error())
+
+	assert.Equal(t, []string{"step.print.a", "step.print.b", "step.print.c"}, depsOf(t, store, "step.print.d"))
+}
+
+func TestCompile_ImplicitDependenciesFromReferences(t *testing.T) {
+	store, diags := compile(t, `
+		step "http_request" "first" {}
+		step "print" "second" {
+			arguments {
+				input = step.http_request.first.output.body
+			}
+		}
+		step "print" "third" {
+			depends_on = [step.http_request.first]
+			arguments {
+				input = "${step.print.second.output} ${step.http_request.first.output.status}"
+			}
+		}
+	`)
+	require.False(t, diags.HasErrors(), diags.Error())
+
+	assert.Equal(t, []string{"step.http_request.first"}, depsOf(t, store, "step.print.second"))
+	assert.Equal(t, []string{"step.http_request.first", "step.print.second"}, depsOf(t, store, "step.print.third"))
+
+	addr, _ := nodeid.Parse("step.print.third")
+	n, _ := store.GetNode(testContext(), *addr)
+	assert.ElementsMatch(t, []string{"step.http_request.first", "step.print.second"}, n.Dependencies)
+}
+
+func TestCompile_DependenciesThroughLocals(t *testing.T) {
+	store, diags := compile(t, `
+		locals {
+			base  = "https://example.com"
+			token = step.http_request.auth.output.token
+			auth  = "Bearer ${local.token}"
+		}
+		step "http_request" "auth" {}
+		step "print" "static" {
+			arguments {
+				input = local.base
+			}
+		}
+		step "print" "dynamic" {
+			arguments {
+				input = "${local.base} ${local.auth}"
+			}
+		}
+	`)
+	require.False(t, diags.HasErrors(), diags.Error())
+
+	assert.Empty(t, depsOf(t, store, "step.print.static"))
+	assert.Equal(t, []string{"step.http_request.auth"}, depsOf(t, store, "step.print.dynamic"),
+		"a step depends on the steps its locals read, transitively")
+}
+
+func TestCompile_LocalErrors(t *testing.T) {
+	testCases := []struct {
+		name        string
+		src         string
+		wantSummary string
+	}{
+		{
+			name: "undeclared local",
+			src: `
+				step "print" "a" {
+					arguments {
+						input = local.missing
+					}
+				}`,
+			wantSummary: "Reference to undeclared local value",
+		},
+		{
+			name: "cycle between locals",
+			src: `
+				locals {
+					a = local.b
+					b = local.a
+				}`,
+			wantSummary: "Cycle in local values",
+		},
+		{
+			name: "local references an undeclared variable",
+			src: `
+				locals {
+					a = var.missing
+				}`,
+			wantSummary: "Reference to undeclared variable",
+		},
+		{
+			name: "local references an undeclared step",
+			src: `
+				locals {
+					a = step.print.missing.output
+				}
+				step "print" "a" {
+					arguments {
+						input = local.a
+					}
+				}`,
+			wantSummary: "Reference to undeclared step",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			store, diags := compile(t, tc.src)
+			require.True(t, diags.HasErrors())
+			assert.Equal(t, tc.wantSummary, diags[0].Summary)
+			assert.Empty(t, store.AllNodes(testContext()), "store must be left untouched on error")
+		})
+	}
+}
+
+func TestCompile_UnknownTarget(t *testing.T) {
+	testCases := []struct {
+		name string
+		src  string
+	}{
+		{
+			name: "depends_on traversal",
+			src: `
+				step "print" "a" {
+					depends_on = [step.print.missing]
+				}`,
+		},
+		{
+			name: "depends_on string",
+			src: `
+				step "print" "a" {
+					depends_on = ["print.missing"]
+				}`,
+		},
+		{
+			name: "argument reference",
+			src: `
+				step "print" "a" {
+					arguments {
+						input = step.print.missing.output
+					}
+				}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			store, diags := compile(t, tc.src)
+			require.True(t, diags.HasErrors())
+			assert.Equal(t, "Reference to undeclared step", diags[0].Summary)
+			assert.Contains(t, diags[0].Detail, "step.print.missing")
+			require.NotNil(t, diags[0].Subject)
+			assert.Equal(t, "main.hcl", filepath.Base(diags[0].Subject.Filename))
+			assert.Greater(t, diags[0].Subject.Start.Line, 1)
+			assert.Empty(t, store.AllNodes(testContext()), "store must be left untouched on error")
+		})
+	}
+}
+
+func TestCompile_VariableReferences(t *testing.T) {
+	testCases := []struct {
+		name        string
+		src         string
+		wantSummary string
+	}{
+		{
+			name: "declared",
+			src: `
+				variable "host" {}
+				step "print" "a" {
+					arguments {
+						input = "https://${var.host}"
+					}
+				}`,
+		},
+		{
+			name: "undeclared",
+			src: `
+				variable "host" {}
+				step "print" "a" {
+					arguments {
+						input = var.hots
+					}
+				}`,
+			wantSummary: "Reference to undeclared variable",
+		},
+		{
+			name: "whole var object",
+			src: `
+				variable "host" {}
+				step "print" "a" {
+					arguments {
+						input = var
+					}
+				}`,
+			wantSummary: "Invalid variable reference",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, diags := compile(t, tc.src)
+			if tc.wantSummary == "" {
+				require.False(t, diags.HasErrors(), diags.Error())
+				return
+			}
+			require.True(t, diags.HasErrors())
+			assert.Equal(t, tc.wantSummary, diags[0].Summary)
+			require.NotNil(t, diags[0].Subject)
+			assert.Equal(t, 5, diags[0].Subject.Start.Line)
+		})
+	}
+}
+
+func TestCompile_ResourceReferences(t *testing.T) {
+	testCases := []struct {
+		name        string
+		src         string
+		wantSummary string
+		wantLine    int
+	}{
+		{
+			name: "declared",
+			src: `
+				variable "proxy" {}
+				locals {
+					port = 3128
+				}
+				resource "http_client" "shared" {
+					env = { HTTPS_PROXY = "${var.proxy}:${local.port}" }
+				}
+				step "print" "a" {
+					uses = [resource.http_client.shared]
+				}`,
+		},
+		{
+			name: "undeclared",
+			src: `
+				resource "http_client" "shared" {}
+				step "print" "a" {
+					uses = [resource.http_client.sahred]
+				}`,
+			wantSummary: "Reference to undeclared resource",
+			wantLine:    4,
+		},
+		{
+			name: "missing name",
+			src: `
+				resource "http_client" "shared" {}
+				step "print" "a" {
+					uses = [resource.http_client]
+				}`,
+			wantSummary: "Invalid resource reference",
+			wantLine:    4,
+		},
+		{
+			name: "step output in env",
+			src: `
+				step "print" "a" {}
+				resource "http_client" "shared" {
+					env = { TOKEN = step.print.a.output }
+				}`,
+			wantSummary: "Invalid reference in resource env",
+			wantLine:    4,
+		},
+		{
+			name: "local reading a step in env",
+			src: `
+				step "print" "a" {}
+				locals {
+					token = step.print.a.output
+				}
+				resource "http_client" "shared" {
+					env = { TOKEN = local.token }
+				}`,
+			wantSummary: "Invalid reference in resource env",
+			wantLine:    7,
+		},
+		{
+			name: "undeclared variable in env",
+			src: `
+				resource "http_client" "shared" {
+					env = { TOKEN = var.token }
+				}`,
+			wantSummary: "Reference to undeclared variable",
+			wantLine:    3,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, diags := compile(t, tc.src)
+			if tc.wantSummary == "" {
+				require.False(t, diags.HasErrors(), diags.Error())
+				return
+			}
+			require.True(t, diags.HasErrors())
+			assert.Equal(t, tc.wantSummary, diags[0].Summary)
+			require.NotNil(t, diags[0].Subject)
+			assert.Equal(t, tc.wantLine, diags[0].Subject.Start.Line)
+		})
+	}
+}
+
+func TestCompile_InvalidDependsOnEntry(t *testing.T) {
+	_, diags := compile(t, `
+		step "print" "a" {
+			depends_on = [var.foo, 42]
+		}
+	`)
+	require.True(t, diags.HasErrors())
+	require.Len(t, diags, 2)
+	for _, d := range diags {
+		assert.Equal(t, "Invalid depends_on entry", d.Summary)
+	}
+}
+
+func TestCompile_DuplicateStep(t *testing.T) {
+	_, diags := compile(t, `
+		step "print" "a" {}
+		step "print" "a" {}
+	`)
+	require.True(t, diags.HasErrors())
+	assert.Equal(t, "Duplicate step", diags[0].Summary)
+	assert.Equal(t, 3, diags[0].Subject.Start.Line)
+}
+
+func TestCompile_RejectsCycles(t *testing.T) {
+	testCases := []struct {
+		name        string
+		src         string
+		wantInCycle []string
+	}{
+		{
+			name: "self reference",
+			src: `
+				step "print" "a" {
+					arguments {
+						input = step.print.a.output
+					}
+				}`,
+			wantInCycle: []string{"step.print.a -> step.print.a"},
+		},
+		{
+			name: "three step cycle",
+			src: `
+				step "print" "a" {
+					depends_on = [step.print.c]
+				}
+				step "print" "b" {
+					depends_on = [step.print.a]
+				}
+				step "print" "c" {
+					arguments {
+						input = step.print.b.output
+					}
+				}`,
+			wantInCycle: []string{"step.print.a", "step.print.b", "step.print.c"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			store, diags := compile(t, tc.src)
+			require.True(t, diags.HasErrors())
+			require.Len(t, diags, 1)
+			assert.Equal(t, "Dependency cycle", diags[0].Summary)
+			for _, want := range tc.wantInCycle {
+				assert.Contains(t, diags[0].Detail, want)
+			}
+			require.NotNil(t, diags[0].Subject)
+			assert.Empty(t, store.AllNodes(testContext()))
+		})
+	}
+}
+
+func TestCompile_ExpandsStaticCount(t *testing.T) {
+	store, diags := compile(t, `
+		step "http_request" "first" {}
+		step "http_request" "delay_requests" {
+			count      = 10
+			depends_on = ["http_request.first"]
+		}
+		step "http_request" "final" {
+			depends_on = [
+				"http_request.delay_requests[0]",
+				"http_request.delay_requests[1]",
+				"http_request.delay_requests[7]",
+			]
+		}
+		step "print" "none" {
+			count = 0
+		}
+	`)
+	require.False(t, diags.HasErrors(), diags.Error())
+	assert.Len(t, store.AllNodes(testContext()), 12)
+
+	addr, _ := nodeid.Parse("step.http_request.delay_requests[9]")
+	n, ok := store.GetNode(testContext(), *addr)
+	require.True(t, ok)
+	require.NotNil(t, n.Instance)
+	assert.Equal(t, 9, n.Instance.Index)
+	assert.False(t, n.Instance.ForEach)
+
+	assert.Equal(t, []string{"step.http_request.first"}, depsOf(t, store, "step.http_request.delay_requests[3]"))
+	assert.Equal(t, []string{
+		"step.http_request.delay_requests[0]",
+		"step.http_request.delay_requests[1]",
+		"step.http_request.delay_requests[7]",
+	}, depsOf(t, store, "step.http_request.final"))
+}
+
+func TestCompile_ExpandsStaticForEach(t *testing.T) {
+	store, diags := compile(t, `
+		step "print" "regions" {
+			for_each = { us = "https://us.example.com", eu = "https://eu.example.com" }
+		}
+		step "print" "names" {
+			for_each = ["b", "a"]
+		}
+		step "print" "hosts" {
+			for_each = toset(split(",", "x.example.com,y.example.com"))
+		}
+		step "print" "pick" {
+			arguments {
+				input = step.print.regions["us"].output
+			}
+		}
+		step "print" "all" {
+			arguments {
+				input = values(step.print.names)[*].output
+			}
+		}
+	`)
+	require.False(t, diags.HasErrors(), diags.Error())
+
+	addr, _ := nodeid.Parse(`step.print.regions["eu"]`)
+	n, ok := store.GetNode(testContext(), *addr)
+	require.True(t, ok)
+	require.NotNil(t, n.Instance)
+	assert.True(t, n.Instance.ForEach)
+	assert.Equal(t, "eu", n.Instance.Key.AsString())
+	assert.Equal(t, "https://eu.example.com", n.Instance.Value.AsString())
+
+	addr, _ = nodeid.Parse(`step.print.hosts["y.example.com"]`)
+	_, ok = store.GetNode(testContext(), *addr)
+	assert.True(t, ok, "functions are available to static for_each expressions")
+
+	assert.Equal(t, []string{`step.print.regions["us"]`}, depsOf(t, store, "step.print.pick"))
+	assert.Equal(t, []string{`step.print.names["a"]`, `step.print.names["b"]`}, depsOf(t, store, "step.print.all"))
+}
+
+func TestCompile_InstanceErrors(t *testing.T) {
+	testCases := []struct {
+		name    string
+		src     string
+		summary string
+		detail  string
+	}{
+		{
+			name: "negative count",
+			src: `
+				step "print" "a" {
+					count = -1
+				}`,
+			summary: "Invalid count value",
+			detail:  "must not be negative",
+		},
+		{
+			name: "duplicate for_each key",
+			src: `
+				step "print" "a" {
+					for_each = ["x", "x"]
+				}`,
+			summary: "Invalid for_each value",
+			detail:  `duplicate key "x"`,
+		},
+		{
+			name: "shorthand on instanced step",
+			src: `
+				step "print" "a" {
+					count = 2
+				}
+				step "print" "b" {
+					arguments {
+						input = step.print.a.output
+					}
+				}`,
+			summary: "Missing instance key",
+			detail:  "step.print.a[0]",
+		},
+		{
+			name: "index out of range",
+			src: `
+				step "print" "a" {
+					count = 2
+				}
+				step "print" "b" {
+					depends_on = ["print.a[7]"]
+				}`,
+			summary: "Invalid instance reference",
+			detail:  "out of range",
+		},
+		{
+			name: "index on singular step",
+			src: `
+				step "print" "a" {}
+				step "print" "b" {
+					arguments {
+						input = step.print.a[0].output
+					}
+				}`,
+			summary: "Invalid instance reference",
+			detail:  "cannot be indexed",
+		},
+		{
+			name: "unknown for_each key",
+			src: `
+				step "print" "a" {
+					for_each = ["x"]
+				}
+				step "print" "b" {
+					depends_on = [step.print.a["y"]]
+				}`,
+			summary: "Invalid instance reference",
+			detail:  `key "y"`,
+		},
+		{
+			name: "shorthand on for_each step",
+			src: `
+				step "print" "a" {
+					for_each = ["x", "y"]
+				}
+				step "print" "b" {
+					arguments {
+						input = step.print.a.output
+					}
+				}`,
+			summary: "Missing instance key",
+			detail:  "values(step.print.a)[*].output",
+		},
+		{
+			name: "splat over for_each step",
+			src: `
+				step "print" "a" {
+					for_each = ["x", "y"]
+				}
+				step "print" "b" {
+					arguments {
+						input = step.print.a[*].output
+					}
+				}`,
+			summary: "Splat over a for_each step",
+			detail:  "values(step.print.a)[*]",
+		},
+		{
+			name: "splat over dynamic for_each step in a local",
+			src: `
+				step "print" "keys" {}
+				step "print" "a" {
+					for_each = step.print.keys.output
+				}
+				locals {
+					outputs = step.print.a[*].output
+				}`,
+			summary: "Splat over a for_each step",
+			detail:  "values(step.print.a)[*]",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			store, diags := compile(t, tc.src)
+			require.True(t, diags.HasErrors())
+			assert.Equal(t, tc.summary, diags[0].Summary)
+			assert.Contains(t, diags[0].Detail, tc.detail)
+			require.NotNil(t, diags[0].Subject)
+			assert.Empty(t, store.AllNodes(testContext()))
+		})
+	}
+}
+
+func TestCompile_DynamicCountCreatesPlaceholder(t *testing.T) {
+	store, diags := compile(t, `
+		step "print" "config" {}
+		step "print" "first" {}
+		step "http_request" "delay_requests" {
+			count      = step.print.config.output.n
+			depends_on = ["print.first"]
+		}
+		step "print" "one" {
+			depends_on = ["http_request.delay_requests[3]"]
+		}
+	`)
+	require.False(t, diags.HasErrors(), diags.Error())
+	assert.Len(t, store.AllNodes(testContext()), 4)
+
+	addr, _ := nodeid.Parse("step.http_request.delay_requests")
+	n, ok := store.GetNode(testContext(), *addr)
+	require.True(t, ok)
+	assert.True(t, n.Placeholder)
+	assert.Nil(t, n.Instance)
+	assert.Equal(t, []string{"step.print.config", "step.print.first"}, depsOf(t, store, "step.http_request.delay_requests"))
+
+	// Individual instances are not known yet, so the reference waits for the placeholder.
+	assert.Equal(t, []string{"step.http_request.delay_requests"}, depsOf(t, store, "step.print.one"))
+}
+
+func TestInstances(t *testing.T) {
+	store, diags := compile(t, `
+		step "print" "config" {}
+		step "print" "counted" {
+			count = step.print.config.output.n
+		}
+		step "print" "keyed" {
+			for_each = step.print.config.output.regions
+		}
+	`)
+	require.False(t, diags.HasErrors(), diags.Error())
+
+	placeholder := func(id string) *node.Node {
+		addr, _ := nodeid.Parse(id)
+		n, ok := store.GetNode(testContext(), *addr)
+		require.True(t, ok)
+		return n
+	}
+	evalCtx := &hcl.EvalContext{Variables: map[string]cty.Value{
+		"step": cty.ObjectVal(map[string]cty.Value{
+			"print": cty.ObjectVal(map[string]cty.Value{
+				"config": cty.ObjectVal(map[string]cty.Value{
+					"output": cty.ObjectVal(map[string]cty.Value{
+						"n":       cty.NumberIntVal(2),
+						"regions": cty.SetVal([]cty.Value{cty.StringVal("us"), cty.StringVal("eu")}),
+					}),
+				}),
+			}),
+		}),
+	}}
+
+	counted, err := Instances(placeholder("step.print.counted"), evalCtx)
+	require.NoError(t, err)
+	require.Len(t, counted, 2)
+	assert.Equal(t, "step.print.counted[1]", counted[1].ID.String())
+	assert.Equal(t, 1, counted[1].Instance.Index)
+
+	keyed, err := Instances(placeholder("step.print.keyed"), evalCtx)
+	require.NoError(t, err)
+	require.Len(t, keyed, 2)
+	assert.Equal(t, "eu", keyed[0].Instance.Key.AsString())
+
+	_, err = Instances(placeholder("step.print.config"), evalCtx)
+	assert.Error(t, err)
+
+	_, err = Instances(placeholder("step.print.counted"), &hcl.EvalContext{})
+	assert.Error(t, err)
+
+	// A sensitive count is fine, but for_each keys end up in node addresses.
+	step := evalCtx.Variables["step"].GetAttr("print").GetAttr("config").GetAttr("output")
+	secret := &hcl.EvalContext{Variables: map[string]cty.Value{
+		"step": cty.ObjectVal(map[string]cty.Value{
+			"print": cty.ObjectVal(map[string]cty.Value{
+				"config": cty.ObjectVal(map[string]cty.Value{"output": step.Mark(sensitive.Mark)}),
+			}),
+		}),
+	}}
+	counted, err = Instances(placeholder("step.print.counted"), secret)
+	require.NoError(t, err)
+	assert.Len(t, counted, 2)
+	_, err = Instances(placeholder("step.print.keyed"), secret)
+	assert.ErrorContains(t, err, "must not be sensitive")
+}
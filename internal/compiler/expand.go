@@ -0,0 +1,278 @@
+package compiler
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/specialistvlad/burstgridgo/internal/funcs"
+	"github.com/specialistvlad/burstgridgo/internal/model"
+	"github.com/specialistvlad/burstgridgo/internal/node"
+	"github.com/specialistvlad/burstgridgo/internal/nodeid"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// group is the set of nodes compiled from a single step. A singular step has
+// exactly one node; a step with `count` or `for_each` has one node per instance.
+type group struct {
+	id        string // step.<runner>.<name>
+	step      *model.Step
+	instanced bool
+	forEach   bool
+	dynamic   bool // expanded at runtime from a placeholder node
+	nodes     []*node.Node
+	keys      map[string]int // for_each key -> instance position
+}
+
+// expand turns a step into its group of nodes, evaluating a static `count` or
+// `for_each` to decide how many instances to create. A `count` or `for_each`
+// that references other values yields a single placeholder node instead, which
+// the executor expands once those values are known.
+func expand(step *model.Step) (*group, hcl.Diagnostics) {
+	base := stepAddress(step.RunnerType, step.Name)
+	g := &group{id: base.String(), step: step}
+
+	loop := step.Count
+	if loop == nil {
+		loop = step.ForEach
+	}
+	if loop == nil {
+		g.nodes = []*node.Node{{ID: base, Type: step.RunnerType, Config: step}}
+		return g, nil
+	}
+
+	g.instanced = true
+	g.forEach = step.ForEach != nil
+	if len(loop.Variables()) > 0 {
+		g.dynamic = true
+		g.nodes = []*node.Node{{ID: base, Type: step.RunnerType, Config: step, Placeholder: true}}
+		return g, nil
+	}
+
+	val, diags := loop.Value(funcs.EvalContext(nil))
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	nodes, err := instances(step, val)
+	if err != nil {
+		return nil, diags.Append(loopDiag(step, err))
+	}
+	g.nodes = nodes
+	if g.forEach {
+		g.keys = make(map[string]int, len(nodes))
+		for i, n := range nodes {
+			g.keys[n.Instance.Key.AsString()] = i
+		}
+	}
+	return g, diags
+}
+
+// Instances evaluates the `count` or `for_each` of a placeholder node against
+// ctx and returns the instance nodes it expands into. It is used by executors
+// to expand dynamic steps once their upstream outputs are available.
+func Instances(placeholder *node.Node, ctx *hcl.EvalContext) ([]*node.Node, error) {
+	step := placeholder.Config
+	if step == nil || !placeholder.Placeholder {
+		return nil, fmt.Errorf("node %s is not a placeholder", placeholder.ID.String())
+	}
+	loop := step.Count
+	if loop == nil {
+		loop = step.ForEach
+	}
+	if loop == nil {
+		return nil, fmt.Errorf("step %s has neither count nor for_each", placeholder.ID.String())
+	}
+
+	val, diags := loop.Value(ctx)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	nodes, err := instances(step, val)
+	if err != nil {
+		return nil, hcl.Diagnostics{loopDiag(step, err)}
+	}
+	return nodes, nil
+}
+
+// instances builds the instance nodes of step from its evaluated `count` or
+// `for_each` value.
+func instances(step *model.Step, val cty.Value) ([]*node.Node, error) {
+	if step.Count != nil {
+		n, err := countValue(val)
+		if err != nil {
+			return nil, err
+		}
+		nodes := make([]*node.Node, n)
+		for i := range nodes {
+			nodes[i] = &node.Node{
+				ID:       instanceAddress(step, nodeid.NewPathSegmentWithIndex(step.Name, i)),
+				Type:     step.RunnerType,
+				Config:   step,
+				Instance: &node.Instance{Index: i},
+			}
+		}
+		return nodes, nil
+	}
+
+	keys, values, err := forEachValue(val)
+	if err != nil {
+		return nil, err
+	}
+	nodes := make([]*node.Node, len(keys))
+	for i, key := range keys {
+		nodes[i] = &node.Node{
+			ID:     instanceAddress(step, nodeid.NewPathSegmentWithKey(step.Name, key)),
+			Type:   step.RunnerType,
+			Config: step,
+			Instance: &node.Instance{
+				ForEach: true,
+				Index:   i,
+				Key:     cty.StringVal(key),
+				Value:   values[i],
+			},
+		}
+	}
+	return nodes, nil
+}
+
+// loopDiag reports an invalid `count` or `for_each` value.
+func loopDiag(step *model.Step, err error) *hcl.Diagnostic {
+	summary, expr := "Invalid count value", step.Count
+	if expr == nil {
+		summary, expr = "Invalid for_each value", step.ForEach
+	}
+	return &hcl.Diagnostic{
+		Severity: hcl.DiagError,
+		Summary:  summary,
+		Detail:   err.Error(),
+		Subject:  expr.Range().Ptr(),
+	}
+}
+
+// instanceAddress returns the address of one instance of a step, given its
+// last segment, e.g. `step.http_request.ping[3]` or `step.http_request.call["eu"]`.
+func instanceAddress(step *model.Step, last nodeid.PathSegment) nodeid.Address {
+	return nodeid.Address{Path: []nodeid.PathSegment{
+		nodeid.NewPathSegment(stepRoot),
+		nodeid.NewPathSegment(step.RunnerType),
+		last,
+	}}
+}
+
+// countValue converts an evaluated `count` value into an instance count.
+func countValue(val cty.Value) (int, error) {
+	val, _ = val.UnmarkDeep()
+	if !val.IsKnown() {
+		return 0, fmt.Errorf("The 'count' value must be known before its instances are created.")
+	}
+	if val.IsNull() || !val.Type().Equals(cty.Number) {
+		return 0, fmt.Errorf("The 'count' value must be a whole number, got %s.", val.Type().FriendlyName())
+	}
+	bf := val.AsBigFloat()
+	if !bf.IsInt() {
+		return 0, fmt.Errorf("The 'count' value must be a whole number, got %s.", bf.Text('f', -1))
+	}
+	if bf.Sign() < 0 {
+		return 0, fmt.Errorf("The 'count' value must not be negative, got %s.", bf.Text('f', -1))
+	}
+	n, acc := bf.Int64()
+	if acc != big.Exact || n > maxInstances {
+		return 0, fmt.Errorf("The 'count' value must not exceed %d.", maxInstances)
+	}
+	return int(n), nil
+}
+
+// maxInstances bounds how many instances a single step may expand into.
+const maxInstances = 1 << 20
+
+// forEachValue converts an evaluated `for_each` value into sorted keys and the
+// element value for each key. Maps and objects use their keys; sets, lists and
+// tuples of strings use each string as both key and value.
+func forEachValue(val cty.Value) ([]string, []cty.Value, error) {
+	if !val.IsWhollyKnown() {
+		return nil, nil, fmt.Errorf("The 'for_each' value must be known before its instances are created.")
+	}
+	if val.IsNull() {
+		return nil, nil, fmt.Errorf("The 'for_each' value must not be null.")
+	}
+	if val.ContainsMarked() {
+		return nil, nil, fmt.Errorf("The 'for_each' value must not be sensitive: its keys become part of node addresses.")
+	}
+	ty := val.Type()
+	elems := make(map[string]cty.Value)
+
+	switch {
+	case ty.IsMapType() || ty.IsObjectType():
+		for it := val.ElementIterator(); it.Next(); {
+			k, v := it.Element()
+			elems[k.AsString()] = v
+		}
+	case ty.IsSetType() || ty.IsListType() || ty.IsTupleType():
+		for it := val.ElementIterator(); it.Next(); {
+			_, v := it.Element()
+			if v.IsNull() || !v.Type().Equals(cty.String) {
+				return nil, nil, fmt.Errorf("When using a list or set for for_each, all elements must be strings.")
+			}
+			key := v.AsString()
+			if _, dup := elems[key]; dup {
+				return nil, nil, fmt.Errorf("The for_each value contains the duplicate key %q.", key)
+			}
+			elems[key] = v
+		}
+	default:
+		return nil, nil, fmt.Errorf("The 'for_each' attribute must be a map, a set of strings, or a list of strings, got %s.", ty.FriendlyName())
+	}
+
+	keys := make([]string, 0, len(elems))
+	for k := range elems {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	values := make([]cty.Value, len(keys))
+	for i, k := range keys {
+		values[i] = elems[k]
+	}
+	return keys, values, nil
+}
+
+// ids returns the node IDs of every instance in the group.
+func (g *group) ids() []string {
+	ids := make([]string, len(g.nodes))
+	for i, n := range g.nodes {
+		ids[i] = n.ID.String()
+	}
+	return ids
+}
+
+// instanceIndex returns the position of the instance selected by key: a
+// number for `count` steps, or a string for `for_each` steps.
+func (g *group) instanceIndex(key cty.Value) (int, error) {
+	if !g.instanced {
+		return 0, fmt.Errorf("Step %s does not use count or for_each, so it cannot be indexed.", g.id)
+	}
+	if key.IsNull() || !key.IsKnown() {
+		return 0, fmt.Errorf("The instance key for %s must be a known, non-null value.", g.id)
+	}
+
+	if g.forEach {
+		if !key.Type().Equals(cty.String) {
+			return 0, fmt.Errorf("Step %s uses for_each, so its instances must be selected by string key, e.g. %s[\"key\"].", g.id, g.id)
+		}
+		i, ok := g.keys[key.AsString()]
+		if !ok {
+			return 0, fmt.Errorf("Step %s has no instance with key %q.", g.id, key.AsString())
+		}
+		return i, nil
+	}
+
+	if !key.Type().Equals(cty.Number) {
+		return 0, fmt.Errorf("Step %s uses count, so its instances must be selected by number, e.g. %s[0].", g.id, g.id)
+	}
+	bf := key.AsBigFloat()
+	n, acc := bf.Int64()
+	if !bf.IsInt() || acc != big.Exact || n < 0 || n >= int64(len(g.nodes)) {
+		return 0, fmt.Errorf("Index %s is out of range for step %s, which has %d instance(s).", bf.Text('f', -1), g.id, len(g.nodes))
+	}
+	return int(n), nil
+}
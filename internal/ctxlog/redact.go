@@ -0,0 +1,88 @@
+package ctxlog
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/specialistvlad/burstgridgo/internal/sensitive"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// redactingHandler keeps sensitive values out of the records it passes on.
+type redactingHandler struct {
+	next    slog.Handler
+	secrets sensitive.Secrets
+}
+
+// NewRedactingHandler wraps next so that sensitive values never reach it:
+// cty.Values carrying the sensitive mark are replaced with
+// sensitive.Redacted, and so is every occurrence of secrets in the message
+// and in attribute values, including numbers whose text matches a secret.
+func NewRedactingHandler(next slog.Handler, secrets sensitive.Secrets) slog.Handler {
+	return &redactingHandler{next: next, secrets: secrets}
+}
+
+// WithSecrets returns a context whose logger also redacts secrets.
+func WithSecrets(ctx context.Context, secrets sensitive.Secrets) context.Context {
+	if len(secrets) == 0 {
+		return ctx
+	}
+	logger := FromContext(ctx)
+	return WithLogger(ctx, slog.New(NewRedactingHandler(logger.Handler(), secrets)))
+}
+
+func (h *redactingHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *redactingHandler) Handle(ctx context.Context, r slog.Record) error {
+	out := slog.NewRecord(r.Time, r.Level, h.secrets.Redact(r.Message), r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		out.AddAttrs(h.redact(a))
+		return true
+	})
+	return h.next.Handle(ctx, out)
+}
+
+func (h *redactingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redacted := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		redacted[i] = h.redact(a)
+	}
+	return &redactingHandler{next: h.next.WithAttrs(redacted), secrets: h.secrets}
+}
+
+func (h *redactingHandler) WithGroup(name string) slog.Handler {
+	return &redactingHandler{next: h.next.WithGroup(name), secrets: h.secrets}
+}
+
+// redact returns a with its sensitive content replaced.
+func (h *redactingHandler) redact(a slog.Attr) slog.Attr {
+	v := a.Value.Resolve()
+	switch v.Kind() {
+	case slog.KindString:
+		return slog.String(a.Key, h.secrets.Redact(v.String()))
+	case slog.KindGroup:
+		group := v.Group()
+		attrs := make([]slog.Attr, len(group))
+		for i, ga := range group {
+			attrs[i] = h.redact(ga)
+		}
+		return slog.Attr{Key: a.Key, Value: slog.GroupValue(attrs...)}
+	case slog.KindAny:
+		raw := v.Any()
+		if val, ok := raw.(cty.Value); ok && sensitive.Marked(val) {
+			raw = sensitive.Redact(val)
+		}
+		if text := fmt.Sprint(raw); h.secrets.Contains(text) {
+			return slog.String(a.Key, h.secrets.Redact(text))
+		}
+		return slog.Any(a.Key, raw)
+	case slog.KindInt64, slog.KindUint64, slog.KindFloat64:
+		if h.secrets.Contains(v.String()) {
+			return slog.String(a.Key, sensitive.Redacted)
+		}
+	}
+	return slog.Attr{Key: a.Key, Value: v}
+}
@@ -0,0 +1,86 @@
+package ctxlog
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/specialistvlad/burstgridgo/internal/sensitive"
+	"github.com/stretchr/testify/assert"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestRedactingHandler(t *testing.T) {
+	testCases := []struct {
+		name    string
+		log     func(l *slog.Logger)
+		want    []string
+		notWant []string
+	}{
+		{
+			name:    "string attribute",
+			log:     func(l *slog.Logger) { l.Info("Printing input value", "value", "token=hunter2") },
+			want:    []string{"token=" + sensitive.Redacted},
+			notWant: []string{"hunter2"},
+		},
+		{
+			name:    "message",
+			log:     func(l *slog.Logger) { l.Info("got hunter2") },
+			notWant: []string{"hunter2"},
+		},
+		{
+			name:    "any attribute",
+			log:     func(l *slog.Logger) { l.Info("Printing input value", "value", map[string]any{"password": "hunter2"}) },
+			notWant: []string{"hunter2"},
+		},
+		{
+			name:    "error attribute",
+			log:     func(l *slog.Logger) { l.Error("Node failed.", "error", errors.New("bad password hunter2")) },
+			notWant: []string{"hunter2"},
+		},
+		{
+			name:    "logger attributes and groups",
+			log:     func(l *slog.Logger) { l.With("auth", "hunter2").WithGroup("g").Info("x", "k", "hunter2") },
+			notWant: []string{"hunter2"},
+		},
+		{
+			name: "marked cty value",
+			log: func(l *slog.Logger) {
+				l.Info("Output.", "value", cty.ObjectVal(map[string]cty.Value{
+					"user": cty.StringVal("alice"),
+					"key":  cty.StringVal("AKIA0000").Mark(sensitive.Mark),
+				}))
+			},
+			want:    []string{"alice"},
+			notWant: []string{"AKIA0000"},
+		},
+		{
+			name:    "numbers matching a secret",
+			log:     func(l *slog.Logger) { l.Info("Connecting.", "port", 5432, "ratio", 0.25, "retries", 3) },
+			want:    []string{`port="` + sensitive.Redacted + `"`, `ratio="` + sensitive.Redacted + `"`, "retries=3"},
+			notWant: []string{"5432", "0.25"},
+		},
+		{
+			name: "unrelated values are untouched",
+			log:  func(l *slog.Logger) { l.Info("Node completed.", "node", "step.print.hello", "n", 3) },
+			want: []string{"node=step.print.hello", "n=3"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			ctx := WithLogger(context.Background(), slog.New(slog.NewTextHandler(&buf, nil)))
+			ctx = WithSecrets(ctx, sensitive.Secrets{"hunter2", "5432", "0.25"})
+			tc.log(FromContext(ctx))
+
+			for _, s := range tc.want {
+				assert.Contains(t, buf.String(), s)
+			}
+			for _, s := range tc.notWant {
+				assert.NotContains(t, buf.String(), s)
+			}
+		})
+	}
+}
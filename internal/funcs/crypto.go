@@ -0,0 +1,57 @@
+package funcs
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+)
+
+// MD5Func returns the hex-encoded MD5 hash of a string.
+var MD5Func = makeHashFunc(md5.New)
+
+// SHA1Func returns the hex-encoded SHA-1 hash of a string.
+var SHA1Func = makeHashFunc(sha1.New)
+
+// SHA256Func returns the hex-encoded SHA-256 hash of a string.
+var SHA256Func = makeHashFunc(sha256.New)
+
+// SHA512Func returns the hex-encoded SHA-512 hash of a string.
+var SHA512Func = makeHashFunc(sha512.New)
+
+// makeHashFunc wraps a hash as a function of one string.
+func makeHashFunc(newHash func() hash.Hash) function.Function {
+	return function.New(&function.Spec{
+		Params:       []function.Parameter{{Name: "str", Type: cty.String}},
+		Type:         function.StaticReturnType(cty.String),
+		RefineResult: refineNotNull,
+		Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+			h := newHash()
+			h.Write([]byte(args[0].AsString()))
+			return cty.StringVal(hex.EncodeToString(h.Sum(nil))), nil
+		},
+	})
+}
+
+// UUIDFunc returns a random (version 4) UUID. Each call returns a new value.
+var UUIDFunc = function.New(&function.Spec{
+	Params:       []function.Parameter{},
+	Type:         function.StaticReturnType(cty.String),
+	RefineResult: refineNotNull,
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		var b [16]byte
+		if _, err := rand.Read(b[:]); err != nil {
+			return cty.UnknownVal(cty.String), fmt.Errorf("failed to generate UUID: %w", err)
+		}
+		b[6] = b[6]&0x0f | 0x40 // version 4
+		b[8] = b[8]&0x3f | 0x80 // RFC 4122 variant
+		return cty.StringVal(fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])), nil
+	},
+})
@@ -0,0 +1,111 @@
+package funcs
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"unicode/utf8"
+
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+	"gopkg.in/yaml.v3"
+)
+
+// Base64EncodeFunc encodes a string as standard base64.
+var Base64EncodeFunc = function.New(&function.Spec{
+	Params:       []function.Parameter{{Name: "str", Type: cty.String}},
+	Type:         function.StaticReturnType(cty.String),
+	RefineResult: refineNotNull,
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		return cty.StringVal(base64.StdEncoding.EncodeToString([]byte(args[0].AsString()))), nil
+	},
+})
+
+// Base64DecodeFunc decodes a standard base64 string. The decoded bytes must be
+// valid UTF-8, since grid values are strings rather than raw bytes.
+var Base64DecodeFunc = function.New(&function.Spec{
+	Params:       []function.Parameter{{Name: "str", Type: cty.String}},
+	Type:         function.StaticReturnType(cty.String),
+	RefineResult: refineNotNull,
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		decoded, err := base64.StdEncoding.DecodeString(args[0].AsString())
+		if err != nil {
+			return cty.UnknownVal(cty.String), function.NewArgErrorf(0, "failed to decode base64 data: %s", err)
+		}
+		if !utf8.Valid(decoded) {
+			return cty.UnknownVal(cty.String), function.NewArgErrorf(0, "the decoded data is not valid UTF-8")
+		}
+		return cty.StringVal(string(decoded)), nil
+	},
+})
+
+// URLEncodeFunc escapes a string for use in a URL query.
+var URLEncodeFunc = function.New(&function.Spec{
+	Params:       []function.Parameter{{Name: "str", Type: cty.String}},
+	Type:         function.StaticReturnType(cty.String),
+	RefineResult: refineNotNull,
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		return cty.StringVal(url.QueryEscape(args[0].AsString())), nil
+	},
+})
+
+// YAMLEncodeFunc encodes a value as a YAML document. Object and map keys are
+// written in lexical order.
+var YAMLEncodeFunc = function.New(&function.Spec{
+	Params: []function.Parameter{{
+		Name:             "val",
+		Type:             cty.DynamicPseudoType,
+		AllowDynamicType: true,
+		AllowNull:        true,
+	}},
+	Type:         function.StaticReturnType(cty.String),
+	RefineResult: refineNotNull,
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		val := args[0]
+		if !val.IsWhollyKnown() {
+			return cty.UnknownVal(retType), nil
+		}
+		out, err := yaml.Marshal(yamlNode(val))
+		if err != nil {
+			return cty.UnknownVal(retType), fmt.Errorf("failed to encode YAML: %w", err)
+		}
+		return cty.StringVal(string(out)), nil
+	},
+})
+
+// yamlNode converts a wholly known value to a YAML node.
+func yamlNode(val cty.Value) *yaml.Node {
+	if val.IsNull() {
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}
+	}
+
+	ty := val.Type()
+	switch {
+	case ty == cty.String:
+		// The explicit tag makes the encoder quote strings that would
+		// otherwise read back as another type, such as "true" or "80".
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: val.AsString()}
+	case ty == cty.Number:
+		bf := val.AsBigFloat()
+		if bf.IsInt() {
+			return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: bf.Text('f', -1)}
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!float", Value: bf.Text('g', -1)}
+	case ty == cty.Bool:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: fmt.Sprint(val.True())}
+	case ty.IsObjectType() || ty.IsMapType():
+		node := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		for it := val.ElementIterator(); it.Next(); {
+			k, v := it.Element()
+			node.Content = append(node.Content, yamlNode(k), yamlNode(v))
+		}
+		return node
+	default: // lists, sets and tuples
+		node := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		for it := val.ElementIterator(); it.Next(); {
+			_, v := it.Element()
+			node.Content = append(node.Content, yamlNode(v))
+		}
+		return node
+	}
+}
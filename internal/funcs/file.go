@@ -0,0 +1,118 @@
+package funcs
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"unicode/utf8"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+)
+
+// FileFunc returns the contents of a file, which must be valid UTF-8.
+var FileFunc = function.New(&function.Spec{
+	Params:       []function.Parameter{{Name: "path", Type: cty.String}},
+	Type:         function.StaticReturnType(cty.String),
+	RefineResult: refineNotNull,
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		src, err := readFile(args[0].AsString())
+		if err != nil {
+			return cty.UnknownVal(cty.String), function.NewArgError(0, err)
+		}
+		return cty.StringVal(string(src)), nil
+	},
+})
+
+// FileExistsFunc reports whether a regular file exists at a path.
+var FileExistsFunc = function.New(&function.Spec{
+	Params:       []function.Parameter{{Name: "path", Type: cty.String}},
+	Type:         function.StaticReturnType(cty.Bool),
+	RefineResult: refineNotNull,
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		path := args[0].AsString()
+		info, err := os.Stat(path)
+		if errors.Is(err, os.ErrNotExist) {
+			return cty.False, nil
+		}
+		if err != nil {
+			return cty.UnknownVal(cty.Bool), function.NewArgErrorf(0, "failed to stat %s: %s", path, err)
+		}
+		if !info.Mode().IsRegular() {
+			return cty.UnknownVal(cty.Bool), function.NewArgErrorf(0, "%s is not a regular file", path)
+		}
+		return cty.True, nil
+	},
+})
+
+// makeTemplateFileFunc returns templatefile, which renders the template in a
+// file with the given variables and functions.
+func makeTemplateFileFunc(funcs map[string]function.Function) function.Function {
+	return function.New(&function.Spec{
+		Params: []function.Parameter{
+			{Name: "path", Type: cty.String},
+			{Name: "vars", Type: cty.DynamicPseudoType},
+		},
+		Type:         function.StaticReturnType(cty.String),
+		RefineResult: refineNotNull,
+		Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+			path, vars := args[0].AsString(), args[1]
+			if !vars.Type().IsObjectType() && !vars.Type().IsMapType() {
+				return cty.UnknownVal(cty.String), function.NewArgErrorf(1, "vars must be an object or a map")
+			}
+			if !vars.IsWhollyKnown() {
+				return cty.UnknownVal(cty.String), nil
+			}
+
+			src, err := readFile(path)
+			if err != nil {
+				return cty.UnknownVal(cty.String), function.NewArgError(0, err)
+			}
+			tmpl, diags := hclsyntax.ParseTemplate(src, path, hcl.InitialPos)
+			if diags.HasErrors() {
+				return cty.UnknownVal(cty.String), function.NewArgError(0, diags)
+			}
+
+			ctx := &hcl.EvalContext{
+				Variables: make(map[string]cty.Value),
+				Functions: funcs,
+			}
+			for it := vars.ElementIterator(); it.Next(); {
+				k, v := it.Element()
+				name := k.AsString()
+				if !hclsyntax.ValidIdentifier(name) {
+					return cty.UnknownVal(cty.String), function.NewArgErrorf(1, "invalid template variable name %q: must be a valid identifier", name)
+				}
+				ctx.Variables[name] = v
+			}
+			for _, trav := range tmpl.Variables() {
+				if _, ok := ctx.Variables[trav.RootName()]; !ok {
+					return cty.UnknownVal(cty.String), function.NewArgErrorf(1, "template %s references %q, which is not among the template variables", path, trav.RootName())
+				}
+			}
+
+			val, diags := tmpl.Value(ctx)
+			if diags.HasErrors() {
+				return cty.UnknownVal(cty.String), diags
+			}
+			if !val.Type().Equals(cty.String) {
+				return cty.UnknownVal(cty.String), fmt.Errorf("template %s produced %s, not a string", path, val.Type().FriendlyName())
+			}
+			return val, nil
+		},
+	})
+}
+
+// readFile reads a file whose contents must be valid UTF-8.
+func readFile(path string) ([]byte, error) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if !utf8.Valid(src) {
+		return nil, fmt.Errorf("contents of %s are not valid UTF-8", path)
+	}
+	return src, nil
+}
@@ -0,0 +1,248 @@
+// Package funcs provides the functions available to grid expressions.
+//
+// The library is curated rather than open-ended: most functions come from
+// cty's stdlib, under the names Terraform users already know, and the rest
+// (encodings, hashes, uuid, timestamp and file access) are implemented here.
+// try and can come from hcl's tryfunc extension.
+// Table returns the same set wherever expressions are evaluated, so a function
+// that works in a step argument also works in a local, a variable validation
+// or a `count`.
+//
+// Function calls are checked when the grid is loaded (see Check), so a typo in
+// a function name fails the load with a diagnostic pointing at the call,
+// rather than the run at the step that happens to use it.
+//
+// Relative paths given to file functions are resolved against the working
+// directory.
+package funcs
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/ext/tryfunc"
+	"github.com/specialistvlad/burstgridgo/internal/bggoexpr"
+	"github.com/specialistvlad/burstgridgo/internal/model"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+	"github.com/zclconf/go-cty/cty/function/stdlib"
+)
+
+var (
+	tableOnce sync.Once
+	table     map[string]function.Function
+)
+
+// Table returns the functions available to grid expressions, keyed by name.
+// The map is shared and must not be modified.
+func Table() map[string]function.Function {
+	tableOnce.Do(func() {
+		table = baseFunctions()
+		// templatefile renders templates with every other function, but not
+		// with itself, so templates cannot recurse.
+		table["templatefile"] = makeTemplateFileFunc(baseFunctions())
+	})
+	return table
+}
+
+// baseFunctions returns every function except templatefile.
+func baseFunctions() map[string]function.Function {
+	return map[string]function.Function{
+		// Strings
+		"chomp":        stdlib.ChompFunc,
+		"endswith":     EndsWithFunc,
+		"format":       stdlib.FormatFunc,
+		"formatlist":   stdlib.FormatListFunc,
+		"indent":       stdlib.IndentFunc,
+		"join":         stdlib.JoinFunc,
+		"lower":        stdlib.LowerFunc,
+		"regex":        stdlib.RegexFunc,
+		"regexall":     stdlib.RegexAllFunc,
+		"regexreplace": stdlib.RegexReplaceFunc,
+		"replace":      stdlib.ReplaceFunc,
+		"split":        stdlib.SplitFunc,
+		"startswith":   StartsWithFunc,
+		"strcontains":  StrContainsFunc,
+		"strlen":       stdlib.StrlenFunc,
+		"strrev":       stdlib.ReverseFunc,
+		"substr":       stdlib.SubstrFunc,
+		"title":        stdlib.TitleFunc,
+		"trim":         stdlib.TrimFunc,
+		"trimprefix":   stdlib.TrimPrefixFunc,
+		"trimspace":    stdlib.TrimSpaceFunc,
+		"trimsuffix":   stdlib.TrimSuffixFunc,
+		"upper":        stdlib.UpperFunc,
+
+		// Collections
+		"chunklist":       stdlib.ChunklistFunc,
+		"coalesce":        stdlib.CoalesceFunc,
+		"coalescelist":    stdlib.CoalesceListFunc,
+		"compact":         stdlib.CompactFunc,
+		"concat":          stdlib.ConcatFunc,
+		"contains":        stdlib.ContainsFunc,
+		"distinct":        stdlib.DistinctFunc,
+		"element":         stdlib.ElementFunc,
+		"flatten":         stdlib.FlattenFunc,
+		"keys":            stdlib.KeysFunc,
+		"length":          stdlib.LengthFunc,
+		"lookup":          stdlib.LookupFunc,
+		"merge":           stdlib.MergeFunc,
+		"range":           stdlib.RangeFunc,
+		"reverse":         stdlib.ReverseListFunc,
+		"setintersection": stdlib.SetIntersectionFunc,
+		"setproduct":      stdlib.SetProductFunc,
+		"setsubtract":     stdlib.SetSubtractFunc,
+		"setunion":        stdlib.SetUnionFunc,
+		"slice":           stdlib.SliceFunc,
+		"sort":            stdlib.SortFunc,
+		"values":          stdlib.ValuesFunc,
+		"zipmap":          stdlib.ZipmapFunc,
+
+		// Type conversions
+		"tobool":   stdlib.MakeToFunc(cty.Bool),
+		"tolist":   stdlib.MakeToFunc(cty.List(cty.DynamicPseudoType)),
+		"tomap":    stdlib.MakeToFunc(cty.Map(cty.DynamicPseudoType)),
+		"tonumber": stdlib.MakeToFunc(cty.Number),
+		"toset":    stdlib.MakeToFunc(cty.Set(cty.DynamicPseudoType)),
+		"tostring": stdlib.MakeToFunc(cty.String),
+
+		// Numbers
+		"abs":      stdlib.AbsoluteFunc,
+		"ceil":     stdlib.CeilFunc,
+		"floor":    stdlib.FloorFunc,
+		"log":      stdlib.LogFunc,
+		"max":      stdlib.MaxFunc,
+		"min":      stdlib.MinFunc,
+		"parseint": stdlib.ParseIntFunc,
+		"pow":      stdlib.PowFunc,
+		"signum":   stdlib.SignumFunc,
+
+		// Encodings
+		"base64decode": Base64DecodeFunc,
+		"base64encode": Base64EncodeFunc,
+		"csvdecode":    stdlib.CSVDecodeFunc,
+		"jsondecode":   stdlib.JSONDecodeFunc,
+		"jsonencode":   stdlib.JSONEncodeFunc,
+		"urlencode":    URLEncodeFunc,
+		"yamlencode":   YAMLEncodeFunc,
+
+		// Hashes and identifiers
+		"md5":    MD5Func,
+		"sha1":   SHA1Func,
+		"sha256": SHA256Func,
+		"sha512": SHA512Func,
+		"uuid":   UUIDFunc,
+
+		// Time
+		"formatdate": stdlib.FormatDateFunc,
+		"timeadd":    stdlib.TimeAddFunc,
+		"timestamp":  TimestampFunc,
+
+		// Files
+		"file":       FileFunc,
+		"fileexists": FileExistsFunc,
+
+		// Error handling
+		"can": tryfunc.CanFunc,
+		"try": tryfunc.TryFunc,
+	}
+}
+
+// EvalContext returns an evaluation context with vars and the function table.
+func EvalContext(vars map[string]cty.Value) *hcl.EvalContext {
+	return &hcl.EvalContext{Variables: vars, Functions: Table()}
+}
+
+// Check reports every call in the grid to a function that is not in the
+// table, pointing at the call.
+func Check(grid *model.Grid) hcl.Diagnostics {
+	var diags hcl.Diagnostics
+	check := func(c *bggoexpr.Container) {
+		for _, name := range c.CalledFunctions() {
+			if _, ok := Table()[name]; ok {
+				continue
+			}
+			diags = diags.Append(unknownFunction(name, c.CallRange(name)))
+		}
+	}
+
+	for _, step := range grid.Steps {
+		check(step.Expressions)
+	}
+	for _, r := range grid.Resources {
+		c := bggoexpr.NewContainer()
+		c.Add(r.Expressions()...)
+		check(c)
+	}
+	for _, l := range grid.Locals {
+		c := bggoexpr.NewContainer()
+		c.Add(l.Expr)
+		check(c)
+	}
+	for _, v := range grid.Variables {
+		c := bggoexpr.NewContainer()
+		for _, val := range v.Validations {
+			c.Add(val.Condition, val.ErrorMessage)
+		}
+		check(c)
+	}
+	return diags
+}
+
+// unknownFunction reports a call to a function that does not exist,
+// suggesting the closest known name.
+func unknownFunction(name string, rng hcl.Range) *hcl.Diagnostic {
+	detail := fmt.Sprintf("There is no function named %q.", name)
+	if suggestion := closest(name); suggestion != "" {
+		detail += fmt.Sprintf(" Did you mean %q?", suggestion)
+	}
+	return &hcl.Diagnostic{
+		Severity: hcl.DiagError,
+		Summary:  "Call to unknown function",
+		Detail:   detail,
+		Subject:  rng.Ptr(),
+	}
+}
+
+// closest returns the known function name nearest to name, or "" if none is
+// close enough to be a likely typo.
+func closest(name string) string {
+	names := make([]string, 0, len(Table()))
+	for n := range Table() {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+
+	best, bestDist := "", 3
+	lower := strings.ToLower(name)
+	for _, n := range names {
+		if d := distance(lower, n); d < bestDist {
+			best, bestDist = n, d
+		}
+	}
+	return best
+}
+
+// distance returns the Levenshtein distance between a and b.
+func distance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
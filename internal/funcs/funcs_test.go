@@ -0,0 +1,230 @@
+package funcs
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/specialistvlad/burstgridgo/internal/ctxlog"
+	"github.com/specialistvlad/burstgridgo/internal/model"
+	"github.com/specialistvlad/burstgridgo/internal/sensitive"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// eval evaluates src with the function table and vars.
+func eval(t *testing.T, src string, vars map[string]cty.Value) (cty.Value, hcl.Diagnostics) {
+	t.Helper()
+	expr, diags := hclsyntax.ParseExpression([]byte(src), "test.hcl", hcl.InitialPos)
+	require.False(t, diags.HasErrors(), diags.Error())
+	return expr.Value(EvalContext(vars))
+}
+
+func TestFunctions(t *testing.T) {
+	testCases := []struct {
+		src  string
+		want cty.Value
+	}{
+		// Strings
+		{`upper("abc")`, cty.StringVal("ABC")},
+		{`startswith("https://x", "https://")`, cty.True},
+		{`endswith("main.hcl", ".json")`, cty.False},
+		{`strcontains("burstgrid", "grid")`, cty.True},
+		{`join(",", split("/", "a/b/c"))`, cty.StringVal("a,b,c")},
+		{`format("%s:%d", "host", 80)`, cty.StringVal("host:80")},
+		{`strrev("abc")`, cty.StringVal("cba")},
+
+		// Collections
+		{`length([1, 2, 3])`, cty.NumberIntVal(3)},
+		{`lookup({ a = "x" }, "b", "fallback")`, cty.StringVal("fallback")},
+		{`keys(merge({ b = 1 }, { a = 2 }))`, cty.TupleVal([]cty.Value{cty.StringVal("a"), cty.StringVal("b")})},
+		{`reverse([1, 2])`, cty.TupleVal([]cty.Value{cty.NumberIntVal(2), cty.NumberIntVal(1)})},
+
+		// Conversions and numbers
+		{`tonumber("42") + 1`, cty.NumberIntVal(43)},
+		{`tostring(true)`, cty.StringVal("true")},
+		{`max(3, abs(-7), 5)`, cty.NumberIntVal(7)},
+		{`parseint("ff", 16)`, cty.NumberIntVal(255)},
+
+		// Encodings
+		{`jsonencode({ a = [1, "b"] })`, cty.StringVal(`{"a":[1,"b"]}`)},
+		{`jsondecode("{\"a\": 1}").a`, cty.NumberIntVal(1)},
+		{`base64encode("hello")`, cty.StringVal("aGVsbG8=")},
+		{`base64decode("aGVsbG8=")`, cty.StringVal("hello")},
+		{`urlencode("a b&c")`, cty.StringVal("a+b%26c")},
+
+		// Hashes
+		{`md5("abc")`, cty.StringVal("900150983cd24fb0d6963f7d28e17f72")},
+		{`sha1("abc")`, cty.StringVal("a9993e364706816aba3e25717850c26c9cd0d89d")},
+		{`sha256("abc")`, cty.StringVal("ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad")},
+
+		// Time
+		{`formatdate("YYYY-MM-DD", "2025-03-04T05:06:07Z")`, cty.StringVal("2025-03-04")},
+		{`timeadd("2025-03-04T05:06:07Z", "1h")`, cty.StringVal("2025-03-04T06:06:07Z")},
+
+		// Error handling
+		{`try(tonumber("x"), 0)`, cty.NumberIntVal(0)},
+		{`can(tonumber("x"))`, cty.False},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.src, func(t *testing.T) {
+			got, diags := eval(t, tc.src, nil)
+			require.False(t, diags.HasErrors(), diags.Error())
+			assert.True(t, tc.want.Equals(got).True(), "got %#v", got)
+		})
+	}
+}
+
+func TestFunctions_Errors(t *testing.T) {
+	testCases := []struct {
+		src        string
+		wantDetail string
+	}{
+		{`base64decode("not base64!")`, "failed to decode base64 data"},
+		{`file("does-not-exist.txt")`, "failed to read does-not-exist.txt"},
+		{`tonumber("eighty")`, "cannot convert"},
+		{`templatefile("x.tmpl", "not an object")`, "vars must be an object or a map"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.src, func(t *testing.T) {
+			_, diags := eval(t, tc.src, nil)
+			require.True(t, diags.HasErrors())
+			assert.Contains(t, diags[0].Detail, tc.wantDetail)
+		})
+	}
+}
+
+func TestYAMLEncode(t *testing.T) {
+	got, diags := eval(t, `yamlencode({
+		name    = "api"
+		port    = "8080"
+		enabled = true
+		ratio   = 0.5
+		tags    = ["a", "b"]
+		none    = null
+	})`, nil)
+	require.False(t, diags.HasErrors(), diags.Error())
+	assert.Equal(t, `enabled: true
+name: api
+none: null
+port: "8080"
+ratio: 0.5
+tags:
+    - a
+    - b
+`, got.AsString())
+}
+
+func TestUUID(t *testing.T) {
+	first, diags := eval(t, `uuid()`, nil)
+	require.False(t, diags.HasErrors(), diags.Error())
+	second, _ := eval(t, `uuid()`, nil)
+
+	uuidV4 := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	assert.Regexp(t, uuidV4, first.AsString())
+	assert.NotEqual(t, first, second, "each call returns a new UUID")
+}
+
+func TestTimestamp(t *testing.T) {
+	now = func() time.Time { return time.Date(2025, 3, 4, 7, 6, 7, 0, time.FixedZone("EET", 2*60*60)) }
+	t.Cleanup(func() { now = time.Now })
+
+	got, diags := eval(t, `timestamp()`, nil)
+	require.False(t, diags.HasErrors(), diags.Error())
+	assert.Equal(t, cty.StringVal("2025-03-04T05:06:07Z"), got)
+}
+
+func TestFileFunctions(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "token.txt"), []byte("abc123\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "body.tmpl"), []byte(
+		`%{ for u in users ~}
+${upper(u)} @ ${host}
+%{ endfor ~}`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "self.tmpl"), []byte(`${templatefile("self.tmpl", {})}`), 0644))
+	t.Chdir(dir)
+
+	got, diags := eval(t, `chomp(file("token.txt"))`, nil)
+	require.False(t, diags.HasErrors(), diags.Error())
+	assert.Equal(t, cty.StringVal("abc123"), got)
+
+	got, diags = eval(t, `[fileexists("token.txt"), fileexists("missing.txt")]`, nil)
+	require.False(t, diags.HasErrors(), diags.Error())
+	assert.Equal(t, cty.TupleVal([]cty.Value{cty.True, cty.False}), got)
+
+	got, diags = eval(t, `templatefile("body.tmpl", { users = ["ann", "bob"], host = var.host })`, map[string]cty.Value{
+		"var": cty.ObjectVal(map[string]cty.Value{"host": cty.StringVal("example.com")}),
+	})
+	require.False(t, diags.HasErrors(), diags.Error())
+	assert.Equal(t, cty.StringVal("ANN @ example.com\nBOB @ example.com\n"), got)
+
+	got, diags = eval(t, `templatefile("body.tmpl", { users = ["ann"], host = var.host })`, map[string]cty.Value{
+		"var": cty.ObjectVal(map[string]cty.Value{"host": cty.StringVal("example.com").Mark(sensitive.Mark)}),
+	})
+	require.False(t, diags.HasErrors(), diags.Error())
+	assert.True(t, got.HasMark(sensitive.Mark), "sensitive template variables mark the result")
+
+	_, diags = eval(t, `templatefile("body.tmpl", { users = [] })`, nil)
+	require.True(t, diags.HasErrors())
+	assert.Contains(t, diags[0].Detail, `references "host"`)
+
+	_, diags = eval(t, `templatefile("self.tmpl", {})`, nil)
+	require.True(t, diags.HasErrors(), "templates cannot call templatefile")
+}
+
+func TestCheck(t *testing.T) {
+	ctx := ctxlog.WithLogger(context.Background(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.hcl"), []byte(`
+variable "port" {
+  validation {
+    condition     = tonumbr(var.port) > 0
+    error_message = "bad port"
+  }
+}
+
+locals {
+  url = "https://${lower(var.host)}"
+}
+
+step "print" "a" {
+  arguments {
+    input = jsondecode(local.body).message
+    other = frobnicate(1)
+  }
+}
+`), 0644))
+	grid, err := model.LoadGridsRecursively(ctx, dir)
+	require.NoError(t, err)
+
+	diags := Check(grid)
+	require.Len(t, diags, 2, diags.Error())
+	for _, d := range diags {
+		assert.Equal(t, "Call to unknown function", d.Summary)
+	}
+
+	var typo, unknown *hcl.Diagnostic
+	for _, d := range diags {
+		switch d.Subject.Start.Line {
+		case 4:
+			typo = d
+		case 16:
+			unknown = d
+		}
+	}
+	require.NotNil(t, typo, diags.Error())
+	assert.Equal(t, `There is no function named "tonumbr". Did you mean "tonumber"?`, typo.Detail)
+	assert.Equal(t, 21, typo.Subject.Start.Column)
+	assert.Equal(t, 28, typo.Subject.End.Column, "the subject covers the function name")
+
+	require.NotNil(t, unknown, diags.Error())
+	assert.Equal(t, `There is no function named "frobnicate".`, unknown.Detail)
+}
@@ -0,0 +1,36 @@
+package funcs
+
+import (
+	"strings"
+
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+)
+
+// StartsWithFunc reports whether a string starts with a prefix.
+var StartsWithFunc = makeStringPredicate("prefix", strings.HasPrefix)
+
+// EndsWithFunc reports whether a string ends with a suffix.
+var EndsWithFunc = makeStringPredicate("suffix", strings.HasSuffix)
+
+// StrContainsFunc reports whether a string contains a substring.
+var StrContainsFunc = makeStringPredicate("substr", strings.Contains)
+
+// makeStringPredicate wraps a test of a string against a second string.
+func makeStringPredicate(param string, test func(s, t string) bool) function.Function {
+	return function.New(&function.Spec{
+		Params: []function.Parameter{
+			{Name: "str", Type: cty.String},
+			{Name: param, Type: cty.String},
+		},
+		Type:         function.StaticReturnType(cty.Bool),
+		RefineResult: refineNotNull,
+		Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+			return cty.BoolVal(test(args[0].AsString(), args[1].AsString())), nil
+		},
+	})
+}
+
+func refineNotNull(b *cty.RefinementBuilder) *cty.RefinementBuilder {
+	return b.NotNull()
+}
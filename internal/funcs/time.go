@@ -0,0 +1,22 @@
+package funcs
+
+import (
+	"time"
+
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+)
+
+// now is the clock behind TimestampFunc, replaced in tests.
+var now = time.Now
+
+// TimestampFunc returns the current time in UTC, in RFC 3339 format. Each call
+// reads the clock, so steps evaluated at different times see different values.
+var TimestampFunc = function.New(&function.Spec{
+	Params:       []function.Parameter{},
+	Type:         function.StaticReturnType(cty.String),
+	RefineResult: refineNotNull,
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		return cty.StringVal(now().UTC().Format(time.RFC3339)), nil
+	},
+})
@@ -33,8 +33,9 @@
 //
 // **Topology Store** (topologystore.Store):
 //   - Manages the immutable DAG structure (nodes and dependency edges)
-//   - Write-once during graph construction, read-many during execution
+//   - Written during graph construction, read-many during execution
 //   - Queried by: AllNodes(), Node(), DependenciesOf()
+//   - Grown by: Expand() when a dynamic count/for_each placeholder is resolved
 //
 // **Node Store** (nodestore.Store):
 //   - Manages mutable execution state (status, outputs, errors)
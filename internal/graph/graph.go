@@ -70,6 +70,26 @@ func (m *Manager) DependenciesOf(ctx context.Context, id nodeid.Address) ([]*nod
 	return deps, nil
 }
 
+// DependentsOf retrieves all nodes that directly depend on the given node.
+// The topology store only indexes dependencies, so this scans every node.
+func (m *Manager) DependentsOf(ctx context.Context, id nodeid.Address) ([]*node.Node, error) {
+	target := id.String()
+	var dependents []*node.Node
+	for _, n := range m.topology.AllNodes(ctx) {
+		depIDs, err := m.topology.DependenciesOf(ctx, n.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, depID := range depIDs {
+			if depID.String() == target {
+				dependents = append(dependents, n)
+				break
+			}
+		}
+	}
+	return dependents, nil
+}
+
 // NodeStatus retrieves the current execution status from the node store.
 func (m *Manager) NodeStatus(ctx context.Context, id nodeid.Address) (node.Status, bool) {
 	status, err := m.nodeState.GetStatus(ctx, id)
@@ -82,6 +102,11 @@ func (m *Manager) NodeStatus(ctx context.Context, id nodeid.Address) (node.Statu
 	return status, true
 }
 
+// NodeOutput retrieves a node's recorded output from the node store.
+func (m *Manager) NodeOutput(ctx context.Context, id nodeid.Address) (any, error) {
+	return m.nodeState.GetOutput(ctx, id)
+}
+
 // AllNodes returns all nodes from the topology store.
 func (m *Manager) AllNodes(ctx context.Context) []*node.Node {
 	return m.topology.AllNodes(ctx)
@@ -94,21 +119,38 @@ func (m *Manager) MarkRunning(ctx context.Context, id nodeid.Address) error {
 
 // MarkCompleted transitions a node to Completed status and records its output.
 func (m *Manager) MarkCompleted(ctx context.Context, id nodeid.Address, output any) error {
-	if err := m.nodeState.SetStatus(ctx, id, node.StatusCompleted); err != nil {
+	// Record the output first: dependents may be scheduled as soon as the
+	// status flips, and they read this output when they are built.
+	if err := m.nodeState.SetOutput(ctx, id, output); err != nil {
 		return err
 	}
-	return m.nodeState.SetOutput(ctx, id, output)
+	return m.nodeState.SetStatus(ctx, id, node.StatusCompleted)
 }
 
 // MarkFailed transitions a node to Failed status and records the error.
 func (m *Manager) MarkFailed(ctx context.Context, id nodeid.Address, nodeErr error) error {
-	if err := m.nodeState.SetStatus(ctx, id, node.StatusFailed); err != nil {
+	if err := m.nodeState.SetError(ctx, id, nodeErr); err != nil {
 		return err
 	}
-	return m.nodeState.SetError(ctx, id, nodeErr)
+	return m.nodeState.SetStatus(ctx, id, node.StatusFailed)
 }
 
 // MarkSkipped transitions a node to Skipped status.
 func (m *Manager) MarkSkipped(ctx context.Context, id nodeid.Address) error {
 	return m.nodeState.SetStatus(ctx, id, node.StatusSkipped)
 }
+
+// RecordAttempt stores the number of the attempt being started in the node store.
+func (m *Manager) RecordAttempt(ctx context.Context, id nodeid.Address, attempt int) error {
+	return m.nodeState.SetAttempts(ctx, id, attempt)
+}
+
+// NodeAttempts retrieves the number of recorded attempts from the node store.
+func (m *Manager) NodeAttempts(ctx context.Context, id nodeid.Address) (int, error) {
+	return m.nodeState.GetAttempts(ctx, id)
+}
+
+// Expand inserts a placeholder's runtime instances into the topology store.
+func (m *Manager) Expand(ctx context.Context, placeholder nodeid.Address, instances []*node.Node) error {
+	return m.topology.Expand(ctx, placeholder, instances)
+}
@@ -180,6 +180,32 @@ func TestDependenciesOf_ReturnsFullNodes(t *testing.T) {
 	assert.Equal(t, node1.RawConfig, deps[0].RawConfig)
 }
 
+func TestDependentsOf(t *testing.T) {
+	g := createTestGraph()
+	ctx := context.Background()
+
+	// first is needed by second and third; second is needed by third
+	node1 := addNodeToGraph(t, g, "step.first.0", "print")
+	node2 := addNodeToGraph(t, g, "step.second.0", "http")
+	node3 := addNodeToGraph(t, g, "step.third.0", "file")
+
+	addDependency(t, g, node1.ID.String(), node2.ID.String())
+	addDependency(t, g, node1.ID.String(), node3.ID.String())
+	addDependency(t, g, node2.ID.String(), node3.ID.String())
+
+	dependents, err := g.DependentsOf(ctx, node1.ID)
+	require.NoError(t, err)
+	ids := make([]string, len(dependents))
+	for i, d := range dependents {
+		ids[i] = d.ID.String()
+	}
+	assert.ElementsMatch(t, []string{node2.ID.String(), node3.ID.String()}, ids)
+
+	dependents, err = g.DependentsOf(ctx, node3.ID)
+	require.NoError(t, err)
+	assert.Empty(t, dependents)
+}
+
 func TestNodeStatus_Default(t *testing.T) {
 	g := createTestGraph()
 	ctx := context.Background()
@@ -251,6 +277,24 @@ func TestMarkCompleted_WithOutput(t *testing.T) {
 	assert.Equal(t, expectedOutput, output)
 }
 
+func TestNodeOutput(t *testing.T) {
+	g := createTestGraph()
+	ctx := context.Background()
+
+	testNode := addNodeToGraph(t, g, "step.test.out", "http")
+
+	// No output before completion
+	output, err := g.NodeOutput(ctx, testNode.ID)
+	require.NoError(t, err)
+	assert.Nil(t, output)
+
+	require.NoError(t, g.MarkCompleted(ctx, testNode.ID, "done"))
+
+	output, err = g.NodeOutput(ctx, testNode.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "done", output)
+}
+
 func TestMarkFailed_WithError(t *testing.T) {
 	g := createTestGraph()
 	ctx := context.Background()
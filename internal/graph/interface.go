@@ -57,7 +57,7 @@ import (
 //   - Update execution state: MarkRunning(), MarkCompleted(), MarkFailed()
 //
 // **Builder** uses Graph to:
-//   - Retrieve dependency outputs for expression resolution (via node store internally)
+//   - Retrieve dependency outputs for expression resolution: NodeOutput()
 //
 // # Thread-Safety
 //
@@ -96,6 +96,18 @@ type Graph interface {
 	// Thread-safety: Must be safe to call concurrently.
 	DependenciesOf(ctx context.Context, id nodeid.Address) ([]*node.Node, error)
 
+	// DependentsOf retrieves all nodes that directly depend on the given node.
+	//
+	// Used by executor to skip the dependents of a node that failed with
+	// `on_error { action = "skip_dependents" }`.
+	//
+	// Returns:
+	//   - Slice of dependent nodes (empty if nothing depends on the node)
+	//   - Error if a dependency lookup fails
+	//
+	// Thread-safety: Must be safe to call concurrently.
+	DependentsOf(ctx context.Context, id nodeid.Address) ([]*node.Node, error)
+
 	// NodeStatus retrieves the current execution status of a node.
 	//
 	// Returns the status and true if found, or StatusPending and false if the node
@@ -109,6 +121,15 @@ type Graph interface {
 	// Thread-safety: Must be safe to call concurrently.
 	NodeStatus(ctx context.Context, id nodeid.Address) (node.Status, bool)
 
+	// NodeOutput retrieves the output recorded for a completed node.
+	//
+	// Used by builder to populate the evaluation context with upstream outputs
+	// (e.g., `step.http_request.first.output.body`). Returns nil if the node has
+	// not recorded an output.
+	//
+	// Thread-safety: Must be safe to call concurrently.
+	NodeOutput(ctx context.Context, id nodeid.Address) (any, error)
+
 	// AllNodes returns all nodes registered in the topology.
 	//
 	// Used by scheduler to discover the full set of nodes that need to be executed.
@@ -159,4 +180,28 @@ type Graph interface {
 	//
 	// Thread-safety: Must be safe to call concurrently for different nodes.
 	MarkSkipped(ctx context.Context, id nodeid.Address) error
+
+	// RecordAttempt records that the executor is starting the given attempt
+	// (1 for the first) of a node.
+	//
+	// Called by executor before each try of a step with a `retry` policy.
+	//
+	// Thread-safety: Must be safe to call concurrently for different nodes.
+	RecordAttempt(ctx context.Context, id nodeid.Address, attempt int) error
+
+	// NodeAttempts returns how many attempts have been recorded for a node,
+	// or 0 if none were.
+	//
+	// Thread-safety: Must be safe to call concurrently.
+	NodeAttempts(ctx context.Context, id nodeid.Address) (int, error)
+
+	// Expand replaces a placeholder node with its runtime instances.
+	//
+	// Called by executor when a step with a dynamic `count` or `for_each` becomes
+	// ready. Each instance inherits the placeholder's dependencies, and every
+	// dependent of the placeholder is made to wait for every instance. The
+	// caller marks the placeholder completed afterwards.
+	//
+	// Thread-safety: Must be safe to call concurrently with all other methods.
+	Expand(ctx context.Context, placeholder nodeid.Address, instances []*node.Node) error
 }
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+)
+
+// Env is the environment overlay of a step: its evaluated `env` map. It is
+// applied on top of the process environment for the step alone; the process
+// environment itself is never changed, so concurrent steps cannot see each
+// other's variables.
+type Env map[string]string
+
+type envKey struct{}
+
+// WithEnv returns a context carrying env as the step's environment overlay.
+func WithEnv(ctx context.Context, env Env) context.Context {
+	return context.WithValue(ctx, envKey{}, env)
+}
+
+// EnvFrom returns the environment overlay carried by ctx, or nil.
+func EnvFrom(ctx context.Context) Env {
+	env, _ := ctx.Value(envKey{}).(Env)
+	return env
+}
+
+// LookupEnv is os.LookupEnv for the step running in ctx: variables of its
+// overlay take precedence over the process environment.
+//
+//	token, ok := handlers.LookupEnv(ctx, "API_TOKEN")
+func LookupEnv(ctx context.Context, key string) (string, bool) {
+	if v, ok := EnvFrom(ctx)[key]; ok {
+		return v, true
+	}
+	return os.LookupEnv(key)
+}
+
+// Environ is os.Environ for the step running in ctx: the process environment
+// with the step's overlay applied, in "KEY=value" form.
+func Environ(ctx context.Context) []string {
+	env := EnvFrom(ctx)
+	base := os.Environ()
+	out := make([]string, 0, len(base)+len(env))
+	for _, kv := range base {
+		key, _, _ := strings.Cut(kv, "=")
+		if _, overridden := env[key]; !overridden {
+			out = append(out, kv)
+		}
+	}
+	keys := make([]string, 0, len(env))
+	for k := range env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		out = append(out, k+"="+env[k])
+	}
+	return out
+}
+
+// Command is exec.CommandContext for subprocess-based runners: the command
+// runs in the step's environment (see Environ).
+func Command(ctx context.Context, name string, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Env = Environ(ctx)
+	return cmd
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+)
+
+// Error classes are short names that a step's `retry_on` and `abort_on`
+// lists match against. Handlers attach a class to an error with Classify;
+// errors without one fall into ClassError.
+const (
+	// ClassError is the class of any error that carries no explicit class.
+	ClassError = "error"
+
+	// ClassTimeout is the class of errors caused by an expired deadline.
+	ClassTimeout = "timeout"
+
+	// ClassCancelled is the class of errors caused by a cancelled context.
+	ClassCancelled = "cancelled"
+)
+
+// Classifier is implemented by errors that know their own class.
+type Classifier interface {
+	ErrorClass() string
+}
+
+// ClassifiedError wraps an error with a class, e.g. "http_5xx" or "404".
+type ClassifiedError struct {
+	Class string
+	Err   error
+}
+
+// Classify returns err tagged with class. It returns nil if err is nil.
+//
+//	if resp.StatusCode >= 500 {
+//	    return nil, handlers.Classify("http_5xx", fmt.Errorf("server returned %s", resp.Status))
+//	}
+func Classify(class string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &ClassifiedError{Class: class, Err: err}
+}
+
+func (e *ClassifiedError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ClassifiedError) Unwrap() error {
+	return e.Err
+}
+
+// ErrorClass implements Classifier.
+func (e *ClassifiedError) ErrorClass() string {
+	return e.Class
+}
+
+// ErrorClass returns the class of err: the class of the outermost Classifier
+// in its chain, ClassTimeout or ClassCancelled for context errors, and
+// ClassError otherwise. It returns "" for a nil error.
+func ErrorClass(err error) string {
+	if err == nil {
+		return ""
+	}
+	var c Classifier
+	if errors.As(err, &c) {
+		return c.ErrorClass()
+	}
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return ClassTimeout
+	case errors.Is(err, context.Canceled):
+		return ClassCancelled
+	}
+	return ClassError
+}
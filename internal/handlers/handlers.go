@@ -34,3 +34,9 @@ func (r *Handlers) RegisterHandler(name string, handler *RegisteredHandler) {
 	slog.Debug("Registering runner handler.", "name", name)
 	r.all[name] = handler
 }
+
+// Get returns the handler registered under name.
+func (r *Handlers) Get(name string) (*RegisteredHandler, bool) {
+	h, ok := r.all[name]
+	return h, ok
+}
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+var (
+	contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+	errorType   = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// NewInput returns a pointer to a fresh, zero-valued Input struct for the
+// handler. It prefers the Input factory and falls back to InputType.
+func (h *RegisteredHandler) NewInput() (any, error) {
+	switch {
+	case h.Input != nil:
+		return h.Input(), nil
+	case h.InputType != nil:
+		return reflect.New(h.InputType).Interface(), nil
+	default:
+		return nil, fmt.Errorf("handler declares neither Input nor InputType")
+	}
+}
+
+// Invoke calls the handler's Fn with the given context, a fresh Deps value
+// and input, and returns its result.
+//
+// Fn must have the shape `func(context.Context, D, I) (O, error)`, where D
+// and I are assignable from what Deps() and input provide. A nil Deps factory
+// passes the zero value of D.
+func (h *RegisteredHandler) Invoke(ctx context.Context, input any) (any, error) {
+	fn := reflect.ValueOf(h.Fn)
+	if err := checkSignature(fn); err != nil {
+		return nil, err
+	}
+	fnType := fn.Type()
+
+	depsVal := reflect.Zero(fnType.In(1))
+	if h.Deps != nil {
+		if deps := h.Deps(); deps != nil {
+			depsVal = reflect.ValueOf(deps)
+		}
+	}
+	if !depsVal.Type().AssignableTo(fnType.In(1)) {
+		return nil, fmt.Errorf("handler deps of type %s cannot be passed as %s", depsVal.Type(), fnType.In(1))
+	}
+
+	inputVal := reflect.Zero(fnType.In(2))
+	if input != nil {
+		inputVal = reflect.ValueOf(input)
+	}
+	if !inputVal.Type().AssignableTo(fnType.In(2)) {
+		return nil, fmt.Errorf("handler input of type %s cannot be passed as %s", inputVal.Type(), fnType.In(2))
+	}
+
+	out := fn.Call([]reflect.Value{reflect.ValueOf(ctx), depsVal, inputVal})
+
+	var err error
+	if errVal := out[1]; !errVal.IsNil() {
+		err = errVal.Interface().(error)
+	}
+	return out[0].Interface(), err
+}
+
+// Validate checks that the handler is well-formed without calling it: Fn has
+// the expected shape and the Input and Deps factories produce values Fn accepts.
+func (h *RegisteredHandler) Validate() error {
+	fn := reflect.ValueOf(h.Fn)
+	if err := checkSignature(fn); err != nil {
+		return err
+	}
+	fnType := fn.Type()
+
+	input, err := h.NewInput()
+	if err != nil {
+		return err
+	}
+	if input == nil || !reflect.TypeOf(input).AssignableTo(fnType.In(2)) {
+		return fmt.Errorf("handler input of type %T cannot be passed as %s", input, fnType.In(2))
+	}
+	if h.Deps != nil {
+		if deps := h.Deps(); deps != nil && !reflect.TypeOf(deps).AssignableTo(fnType.In(1)) {
+			return fmt.Errorf("handler deps of type %T cannot be passed as %s", deps, fnType.In(1))
+		}
+	}
+	return nil
+}
+
+// checkSignature verifies that fn is `func(context.Context, D, I) (O, error)`.
+func checkSignature(fn reflect.Value) error {
+	if !fn.IsValid() || fn.Kind() != reflect.Func || fn.IsNil() {
+		return fmt.Errorf("handler Fn is not a function")
+	}
+	t := fn.Type()
+	if t.NumIn() != 3 || t.NumOut() != 2 || t.IsVariadic() {
+		return fmt.Errorf("handler Fn has signature %s, want func(context.Context, deps, input) (output, error)", t)
+	}
+	if !contextType.AssignableTo(t.In(0)) {
+		return fmt.Errorf("handler Fn first parameter is %s, want context.Context", t.In(0))
+	}
+	if t.Out(1) != errorType {
+		return fmt.Errorf("handler Fn second result is %s, want error", t.Out(1))
+	}
+	return nil
+}
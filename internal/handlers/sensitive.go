@@ -0,0 +1,32 @@
+package handlers
+
+import "context"
+
+type sensitiveKey struct{}
+
+// WithSensitiveInputs returns a context recording which of the handler's
+// inputs, by name, are sensitive in whole or in part.
+func WithSensitiveInputs(ctx context.Context, names []string) context.Context {
+	if len(names) == 0 {
+		return ctx
+	}
+	set := make(map[string]struct{}, len(names))
+	for _, name := range names {
+		set[name] = struct{}{}
+	}
+	return context.WithValue(ctx, sensitiveKey{}, set)
+}
+
+// InputSensitive reports whether the named input of the handler running in
+// ctx is sensitive. Handlers receive plain values, so one that shows its
+// inputs as they are, rather than as text the logger can redact, should check
+// this first:
+//
+//	if handlers.InputSensitive(ctx, "input") {
+//		value = sensitive.Redacted
+//	}
+func InputSensitive(ctx context.Context, name string) bool {
+	set, _ := ctx.Value(sensitiveKey{}).(map[string]struct{})
+	_, ok := set[name]
+	return ok
+}
@@ -51,15 +51,17 @@ import (
 //   - states: Maps node ID strings to node.Status (Pending, Running, Completed, Failed)
 //   - outputs: Maps node ID strings to execution outputs (any type, typically map[string]interface{})
 //   - errors: Maps node ID strings to error objects for failed nodes
+//   - attempts: Maps node ID strings to the number of attempts made
 //
 // Thread-safety is guaranteed by sync.Map's built-in concurrency control:
 //   - Multiple goroutines can safely read/write different keys simultaneously
 //   - Read-heavy workloads (after initial writes) are lock-free
 //   - Write contention on the same key is handled internally by sync.Map
 type Store struct {
-	states  sync.Map // Key: node ID string, Value: node.Status
-	outputs sync.Map // Key: node ID string, Value: any (output data)
-	errors  sync.Map // Key: node ID string, Value: error
+	states   sync.Map // Key: node ID string, Value: node.Status
+	outputs  sync.Map // Key: node ID string, Value: any (output data)
+	errors   sync.Map // Key: node ID string, Value: error
+	attempts sync.Map // Key: node ID string, Value: int
 }
 
 // New creates a new, empty in-memory node state store.
@@ -112,3 +114,18 @@ func (s *Store) GetError(ctx context.Context, id nodeid.Address) (error, error)
 	}
 	return err.(error), nil
 }
+
+// SetAttempts records the number of attempts made for a node.
+func (s *Store) SetAttempts(ctx context.Context, id nodeid.Address, attempts int) error {
+	s.attempts.Store(id.String(), attempts)
+	return nil
+}
+
+// GetAttempts retrieves the number of attempts made for a node.
+func (s *Store) GetAttempts(ctx context.Context, id nodeid.Address) (int, error) {
+	attempts, ok := s.attempts.Load(id.String())
+	if !ok {
+		return 0, nil // Not attempted yet.
+	}
+	return attempts.(int), nil
+}
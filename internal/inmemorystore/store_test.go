@@ -136,3 +136,38 @@ func TestStore_ConcurrentAccess(t *testing.T) {
 
 	wg.Wait() // Wait for all reads to complete
 }
+
+func TestKeyedAndIndexedAddressesAreDistinct(t *testing.T) {
+	s := New()
+	ctx := context.Background()
+	indexed, err := nodeid.Parse("step.http_request.call[0]")
+	require.NoError(t, err)
+	keyed, err := nodeid.Parse(`step.http_request.call["0"]`)
+	require.NoError(t, err)
+
+	require.NoError(t, s.SetOutput(ctx, *indexed, "by index"))
+	require.NoError(t, s.SetOutput(ctx, *keyed, "by key"))
+
+	out, err := s.GetOutput(ctx, *indexed)
+	require.NoError(t, err)
+	assert.Equal(t, "by index", out)
+	out, err = s.GetOutput(ctx, *keyed)
+	require.NoError(t, err)
+	assert.Equal(t, "by key", out)
+}
+
+func TestSetAndGetAttempts(t *testing.T) {
+	s := New()
+	ctx := context.Background()
+	addr, err := nodeid.Parse("step.test.0")
+	require.NoError(t, err)
+
+	attempts, err := s.GetAttempts(ctx, *addr)
+	require.NoError(t, err)
+	assert.Equal(t, 0, attempts)
+
+	require.NoError(t, s.SetAttempts(ctx, *addr, 3))
+	attempts, err = s.GetAttempts(ctx, *addr)
+	require.NoError(t, err)
+	assert.Equal(t, 3, attempts)
+}
@@ -142,3 +142,40 @@ func (s *Store) DependenciesOf(ctx context.Context, id nodeid.Address) ([]nodeid
 	}
 	return deps, nil
 }
+
+// Expand inserts the instances of a placeholder node and wires them into the
+// placeholder's position in the graph under a single write lock.
+func (s *Store) Expand(ctx context.Context, placeholder nodeid.Address, instances []*node.Node) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := placeholder.String()
+	if _, exists := s.nodes[key]; !exists {
+		return fmt.Errorf("placeholder node '%s' not found in topology", key)
+	}
+	for _, n := range instances {
+		if _, exists := s.nodes[n.ID.String()]; exists {
+			return fmt.Errorf("instance node '%s' already exists in topology", n.ID.String())
+		}
+	}
+
+	var dependents []string
+	for toKey, depSet := range s.deps {
+		if _, ok := depSet[key]; ok {
+			dependents = append(dependents, toKey)
+		}
+	}
+
+	for _, n := range instances {
+		instKey := n.ID.String()
+		s.nodes[instKey] = n
+		s.deps[instKey] = make(map[string]struct{}, len(s.deps[key]))
+		for depKey := range s.deps[key] {
+			s.deps[instKey][depKey] = struct{}{}
+		}
+		for _, toKey := range dependents {
+			s.deps[toKey][instKey] = struct{}{}
+		}
+	}
+	return nil
+}
@@ -47,3 +47,59 @@ func TestDependencies(t *testing.T) {
 	require.Len(t, deps, 1)
 	assert.True(t, addr1.Equal(&deps[0])) // FIX: Correct comparison logic
 }
+
+func TestExpand(t *testing.T) {
+	s := New()
+	ctx := context.Background()
+	up, _ := nodeid.Parse("step.source.n")
+	ph, _ := nodeid.Parse("step.work.items")
+	down, _ := nodeid.Parse("step.sink.all")
+	for _, addr := range []*nodeid.Address{up, ph, down} {
+		require.NoError(t, s.AddNode(ctx, &node.Node{ID: *addr}))
+	}
+	require.NoError(t, s.AddDependency(ctx, *up, *ph))
+	require.NoError(t, s.AddDependency(ctx, *ph, *down))
+
+	inst0, _ := nodeid.Parse("step.work.items[0]")
+	inst1, _ := nodeid.Parse("step.work.items[1]")
+	err := s.Expand(ctx, *ph, []*node.Node{{ID: *inst0}, {ID: *inst1}})
+	require.NoError(t, err)
+
+	_, ok := s.GetNode(ctx, *inst1)
+	assert.True(t, ok)
+
+	deps, err := s.DependenciesOf(ctx, *inst0)
+	require.NoError(t, err)
+	require.Len(t, deps, 1)
+	assert.True(t, up.Equal(&deps[0]))
+
+	deps, err = s.DependenciesOf(ctx, *down)
+	require.NoError(t, err)
+	assert.Len(t, deps, 3, "dependent waits for the placeholder and both instances")
+
+	// Expanding again would duplicate the instances.
+	assert.Error(t, s.Expand(ctx, *ph, []*node.Node{{ID: *inst0}}))
+	missing, _ := nodeid.Parse("step.work.missing")
+	assert.Error(t, s.Expand(ctx, *missing, nil))
+}
+
+func TestKeyedAddresses(t *testing.T) {
+	s := New()
+	ctx := context.Background()
+	us, _ := nodeid.Parse(`step.http_request.call["us.east [1]"]`)
+	eu, _ := nodeid.Parse(`step.http_request.call["eu"]`)
+	down, _ := nodeid.Parse("step.print.out")
+	for _, addr := range []*nodeid.Address{us, eu, down} {
+		require.NoError(t, s.AddNode(ctx, &node.Node{ID: *addr}))
+	}
+	require.NoError(t, s.AddDependency(ctx, *us, *down))
+
+	n, ok := s.GetNode(ctx, *us)
+	require.True(t, ok)
+	assert.True(t, us.Equal(&n.ID))
+
+	deps, err := s.DependenciesOf(ctx, *down)
+	require.NoError(t, err)
+	require.Len(t, deps, 1)
+	assert.True(t, us.Equal(&deps[0]), "keyed dependency should survive the string round-trip, got %s", deps[0].String())
+}
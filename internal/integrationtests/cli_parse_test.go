@@ -69,6 +69,26 @@ func TestParse(t *testing.T) {
 				HealthcheckPort: 0,
 			},
 		},
+		{
+			name: "Repeated var and var-file flags",
+			args: []string{
+				"--var", "host=example.com",
+				"--var=port=8080",
+				"--var-file", "staging.hcl",
+				"--var-file=secrets.json",
+				"/path",
+			},
+			expectedConfig: &app.Config{
+				GridPath:        "/path",
+				ModulesPath:     "modules",
+				LogLevel:        "info",
+				LogFormat:       "json",
+				WorkerCount:     10,
+				HealthcheckPort: 0,
+				Vars:            []string{"host=example.com", "port=8080"},
+				VarFiles:        []string{"staging.hcl", "secrets.json"},
+			},
+		},
 		{
 			name:       "Help flag triggers clean exit",
 			args:       []string{"-h"},
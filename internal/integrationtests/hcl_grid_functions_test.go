@@ -0,0 +1,96 @@
+package integration_tests
+
+import (
+	"testing"
+
+	"github.com/specialistvlad/burstgridgo/internal/handlers"
+	"github.com/specialistvlad/burstgridgo/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGridLoader_EvaluatesFunctions(t *testing.T) {
+	// --- Arrange ---
+	files := map[string]string{
+		"grid/main.hcl": `
+			variable "host" {
+				default = "Example.COM"
+				validation {
+					condition     = strlen(var.host) > 0
+					error_message = "The host must not be empty."
+				}
+			}
+
+			locals {
+				base_url = format("https://%s", lower(var.host))
+				digest   = sha256(jsonencode({ url = local.base_url }))
+			}
+
+			step "print" "hello" {
+				arguments {
+					message = upper(local.base_url)
+				}
+			}
+		`,
+	}
+
+	// --- Act ---
+	result := testutil.RunIntegrationTest(t, files, handlers.New())
+
+	// --- Assert ---
+	require.NoError(t, result.Err)
+	assert.Equal(t, []string{"upper"}, result.App.Grid().Steps[0].Expressions.CalledFunctions())
+}
+
+func TestGridLoader_UnknownFunctions(t *testing.T) {
+	testCases := []struct {
+		name    string
+		src     string
+		wantErr string
+	}{
+		{
+			name: "in a step argument",
+			src: `
+				step "print" "hello" {
+					arguments {
+						message = uper("hello")
+					}
+				}`,
+			wantErr: `main.hcl:4,17-21: Call to unknown function; There is no function named "uper". Did you mean "upper"?`,
+		},
+		{
+			name: "nested in a traversal",
+			src: `
+				step "print" "hello" {
+					arguments {
+						message = json_decode("{}").message
+					}
+				}`,
+			wantErr: `There is no function named "json_decode". Did you mean "jsondecode"?`,
+		},
+		{
+			name:    "in a local value",
+			src:     `locals { id = generate_id() }`,
+			wantErr: `There is no function named "generate_id".`,
+		},
+		{
+			name: "in a variable validation",
+			src: `
+				variable "port" {
+					validation {
+						condition     = is_port(var.port)
+						error_message = "bad port"
+					}
+				}`,
+			wantErr: `There is no function named "is_port".`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := testutil.RunIntegrationTest(t, map[string]string{"grid/main.hcl": tc.src}, handlers.New())
+			require.Error(t, result.Err)
+			assert.Contains(t, result.Err.Error(), tc.wantErr)
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package integration_tests
+
+import (
+	"testing"
+
+	"github.com/specialistvlad/burstgridgo/internal/handlers"
+	"github.com/specialistvlad/burstgridgo/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGridLoader_ParsesLocals(t *testing.T) {
+	// --- Arrange ---
+	files := map[string]string{
+		"grid/locals.hcl": `
+			variable "host" {
+				default = "localhost"
+			}
+
+			locals {
+				base_url = "https://${var.host}"
+				users    = "${local.base_url}/users"
+			}
+		`,
+		"grid/main.hcl": `
+			locals {
+				greeting = "Hello from ${local.users}"
+			}
+
+			step "print" "hello" {
+				arguments {
+					message = local.greeting
+				}
+			}
+		`,
+	}
+
+	// --- Act ---
+	result := testutil.RunIntegrationTest(t, files, handlers.New())
+
+	// --- Assert ---
+	require.NoError(t, result.Err)
+	var names []string
+	for _, l := range result.App.Grid().Locals {
+		names = append(names, l.Name)
+	}
+	assert.ElementsMatch(t, []string{"base_url", "users", "greeting"}, names)
+}
+
+func TestGridLoader_LocalErrors(t *testing.T) {
+	testCases := []struct {
+		name    string
+		files   map[string]string
+		wantErr string
+	}{
+		{
+			name: "duplicate across files",
+			files: map[string]string{
+				"grid/a.hcl": `locals { base_url = "a" }`,
+				"grid/b.hcl": `locals { base_url = "b" }`,
+			},
+			wantErr: "Duplicate local value definition",
+		},
+		{
+			name: "duplicate across blocks",
+			files: map[string]string{
+				"grid/main.hcl": `
+					locals { base_url = "a" }
+					locals { base_url = "b" }`,
+			},
+			wantErr: "Duplicate local value definition",
+		},
+		{
+			name: "nested block",
+			files: map[string]string{
+				"grid/main.hcl": `
+					locals {
+						nested {}
+					}`,
+			},
+			wantErr: "Unexpected \"nested\" block",
+		},
+		{
+			name: "cycle",
+			files: map[string]string{
+				"grid/main.hcl": `
+					locals {
+						a = local.b
+						b = local.a
+					}`,
+			},
+			wantErr: "Cycle in local values",
+		},
+		{
+			name: "static evaluation error",
+			files: map[string]string{
+				"grid/main.hcl": `
+					locals {
+						port = 1 + "two"
+					}`,
+			},
+			wantErr: "Invalid operand",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := testutil.RunIntegrationTest(t, tc.files, handlers.New())
+			require.Error(t, result.Err)
+			assert.Contains(t, result.Err.Error(), tc.wantErr)
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package integration_tests
+
+import (
+	"testing"
+
+	"github.com/specialistvlad/burstgridgo/internal/handlers"
+	"github.com/specialistvlad/burstgridgo/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGridLoader_ParsesResources(t *testing.T) {
+	// --- Arrange ---
+	files := map[string]string{
+		"grid/resources.hcl": `
+			resource "http_client" "shared" {
+				env = {
+					HTTPS_PROXY = "http://proxy:3128"
+				}
+				arguments {
+					timeout = "45s"
+				}
+			}
+		`,
+		"grid/main.hcl": `
+			step "print" "hello" {
+				uses = [resource.http_client.shared]
+				arguments {
+					message = "Hello"
+				}
+			}
+		`,
+	}
+
+	// --- Act ---
+	result := testutil.RunIntegrationTest(t, files, handlers.New())
+
+	// --- Assert ---
+	require.NoError(t, result.Err)
+	grid := result.App.Grid()
+	require.Len(t, grid.Resources, 1)
+
+	r := grid.Resource("http_client", "shared")
+	require.NotNil(t, r)
+	assert.Equal(t, "resource.http_client.shared", r.Address())
+	assert.NotNil(t, r.Env)
+	assert.Contains(t, r.Arguments, "timeout")
+	assert.Nil(t, grid.Resource("http_client", "other"))
+
+	require.Len(t, grid.Steps, 1)
+	refs := grid.Steps[0].ResourceReferences()
+	require.Len(t, refs, 1)
+	assert.Equal(t, "resource", refs[0].RootName())
+}
+
+func TestGridLoader_ResourceErrors(t *testing.T) {
+	testCases := []struct {
+		name    string
+		files   map[string]string
+		wantErr string
+	}{
+		{
+			name: "duplicate across files",
+			files: map[string]string{
+				"grid/a.hcl": `resource "http_client" "shared" {}`,
+				"grid/b.hcl": `resource "http_client" "shared" {}`,
+			},
+			wantErr: "Duplicate resource definition",
+		},
+		{
+			name: "unknown attribute",
+			files: map[string]string{
+				"grid/main.hcl": `
+					resource "http_client" "shared" {
+						timeout = "45s"
+					}`,
+			},
+			wantErr: "Unsupported argument",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := testutil.RunIntegrationTest(t, tc.files, handlers.New())
+			require.Error(t, result.Err)
+			assert.Contains(t, result.Err.Error(), tc.wantErr)
+		})
+	}
+}
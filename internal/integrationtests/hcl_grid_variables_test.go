@@ -0,0 +1,137 @@
+package integration_tests
+
+import (
+	"testing"
+
+	"github.com/specialistvlad/burstgridgo/internal/handlers"
+	"github.com/specialistvlad/burstgridgo/internal/model"
+	"github.com/specialistvlad/burstgridgo/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestGridLoader_ParsesVariables(t *testing.T) {
+	// --- Arrange ---
+	files := map[string]string{
+		"grid/variables.hcl": `
+			variable "host" {
+				type        = string
+				description = "The host under test."
+				default     = "localhost"
+
+				validation {
+					condition     = var.host != ""
+					error_message = "The host must not be empty."
+				}
+			}
+
+			variable "token" {
+				sensitive = true
+				default   = "s3cr3t"
+			}
+		`,
+		"grid/main.hcl": `
+			step "print" "hello" {
+				arguments {
+					message = "Hello, ${var.host}!"
+				}
+			}
+		`,
+	}
+
+	// --- Act ---
+	result := testutil.RunIntegrationTest(t, files, handlers.New())
+
+	// --- Assert ---
+	require.NoError(t, result.Err)
+	vars := result.App.Grid().Variables
+	require.Len(t, vars, 2)
+
+	byName := map[string]*model.Variable{}
+	for _, v := range vars {
+		byName[v.Name] = v
+	}
+
+	host := byName["host"]
+	require.NotNil(t, host)
+	assert.True(t, host.Type.Equals(cty.String))
+	assert.Equal(t, "The host under test.", host.Description)
+	require.NotNil(t, host.Default)
+	assert.Equal(t, cty.StringVal("localhost"), *host.Default)
+	assert.False(t, host.Sensitive)
+	require.Len(t, host.Validations, 1)
+
+	token := byName["token"]
+	require.NotNil(t, token)
+	assert.Equal(t, cty.DynamicPseudoType, token.Type, "a variable without a type accepts any value")
+	assert.True(t, token.Sensitive)
+
+	resolved := result.App.Variables()
+	assert.Equal(t, cty.StringVal("localhost"), resolved.GetAttr("host"))
+}
+
+func TestGridLoader_VariableErrors(t *testing.T) {
+	testCases := []struct {
+		name    string
+		files   map[string]string
+		wantErr string
+	}{
+		{
+			name: "duplicate across files",
+			files: map[string]string{
+				"grid/a.hcl": `variable "host" {}`,
+				"grid/b.hcl": `variable "host" {}`,
+			},
+			wantErr: "Duplicate variable definition",
+		},
+		{
+			name: "default of the wrong type",
+			files: map[string]string{
+				"grid/main.hcl": `
+					variable "port" {
+						type    = number
+						default = "eighty"
+					}`,
+			},
+			wantErr: "Invalid default value type",
+		},
+		{
+			name: "unknown attribute",
+			files: map[string]string{
+				"grid/main.hcl": `
+					variable "port" {
+						required = true
+					}`,
+			},
+			wantErr: "Unsupported argument",
+		},
+		{
+			name: "validation without an error message",
+			files: map[string]string{
+				"grid/main.hcl": `
+					variable "port" {
+						validation {
+							condition = true
+						}
+					}`,
+			},
+			wantErr: "Missing required argument",
+		},
+		{
+			name: "missing required value",
+			files: map[string]string{
+				"grid/main.hcl": `variable "bggo_test_unset" {}`,
+			},
+			wantErr: "Missing required variable",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := testutil.RunIntegrationTest(t, tc.files, handlers.New())
+			require.Error(t, result.Err)
+			assert.Contains(t, result.Err.Error(), tc.wantErr)
+		})
+	}
+}
@@ -138,19 +138,124 @@ func TestRunnerParsing_Input(t *testing.T) {
 		}
 	})
 
-	t.Run("Panic on unimplemented complex type", func(t *testing.T) {
+	t.Run("Success: Parses complex type constraints", func(t *testing.T) {
 		t.Parallel()
 		hcl := `
+		runner "test" {
+			lifecycle { on_run = "OnRun" }
+
+			input "payload" {
+			type = any
+			}
+
+			input "tags" {
+			type    = list(string)
+			default = ["a", "b"]
+			}
+
+			input "headers" {
+			type = map(string)
+			}
+
+			input "targets" {
+			type = map(object({
+				url     = string
+				retries = optional(number, 3)
+				labels  = optional(set(string))
+			}))
+			default = {
+				primary = { url = "https://example.com" }
+			}
+			}
+
+			input "pair" {
+			type = tuple([string, number])
+			}
+		}`
+
+		runner, err := testutil.RunRunnerParsingTest(t, hcl)
+		require.NoError(t, err)
+		require.Len(t, runner.Inputs, 5)
+
+		require.Equal(t, cty.DynamicPseudoType, runner.Inputs["payload"].Type)
+		require.True(t, runner.Inputs["headers"].Type.Equals(cty.Map(cty.String)))
+		require.True(t, runner.Inputs["pair"].Type.Equals(cty.Tuple([]cty.Type{cty.String, cty.Number})))
+
+		tags := runner.Inputs["tags"]
+		require.True(t, tags.Type.Equals(cty.List(cty.String)))
+		require.NotNil(t, tags.Default)
+		require.True(t, cty.ListVal([]cty.Value{cty.StringVal("a"), cty.StringVal("b")}).RawEquals(*tags.Default),
+			"a tuple default is converted to the declared list type")
+
+		targets := runner.Inputs["targets"]
+		require.True(t, targets.Type.Equals(cty.Map(cty.ObjectWithOptionalAttrs(map[string]cty.Type{
+			"url":     cty.String,
+			"retries": cty.Number,
+			"labels":  cty.Set(cty.String),
+		}, []string{"retries", "labels"}))))
+		require.NotNil(t, targets.Default)
+		primary := targets.Default.Index(cty.StringVal("primary"))
+		require.True(t, cty.NumberIntVal(3).Equals(primary.GetAttr("retries")).True(), "optional attributes take their defaults")
+		require.True(t, primary.GetAttr("labels").IsNull(), "optional attributes without defaults are null")
+	})
+
+	t.Run("Failure: Invalid complex type constraints", func(t *testing.T) {
+		t.Parallel()
+		cases := []struct {
+			name        string
+			hcl         string
+			errContains string
+		}{
+			{
+				name: "collection without element type",
+				hcl: `
 			runner "test" {
 			input "a" {
 			type = list
 			}
-			}`
+			}`,
+				errContains: "The list type constructor requires one argument specifying the element type.",
+			},
+			{
+				name: "unknown nested type keyword",
+				hcl: `
+			runner "test" {
+			input "a" {
+			type = map(integer)
+			}
+			}`,
+				errContains: `The keyword "integer" is not a valid type specification.`,
+			},
+			{
+				name: "optional outside an object",
+				hcl: `
+			runner "test" {
+			input "a" {
+			type = list(optional(string))
+			}
+			}`,
+				errContains: "Keyword \"optional\" is valid only as a modifier for object type attributes.",
+			},
+			{
+				name: "default with a mistyped element",
+				hcl: `
+			runner "test" {
+			input "a" {
+			type    = list(string)
+			default = ["a", 1]
+			}
+			}`,
+				errContains: "Invalid default value type",
+			},
+		}
 
-		// --- FIX: Changed from PanicsWithError to PanicsWithValue to match a string panic ---
-		require.PanicsWithValue(t, "PANIC: The complex type 'list' is not yet implemented.", func() {
-			// We only care about the panic, not the return values.
-			_, _ = testutil.RunRunnerParsingTest(t, hcl)
-		})
+		for _, tc := range cases {
+			t.Run(tc.name, func(t *testing.T) {
+				t.Parallel()
+				_, err := testutil.RunRunnerParsingTest(t, tc.hcl)
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tc.errContains)
+			})
+		}
 	})
 }
@@ -44,6 +44,31 @@ func TestRunnerParsing_Output(t *testing.T) {
 		require.Equal(t, "success", successOutput.Name)
 		require.Empty(t, successOutput.Description, "Description should be empty")
 		require.True(t, successOutput.Type.Equals(cty.Bool), "Type should be cty.Bool")
+		require.Nil(t, successOutput.TypeDefaults)
+	})
+
+	t.Run("Success: Keeps the defaults of optional attributes", func(t *testing.T) {
+		t.Parallel()
+		hcl := `
+		runner "test" {
+			output "endpoint" {
+				type = object({
+					host = string
+					port = optional(number, 443)
+				})
+			}
+		}`
+
+		runner, err := testutil.RunRunnerParsingTest(t, hcl)
+		require.NoError(t, err)
+
+		endpoint := runner.Outputs["endpoint"]
+		require.True(t, endpoint.Type.Equals(cty.ObjectWithOptionalAttrs(map[string]cty.Type{
+			"host": cty.String,
+			"port": cty.Number,
+		}, []string{"port"})))
+		require.NotNil(t, endpoint.TypeDefaults)
+		require.True(t, cty.NumberIntVal(443).RawEquals(endpoint.TypeDefaults.DefaultValues["port"]))
 	})
 
 	t.Run("Failure: Invalid output block definitions", func(t *testing.T) {
@@ -72,7 +72,7 @@ func TestHCLExtraction_AllExpressionTypes(t *testing.T) {
 	gridHCL := `
 		step "test" "comprehensive" {
 			arguments {
-				binary   = len(var.list) + abs(-1)
+				binary   = length(var.list) + abs(-1)
 				cond     = var.cond ? upper("A") : lower("B")
 				template = "val is ${upper(var.name)}"
 				tuple    = [upper("x")]
@@ -109,7 +109,7 @@ func TestHCLExtraction_AllExpressionTypes(t *testing.T) {
 	// --- Assert on Functions ---
 	expectedFns := []string{
 		"abs",
-		"len",
+		"length",
 		"lower",
 		"upper",
 	}
@@ -0,0 +1,134 @@
+package integration_tests
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/specialistvlad/burstgridgo/internal/handlers"
+	"github.com/specialistvlad/burstgridgo/internal/registry"
+	"github.com/specialistvlad/burstgridgo/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zclconf/go-cty/cty"
+
+	prnt "github.com/specialistvlad/burstgridgo/modules/print"
+)
+
+type contractInput struct {
+	URL     string `bggo:"url"`
+	Retries int    `bggo:"retries"`
+	Debug   bool   `bggo:"debug"`
+}
+
+func contractHandler() *handlers.RegisteredHandler {
+	return &handlers.RegisteredHandler{
+		Input: func() any { return new(contractInput) },
+		Fn: func(ctx context.Context, deps any, input *contractInput) (any, error) {
+			return nil, nil
+		},
+	}
+}
+
+func TestModuleContracts_Match(t *testing.T) {
+	t.Parallel()
+	files := map[string]string{
+		"modules/http/manifest.hcl": `
+			runner "http" {
+				lifecycle { on_run = "OnRunHTTP" }
+				input "url" { type = string }
+				input "retries" {
+					type    = number
+					default = 3
+				}
+				input "debug" { type = bool }
+			}`,
+	}
+	hndls := handlers.New()
+	hndls.RegisterHandler("OnRunHTTP", contractHandler())
+
+	result := testutil.RunIntegrationTest(t, files, hndls)
+	require.NoError(t, result.Err)
+
+	assert.NoError(t, result.App.ValidateModules())
+}
+
+func TestModuleContracts_ReportsEveryMismatch(t *testing.T) {
+	t.Parallel()
+	files := map[string]string{
+		"modules/http/manifest.hcl": `
+			runner "http" {
+				lifecycle { on_run = "OnRunHTTP" }
+				input "url" { type = number }
+				input "timeout" { type = string }
+				input "debug" { type = bool }
+			}`,
+		"modules/missing/manifest.hcl": `
+			runner "missing" {
+				lifecycle { on_run = "OnRunMissing" }
+			}`,
+		"modules/nolifecycle/manifest.hcl": `
+			runner "nolifecycle" {}`,
+	}
+	hndls := handlers.New()
+	hndls.RegisterHandler("OnRunHTTP", contractHandler())
+
+	result := testutil.RunIntegrationTest(t, files, hndls)
+	require.NoError(t, result.Err)
+
+	err := result.App.ValidateModules()
+	require.Error(t, err)
+
+	var contractErr *registry.ContractError
+	require.ErrorAs(t, err, &contractErr)
+	assert.Len(t, contractErr.Mismatches, 5)
+
+	msg := err.Error()
+	assert.Contains(t, msg, `input "url" is declared as number but its Go field is string`)
+	assert.Contains(t, msg, `input "timeout" has no Go field tagged`)
+	assert.Contains(t, msg, `Go field for "retries" has no matching input block`)
+	assert.Contains(t, msg, `on_run handler "OnRunMissing" is not registered`)
+	assert.Contains(t, msg, `runner "nolifecycle"`)
+	assert.Contains(t, msg, "lifecycle.on_run is not set")
+}
+
+func TestModuleContracts_InvalidHandlerSignature(t *testing.T) {
+	t.Parallel()
+	files := map[string]string{
+		"modules/bad/manifest.hcl": `
+			runner "bad" {
+				lifecycle { on_run = "OnRunBad" }
+			}`,
+	}
+	hndls := handlers.New()
+	hndls.RegisterHandler("OnRunBad", &handlers.RegisteredHandler{
+		Input: func() any { return new(struct{}) },
+		Fn:    func(input *struct{}) error { return nil },
+	})
+
+	result := testutil.RunIntegrationTest(t, files, hndls)
+	require.NoError(t, result.Err)
+
+	err := result.App.ValidateModules()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `handler "OnRunBad"`)
+	assert.Contains(t, err.Error(), "want func(context.Context, deps, input) (output, error)")
+}
+
+// TestModuleContracts_StockModules loads the manifests shipped in the modules
+// directory against their real handlers.
+func TestModuleContracts_StockModules(t *testing.T) {
+	t.Parallel()
+	manifest, err := os.ReadFile("../../modules/print/manifest.hcl")
+	require.NoError(t, err)
+	hndls := handlers.New()
+	prnt.RegisterHandler(hndls)
+
+	result := testutil.RunIntegrationTest(t, map[string]string{"modules/print/manifest.hcl": string(manifest)}, hndls)
+	require.NoError(t, result.Err)
+	assert.NoError(t, result.App.ValidateModules())
+
+	runner, ok := result.App.Registry().Runner("print")
+	require.True(t, ok)
+	assert.Equal(t, cty.DynamicPseudoType, runner.Inputs["input"].Type, "`type = any` accepts a value of any type")
+}
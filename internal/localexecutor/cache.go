@@ -0,0 +1,94 @@
+package localexecutor
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/specialistvlad/burstgridgo/internal/bggoexpr"
+	"github.com/specialistvlad/burstgridgo/internal/cache"
+	"github.com/specialistvlad/burstgridgo/internal/ctxlog"
+	"github.com/specialistvlad/burstgridgo/internal/task"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// cacheSlot is where a task's output is cached, from its evaluated `cache` block.
+type cacheSlot struct {
+	store   cache.Store
+	scope   string
+	key     string
+	ttl     time.Duration
+	restore bool
+}
+
+// cacheSlot evaluates the `cache` block of a built task. It returns nil when
+// the step has no block or caching is disabled.
+func (e *Executor) cacheSlot(t *task.Task) (*cacheSlot, error) {
+	step := t.Node.Config
+	if step == nil || step.Cache == nil {
+		return nil, nil
+	}
+	c := step.Cache
+	var diags, d hcl.Diagnostics
+
+	enabled, hasEnabled, d := bggoexpr.EvalBool(c.Enabled, t.EvalContext, "enabled")
+	diags = append(diags, d...)
+	userKey, _, d := bggoexpr.EvalString(c.Key, t.EvalContext, "key")
+	diags = append(diags, d...)
+	ttl, _, d := bggoexpr.EvalDuration(c.TTL, t.EvalContext, "ttl")
+	diags = append(diags, d...)
+	restore, hasRestore, d := bggoexpr.EvalBool(c.Restore, t.EvalContext, "restore")
+	diags = append(diags, d...)
+
+	slot := &cacheSlot{scope: cache.ScopeGlobal, ttl: ttl, restore: restore || !hasRestore}
+	scope, hasScope, d := bggoexpr.EvalString(c.Scope, t.EvalContext, "scope")
+	diags = append(diags, d...)
+	if hasScope {
+		slot.scope = scope
+	}
+	store, ok := e.caches[slot.scope]
+	if !ok && !diags.HasErrors() {
+		diags = diags.Append(&hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Invalid scope value",
+			Detail:   fmt.Sprintf("Inappropriate value: must be %q or %q, got %q.", cache.ScopeRun, cache.ScopeGlobal, scope),
+			Subject:  c.Scope.Range().Ptr(),
+		})
+	}
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("invalid cache: %w", diags)
+	}
+	if hasEnabled && !enabled {
+		return nil, nil
+	}
+
+	key, err := cache.Key(userKey, t.Node.Type, t.ResolvedInputs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute cache key: %w", err)
+	}
+	slot.store = store
+	slot.key = key
+	return slot, nil
+}
+
+// get returns the cached output, if any. Backend errors count as a miss.
+func (s *cacheSlot) get(ctx context.Context) (cty.Value, bool) {
+	entry, ok, err := s.store.Get(ctx, s.key)
+	if err != nil {
+		ctxlog.FromContext(ctx).Warn("Failed to read cache entry.", "scope", s.scope, "key", s.key, "error", err)
+		return cty.NilVal, false
+	}
+	return entry.Output, ok
+}
+
+// put caches output for the slot's TTL. Backend errors are logged only.
+func (s *cacheSlot) put(ctx context.Context, output cty.Value) {
+	entry := cache.Entry{Output: output}
+	if s.ttl > 0 {
+		entry.ExpiresAt = time.Now().Add(s.ttl)
+	}
+	if err := s.store.Put(ctx, s.key, entry); err != nil {
+		ctxlog.FromContext(ctx).Warn("Failed to write cache entry.", "scope", s.scope, "key", s.key, "error", err)
+	}
+}
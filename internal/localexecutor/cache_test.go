@@ -0,0 +1,125 @@
+package localexecutor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/specialistvlad/burstgridgo/internal/builder"
+	"github.com/specialistvlad/burstgridgo/internal/cache"
+	"github.com/specialistvlad/burstgridgo/internal/node"
+	"github.com/specialistvlad/burstgridgo/internal/scheduler"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache(t *testing.T) {
+	testCases := []struct {
+		name      string
+		block     string
+		value     []string // consumer argument of each successive run
+		pause     time.Duration
+		wantCalls int32
+	}{
+		{
+			name:      "hit skips the run",
+			block:     `key = "v1"`,
+			value:     []string{"a", "a"},
+			wantCalls: 1,
+		},
+		{
+			name:      "inputs are part of the key",
+			value:     []string{"a", "b", "a"},
+			wantCalls: 2,
+		},
+		{
+			name:      "entries expire after ttl",
+			block:     `ttl = "30ms"`,
+			value:     []string{"a", "a"},
+			pause:     60 * time.Millisecond,
+			wantCalls: 2,
+		},
+		{
+			name:      "restore false refreshes the entry",
+			block:     `restore = false`,
+			value:     []string{"a", "a"},
+			wantCalls: 2,
+		},
+		{
+			name:      "disabled",
+			block:     `enabled = false`,
+			value:     []string{"a", "a"},
+			wantCalls: 2,
+		},
+		{
+			name:      "run scope does not outlive the run",
+			block:     `scope = "run"`,
+			value:     []string{"a", "a"},
+			wantCalls: 2,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var c consumed
+			reg := onErrorRegistry(t, &c)
+			global := cache.NewMemory()
+			for i, value := range tc.value {
+				if i > 0 {
+					time.Sleep(tc.pause)
+				}
+				g := compileGraph(t, `
+					step "consumer" "cached" {
+						count = 1
+						arguments {
+							value = "`+value+`"
+						}
+						cache {
+							`+tc.block+`
+						}
+					}
+				`)
+				e := New(scheduler.New(g), g, builder.New(reg), reg, 1, WithCache(cache.ScopeGlobal, global))
+				require.NoError(t, e.Execute(testContext()))
+				assert.Equal(t, node.StatusCompleted, status(t, g, "step.consumer.cached[0]"))
+			}
+			assert.Equal(t, tc.wantCalls, c.calls.Load())
+		})
+	}
+}
+
+func TestCache_DiskPersistsAcrossRuns(t *testing.T) {
+	var c consumed
+	reg := onErrorRegistry(t, &c)
+	dir := t.TempDir()
+	for range 2 {
+		g := compileGraph(t, `
+			step "consumer" "cached" {
+				arguments {
+					value = "a"
+				}
+				cache {
+					key = "disk"
+				}
+			}
+		`)
+		e := New(scheduler.New(g), g, builder.New(reg), reg, 1, WithCache(cache.ScopeGlobal, cache.NewDisk(dir)))
+		require.NoError(t, e.Execute(testContext()))
+	}
+	assert.Equal(t, int32(1), c.calls.Load())
+}
+
+func TestCache_InvalidScope(t *testing.T) {
+	var c consumed
+	reg := onErrorRegistry(t, &c)
+	g := compileGraph(t, `
+		step "consumer" "bad" {
+			cache {
+				scope = "cluster"
+			}
+		}
+	`)
+	err := New(scheduler.New(g), g, builder.New(reg), reg, 1).Execute(testContext())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Invalid scope value")
+	assert.Equal(t, node.StatusFailed, status(t, g, "step.consumer.bad"))
+	assert.Zero(t, c.calls.Load())
+}
@@ -0,0 +1,212 @@
+package localexecutor
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/specialistvlad/burstgridgo/internal/bggoexpr"
+	"github.com/specialistvlad/burstgridgo/internal/model"
+	"github.com/specialistvlad/burstgridgo/internal/node"
+)
+
+// Queue orders accepted by a `concurrency` block's `order` attribute.
+const (
+	orderFIFO     = "fifo"
+	orderLIFO     = "lifo"
+	orderPriority = "priority"
+)
+
+// gate holds back nodes whose step is already running as many instances as
+// its `concurrency` block allows. Nodes are grouped into buckets: one per
+// step, or one per step and evaluated `per_key` value.
+type gate struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	held    map[string]*bucket // node ID -> bucket it holds a slot in
+	parked  int
+	freed   []*node.Node // parked nodes that were given a slot
+	wake    chan struct{}
+}
+
+// bucket tracks the running and waiting nodes that share one limit.
+type bucket struct {
+	limit   int
+	order   string
+	running int
+	waiting []waiter
+	seq     int
+}
+
+type waiter struct {
+	n        *node.Node
+	priority int
+	seq      int
+}
+
+func newGate() *gate {
+	return &gate{
+		buckets: make(map[string]*bucket),
+		held:    make(map[string]*bucket),
+		wake:    make(chan struct{}, 1),
+	}
+}
+
+// admission is the evaluated `concurrency` block of a node.
+type admission struct {
+	bucket   string
+	limit    int
+	order    string
+	priority int
+}
+
+// admit reports whether n may run now. When its bucket is full, n is parked
+// and handed back through take once a slot frees up.
+func (g *gate) admit(n *node.Node, a admission) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	b := g.buckets[a.bucket]
+	if b == nil {
+		b = &bucket{limit: a.limit, order: a.order}
+		g.buckets[a.bucket] = b
+	}
+	if b.running < b.limit {
+		b.running++
+		g.held[n.ID.String()] = b
+		return true
+	}
+	b.seq++
+	b.waiting = append(b.waiting, waiter{n: n, priority: a.priority, seq: b.seq})
+	g.parked++
+	return false
+}
+
+// release frees the slot held by n, if any, and passes it to the next
+// waiting node of the same bucket.
+func (g *gate) release(n *node.Node) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	key := n.ID.String()
+	b := g.held[key]
+	if b == nil {
+		return
+	}
+	delete(g.held, key)
+	b.running--
+	if len(b.waiting) == 0 {
+		return
+	}
+	next := b.next()
+	b.running++
+	g.held[next.ID.String()] = b
+	g.parked--
+	g.freed = append(g.freed, next)
+	select {
+	case g.wake <- struct{}{}:
+	default:
+	}
+}
+
+// next removes and returns the waiting node that should run first.
+func (b *bucket) next() *node.Node {
+	pick := 0
+	for i, w := range b.waiting {
+		best := b.waiting[pick]
+		switch b.order {
+		case orderLIFO:
+			if w.seq > best.seq {
+				pick = i
+			}
+		case orderPriority:
+			if w.priority > best.priority || (w.priority == best.priority && w.seq < best.seq) {
+				pick = i
+			}
+		default:
+			if w.seq < best.seq {
+				pick = i
+			}
+		}
+	}
+	n := b.waiting[pick].n
+	b.waiting = append(b.waiting[:pick], b.waiting[pick+1:]...)
+	return n
+}
+
+// take returns the parked nodes that have been given a slot since the last call.
+func (g *gate) take() []*node.Node {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	freed := g.freed
+	g.freed = nil
+	return freed
+}
+
+// idle reports whether no node is parked or waiting to be taken.
+func (g *gate) idle() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.parked == 0 && len(g.freed) == 0
+}
+
+// evalConcurrency evaluates the `concurrency` block of a step. It reports
+// limited=false for steps without a limit. `per_key` without `limit` allows
+// one running node per key.
+func evalConcurrency(name string, step *model.Step, evalCtx *hcl.EvalContext) (admission, bool, error) {
+	c := step.Concurrency
+	if c == nil {
+		return admission{}, false, nil
+	}
+
+	var diags, d hcl.Diagnostics
+	a := admission{bucket: name, order: orderFIFO}
+
+	limit, hasLimit, d := bggoexpr.EvalInt(c.Limit, evalCtx, "limit")
+	diags = append(diags, d...)
+	if hasLimit && limit < 1 {
+		diags = diags.Append(&hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Invalid limit value",
+			Detail:   fmt.Sprintf("Inappropriate value: must be at least 1, got %d.", limit),
+			Subject:  c.Limit.Range().Ptr(),
+		})
+	}
+
+	key, hasKey, d := bggoexpr.EvalString(c.PerKey, evalCtx, "per_key")
+	diags = append(diags, d...)
+	if hasKey {
+		a.bucket += "\x00" + key
+	}
+
+	order, hasOrder, d := bggoexpr.EvalString(c.Order, evalCtx, "order")
+	diags = append(diags, d...)
+	if hasOrder {
+		switch order {
+		case orderFIFO, orderLIFO, orderPriority:
+			a.order = order
+		default:
+			diags = diags.Append(&hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid order value",
+				Detail:   fmt.Sprintf("Inappropriate value: must be %q, %q or %q, got %q.", orderFIFO, orderLIFO, orderPriority, order),
+				Subject:  c.Order.Range().Ptr(),
+			})
+		}
+	}
+	if a.order == orderPriority {
+		a.priority, d = evalPriority(step, evalCtx)
+		diags = append(diags, d...)
+	}
+
+	if diags.HasErrors() {
+		return admission{}, false, fmt.Errorf("invalid concurrency: %w", diags)
+	}
+	switch {
+	case hasLimit:
+		a.limit = limit
+	case hasKey:
+		a.limit = 1
+	default:
+		return admission{}, false, nil
+	}
+	return a, true, nil
+}
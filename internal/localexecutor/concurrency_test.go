@@ -0,0 +1,186 @@
+package localexecutor
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/specialistvlad/burstgridgo/internal/builder"
+	"github.com/specialistvlad/burstgridgo/internal/handlers"
+	"github.com/specialistvlad/burstgridgo/internal/registry"
+	"github.com/specialistvlad/burstgridgo/internal/scheduler"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const limitedManifest = `
+runner "limited" {
+  input "key" {
+    type    = string
+    default = ""
+  }
+  input "index" {
+    type    = number
+    default = 0
+  }
+  lifecycle {
+    on_run = "OnRunLimited"
+  }
+}
+`
+
+// tracker records how many handler calls run at once, overall and per key,
+// and the order in which they started.
+type tracker struct {
+	mu      sync.Mutex
+	running map[string]int
+	total   int
+	peak    int
+	peakKey map[string]int
+	started []int
+}
+
+func newTracker() *tracker {
+	return &tracker{running: make(map[string]int), peakKey: make(map[string]int)}
+}
+
+func (tr *tracker) enter(key string, index int) {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	tr.total++
+	tr.running[key]++
+	tr.peak = max(tr.peak, tr.total)
+	tr.peakKey[key] = max(tr.peakKey[key], tr.running[key])
+	tr.started = append(tr.started, index)
+}
+
+func (tr *tracker) leave(key string) {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	tr.total--
+	tr.running[key]--
+}
+
+// limitedRegistry registers a handler that sleeps briefly while tracked.
+func limitedRegistry(t *testing.T, tr *tracker) *registry.Registry {
+	type limitedIn struct {
+		Key   string `bggo:"key"`
+		Index int    `bggo:"index"`
+	}
+	return newRegistry(t, limitedManifest, map[string]*handlers.RegisteredHandler{
+		"OnRunLimited": {
+			Input: func() any { return new(limitedIn) },
+			Fn: func(ctx context.Context, deps any, input *limitedIn) (any, error) {
+				tr.enter(input.Key, input.Index)
+				defer tr.leave(input.Key)
+				time.Sleep(20 * time.Millisecond)
+				return nil, nil
+			},
+		},
+	})
+}
+
+func runLimited(t *testing.T, src string, workers int) *tracker {
+	t.Helper()
+	tr := newTracker()
+	reg := limitedRegistry(t, tr)
+	g := compileGraph(t, src)
+	require.NoError(t, New(scheduler.New(g), g, builder.New(reg), reg, workers).Execute(testContext()))
+	return tr
+}
+
+func TestConcurrency_Limit(t *testing.T) {
+	tr := runLimited(t, `
+		step "limited" "work" {
+			count = 6
+			arguments {
+				index = count.index
+			}
+			concurrency {
+				limit = 2
+			}
+		}
+		step "limited" "other" {
+			arguments {
+				key = "other"
+			}
+		}
+	`, 8)
+	assert.Len(t, tr.started, 7)
+	assert.Equal(t, 2, tr.peakKey[""], "at most two instances of the step may run at once")
+	assert.Equal(t, 1, tr.peakKey["other"])
+}
+
+func TestConcurrency_PerKey(t *testing.T) {
+	tr := runLimited(t, `
+		step "limited" "work" {
+			for_each = {
+				a1 = "a"
+				a2 = "a"
+				a3 = "a"
+				b1 = "b"
+				b2 = "b"
+			}
+			arguments {
+				key = each.value
+			}
+			concurrency {
+				per_key = each.value
+			}
+		}
+	`, 8)
+	assert.Len(t, tr.started, 5)
+	assert.Equal(t, 1, tr.peakKey["a"])
+	assert.Equal(t, 1, tr.peakKey["b"])
+	assert.Equal(t, 2, tr.peak, "different keys run side by side")
+}
+
+func TestConcurrency_Order(t *testing.T) {
+	testCases := []struct {
+		name  string
+		order string
+		want  []int
+	}{
+		{name: "fifo", order: "fifo", want: []int{0, 1, 2, 3}},
+		{name: "lifo", order: "lifo", want: []int{0, 3, 2, 1}},
+		{name: "priority", order: "priority", want: []int{0, 2, 1, 3}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Instance 0 takes the only slot; the others are parked while it runs
+			// and are released in the requested order.
+			tr := runLimited(t, `
+				step "limited" "work" {
+					count    = 4
+					priority = count.index == 2 ? 10 : 0
+					arguments {
+						index = count.index
+					}
+					concurrency {
+						limit = 1
+						order = "`+tc.order+`"
+					}
+				}
+			`, 4)
+			assert.Equal(t, tc.want, tr.started)
+		})
+	}
+}
+
+func TestConcurrency_InvalidValue(t *testing.T) {
+	tr := newTracker()
+	reg := limitedRegistry(t, tr)
+	g := compileGraph(t, `
+		step "limited" "bad" {
+			concurrency {
+				limit = 1
+				order = "random"
+			}
+		}
+	`)
+	err := New(scheduler.New(g), g, builder.New(reg), reg, 1).Execute(testContext())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Invalid order value")
+	assert.Empty(t, tr.started)
+}
@@ -0,0 +1,229 @@
+package localexecutor
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/specialistvlad/burstgridgo/internal/bggoexpr"
+	"github.com/specialistvlad/burstgridgo/internal/cache"
+	"github.com/specialistvlad/burstgridgo/internal/ctxlog"
+	"github.com/specialistvlad/burstgridgo/internal/node"
+	"github.com/specialistvlad/burstgridgo/internal/task"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// Actions accepted by a `dedupe` block's `action` attribute.
+const (
+	dedupeShare = "share"
+	dedupeSkip  = "skip"
+)
+
+// DedupeTable records the dedupe keys claimed within a scope and the outcome
+// of the node that claimed each one. A key whose node failed is released once
+// the failure is handed to its waiters, so a later node claims it afresh. It
+// lives in memory and is safe for concurrent use.
+type DedupeTable struct {
+	mu      sync.Mutex
+	flights map[string]*flight
+}
+
+// NewDedupeTable creates an empty DedupeTable.
+func NewDedupeTable() *DedupeTable {
+	return &DedupeTable{flights: make(map[string]*flight)}
+}
+
+// globalDedupe is the table of the "global" scope, shared by every executor
+// in the process unless replaced with WithDedupe. It is not persisted: a
+// "global" key lasts only as long as the process.
+var globalDedupe = NewDedupeTable()
+
+// flight is the work of the first node to claim a dedupe key.
+type flight struct {
+	key     string
+	leader  string
+	landed  bool
+	output  cty.Value
+	err     error
+	waiters []func(cty.Value, error)
+}
+
+// claim returns the flight of key, starting one led by leader if the key is
+// unclaimed. It reports whether the caller leads the flight.
+func (d *DedupeTable) claim(key, leader string) (*flight, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if f, ok := d.flights[key]; ok {
+		return f, false
+	}
+	f := &flight{key: key, leader: leader}
+	d.flights[key] = f
+	return f, true
+}
+
+// wait calls fn with the outcome of f once it lands, or right away if it
+// already has.
+func (d *DedupeTable) wait(f *flight, fn func(cty.Value, error)) {
+	d.mu.Lock()
+	if !f.landed {
+		f.waiters = append(f.waiters, fn)
+		d.mu.Unlock()
+		return
+	}
+	d.mu.Unlock()
+	fn(f.output, f.err)
+}
+
+// land records the outcome of f and hands it to its waiters. A failed flight
+// is evicted, so that the next node with its key runs instead of inheriting
+// the failure.
+func (d *DedupeTable) land(f *flight, output cty.Value, err error) {
+	d.mu.Lock()
+	f.landed, f.output, f.err = true, output, err
+	if err != nil && d.flights[f.key] == f {
+		delete(d.flights, f.key)
+	}
+	waiters := f.waiters
+	f.waiters = nil
+	d.mu.Unlock()
+	for _, fn := range waiters {
+		fn(output, err)
+	}
+}
+
+// leadership is a flight led by a node of this executor.
+type leadership struct {
+	table  *DedupeTable
+	flight *flight
+}
+
+// dedupeSpec is the evaluated `dedupe` block of a task.
+type dedupeSpec struct {
+	key    string
+	action string
+	scope  string
+	table  *DedupeTable
+}
+
+// evalDedupe evaluates the `dedupe` block of a built task, or returns nil
+// when it has none. The key defaults to the runner type and input hash, so
+// that identical instances collide; the action defaults to "share" and the
+// scope to "run".
+func (e *Executor) evalDedupe(t *task.Task) (*dedupeSpec, error) {
+	step := t.Node.Config
+	if step == nil || step.Dedupe == nil {
+		return nil, nil
+	}
+	dd := step.Dedupe
+	spec := &dedupeSpec{action: dedupeShare, scope: cache.ScopeRun}
+	var diags hcl.Diagnostics
+
+	userKey, hasKey, d := bggoexpr.EvalString(dd.Key, t.EvalContext, "key")
+	diags = append(diags, d...)
+	action, hasAction, d := bggoexpr.EvalString(dd.Action, t.EvalContext, "action")
+	diags = append(diags, d...)
+	if hasAction {
+		spec.action = action
+		if action != dedupeShare && action != dedupeSkip {
+			diags = diags.Append(&hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid action value",
+				Detail:   fmt.Sprintf("Inappropriate value: must be %q or %q, got %q.", dedupeShare, dedupeSkip, action),
+				Subject:  dd.Action.Range().Ptr(),
+			})
+		}
+	}
+	scope, hasScope, d := bggoexpr.EvalString(dd.Scope, t.EvalContext, "scope")
+	diags = append(diags, d...)
+	if hasScope {
+		spec.scope = scope
+	}
+	spec.table = e.dedupes[spec.scope]
+	if spec.table == nil && !diags.HasErrors() {
+		diags = diags.Append(&hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Invalid scope value",
+			Detail:   fmt.Sprintf("Inappropriate value: must be %q or %q, got %q.", cache.ScopeRun, cache.ScopeGlobal, scope),
+			Subject:  dd.Scope.Range().Ptr(),
+		})
+	}
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("invalid dedupe: %w", diags)
+	}
+
+	if hasKey {
+		spec.key = userKey
+		return spec, nil
+	}
+	key, err := cache.Key("", t.Node.Type, t.ResolvedInputs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute dedupe key: %w", err)
+	}
+	spec.key = key
+	return spec, nil
+}
+
+// dedupe claims the dedupe key of a built task. It reports false when the
+// node should run: it has no `dedupe` block or it is the first to claim its
+// key. Otherwise the node has been handled: it is skipped, or it completes
+// (or fails) with the outcome of the first node once that lands, without
+// holding a worker in the meantime.
+func (e *Executor) dedupe(ctx context.Context, t *task.Task) (bool, error) {
+	spec, err := e.evalDedupe(t)
+	if err != nil || spec == nil {
+		return false, err
+	}
+	n := t.Node
+	f, lead := spec.table.claim(spec.key, n.ID.String())
+	if lead {
+		e.mu.Lock()
+		e.leading[n.ID.String()] = leadership{table: spec.table, flight: f}
+		e.mu.Unlock()
+		return false, nil
+	}
+
+	logger := ctxlog.FromContext(ctx).With("node", n.ID.String(), "leader", f.leader, "scope", spec.scope)
+	if spec.action == dedupeSkip {
+		if err := e.graph.MarkSkipped(ctx, n.ID); err != nil {
+			return false, fmt.Errorf("failed to mark node skipped: %w", err)
+		}
+		logger.Info("Node skipped: duplicate of another node.")
+		e.skipDependents(ctx, n.ID)
+		return true, nil
+	}
+
+	logger.Info("Node deduplicated, sharing the result of another node.")
+	spec.table.wait(f, func(output cty.Value, err error) {
+		defer e.scheduler.Notify()
+		if err != nil {
+			e.fail(ctx, n, fmt.Errorf("deduplicated with %s: %w", f.leader, err))
+			return
+		}
+		if err := e.complete(ctx, n, output); err != nil {
+			e.fail(ctx, n, fmt.Errorf("failed to mark node completed: %w", err))
+		}
+	})
+	return true, nil
+}
+
+// complete marks n Completed with output and shares the output with the
+// nodes waiting on its dedupe key.
+func (e *Executor) complete(ctx context.Context, n *node.Node, output cty.Value) error {
+	if err := e.graph.MarkCompleted(ctx, n.ID, output); err != nil {
+		return err
+	}
+	e.land(n, output, nil)
+	return nil
+}
+
+// land ends the dedupe flight led by n, if any.
+func (e *Executor) land(n *node.Node, output cty.Value, err error) {
+	e.mu.Lock()
+	l, ok := e.leading[n.ID.String()]
+	delete(e.leading, n.ID.String())
+	e.mu.Unlock()
+	if ok {
+		l.table.land(l.flight, output, err)
+	}
+}
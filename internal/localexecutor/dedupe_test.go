@@ -0,0 +1,189 @@
+package localexecutor
+
+import (
+	"testing"
+
+	"github.com/specialistvlad/burstgridgo/internal/builder"
+	"github.com/specialistvlad/burstgridgo/internal/cache"
+	"github.com/specialistvlad/burstgridgo/internal/graph"
+	"github.com/specialistvlad/burstgridgo/internal/node"
+	"github.com/specialistvlad/burstgridgo/internal/scheduler"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDedupe(t *testing.T) {
+	testCases := []struct {
+		name        string
+		arguments   string
+		block       string
+		wantCalls   int32 // calls of step.consumer.items
+		wantSkipped int
+		wantAfter   node.Status
+	}{
+		{
+			name:      "identical instances share the first result",
+			arguments: `value = "same"`,
+			wantCalls: 1,
+			wantAfter: node.StatusCompleted,
+		},
+		{
+			name:      "different inputs do not collide",
+			arguments: `value = "item${count.index}"`,
+			wantCalls: 3,
+			wantAfter: node.StatusCompleted,
+		},
+		{
+			name:      "explicit key collides across inputs",
+			arguments: `value = "item${count.index}"`,
+			block:     `key = "shared"`,
+			wantCalls: 1,
+			wantAfter: node.StatusCompleted,
+		},
+		{
+			name:        "skip action",
+			arguments:   `value = "same"`,
+			block:       `action = "skip"`,
+			wantCalls:   1,
+			wantSkipped: 2,
+			wantAfter:   node.StatusSkipped,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var c consumed
+			reg := onErrorRegistry(t, &c)
+			g := compileGraph(t, `
+				step "consumer" "items" {
+					count = 3
+					arguments {
+						ms = 20
+						`+tc.arguments+`
+					}
+					dedupe {
+						`+tc.block+`
+					}
+				}
+				step "consumer" "after" {
+					depends_on = [step.consumer.items]
+				}
+			`)
+			require.NoError(t, New(scheduler.New(g), g, builder.New(reg), reg, 3).Execute(testContext()))
+
+			skipped := 0
+			for _, id := range []string{"step.consumer.items[0]", "step.consumer.items[1]", "step.consumer.items[2]"} {
+				if status(t, g, id) == node.StatusSkipped {
+					skipped++
+				} else {
+					assert.Equal(t, node.StatusCompleted, status(t, g, id), id)
+				}
+			}
+			assert.Equal(t, tc.wantSkipped, skipped)
+			assert.Equal(t, tc.wantAfter, status(t, g, "step.consumer.after"))
+			ran := c.calls.Load()
+			if tc.wantAfter == node.StatusCompleted {
+				ran--
+			}
+			assert.Equal(t, tc.wantCalls, ran)
+		})
+	}
+}
+
+func TestDedupe_SharesFailure(t *testing.T) {
+	var c consumed
+	reg := onErrorRegistry(t, &c)
+	g := compileGraph(t, `
+		step "broken" "api" {
+			count = 2
+			dedupe {
+				key = "api"
+			}
+		}
+	`)
+	err := New(scheduler.New(g), g, builder.New(reg), reg, 2).Execute(testContext())
+	var runErr *RunError
+	require.ErrorAs(t, err, &runErr)
+	assert.Len(t, runErr.Failures, 2)
+	assert.ErrorIs(t, err, errBroken)
+	assert.Equal(t, node.StatusFailed, status(t, g, "step.broken.api[0]"))
+	assert.Equal(t, node.StatusFailed, status(t, g, "step.broken.api[1]"))
+}
+
+func TestDedupe_Scope(t *testing.T) {
+	testCases := []struct {
+		scope     string
+		wantCalls int32
+	}{
+		{scope: "run", wantCalls: 2},
+		{scope: "global", wantCalls: 1},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.scope, func(t *testing.T) {
+			var c consumed
+			reg := onErrorRegistry(t, &c)
+			global := NewDedupeTable()
+			for range 2 {
+				g := compileGraph(t, `
+					step "consumer" "once" {
+						dedupe {
+							scope = "`+tc.scope+`"
+						}
+					}
+				`)
+				e := New(scheduler.New(g), g, builder.New(reg), reg, 1, WithDedupe(cache.ScopeGlobal, global))
+				require.NoError(t, e.Execute(testContext()))
+				assert.Equal(t, node.StatusCompleted, status(t, g, "step.consumer.once"))
+			}
+			assert.Equal(t, tc.wantCalls, c.calls.Load())
+		})
+	}
+}
+
+func TestDedupe_FailureReleasesKey(t *testing.T) {
+	var c consumed
+	reg := onErrorRegistry(t, &c)
+	global := NewDedupeTable()
+	run := func(src string) (error, graph.Graph) {
+		g := compileGraph(t, src)
+		return New(scheduler.New(g), g, builder.New(reg), reg, 1, WithDedupe(cache.ScopeGlobal, global)).Execute(testContext()), g
+	}
+
+	err, _ := run(`
+		step "broken" "api" {
+			dedupe {
+				key   = "api"
+				scope = "global"
+			}
+		}
+	`)
+	require.ErrorIs(t, err, errBroken)
+
+	// The failed flight was evicted, so the next claim of the key runs.
+	err, g := run(`
+		step "consumer" "api" {
+			dedupe {
+				key   = "api"
+				scope = "global"
+			}
+		}
+	`)
+	require.NoError(t, err)
+	assert.Equal(t, node.StatusCompleted, status(t, g, "step.consumer.api"))
+	assert.Equal(t, int32(1), c.calls.Load())
+}
+
+func TestDedupe_InvalidAction(t *testing.T) {
+	var c consumed
+	reg := onErrorRegistry(t, &c)
+	g := compileGraph(t, `
+		step "consumer" "bad" {
+			dedupe {
+				action = "merge"
+			}
+		}
+	`)
+	err := New(scheduler.New(g), g, builder.New(reg), reg, 1).Execute(testContext())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Invalid action value")
+	assert.Zero(t, c.calls.Load())
+}
@@ -0,0 +1,56 @@
+package localexecutor
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/specialistvlad/burstgridgo/internal/bggoexpr"
+	"github.com/specialistvlad/burstgridgo/internal/ctxlog"
+	"github.com/specialistvlad/burstgridgo/internal/node"
+	"github.com/specialistvlad/burstgridgo/internal/task"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// evalDelay evaluates a `delay_before` or `delay_after` attribute. An unset
+// or null attribute means no delay.
+func evalDelay(expr hcl.Expression, evalCtx *hcl.EvalContext, name string) (time.Duration, error) {
+	d, _, diags := bggoexpr.EvalDuration(expr, evalCtx, name)
+	if diags.HasErrors() {
+		return 0, fmt.Errorf("invalid %s: %w", name, diags)
+	}
+	return d, nil
+}
+
+// delays evaluates the `delay_before` and `delay_after` of a built task.
+func delays(t *task.Task) (before, after time.Duration, err error) {
+	step := t.Node.Config
+	if step == nil {
+		return 0, 0, nil
+	}
+	if before, err = evalDelay(step.DelayBefore, t.EvalContext, "delay_before"); err != nil {
+		return 0, 0, err
+	}
+	if after, err = evalDelay(step.DelayAfter, t.EvalContext, "delay_after"); err != nil {
+		return 0, 0, err
+	}
+	return before, after, nil
+}
+
+// completeAfter marks n Completed once its `delay_after` has elapsed. The
+// worker is released in the meantime; the node stays Running, so its
+// dependents wait. If ctx ends first the node is completed right away.
+func (e *Executor) completeAfter(ctx context.Context, n *node.Node, output cty.Value, delay time.Duration) {
+	e.delayed.Add(1)
+	go func() {
+		defer e.delayed.Done()
+		defer e.scheduler.Notify()
+		sleep(ctx, delay)
+		if err := e.complete(ctx, n, output); err != nil {
+			e.fail(ctx, n, fmt.Errorf("failed to mark node completed: %w", err))
+			return
+		}
+		ctxlog.FromContext(ctx).Debug("Node completed.", "node", n.ID.String(), "delay_after", delay)
+	}()
+}
@@ -0,0 +1,162 @@
+package localexecutor
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/specialistvlad/burstgridgo/internal/ctxlog"
+	"github.com/specialistvlad/burstgridgo/internal/node"
+)
+
+// dispatch forwards ready nodes from the scheduler to the workers. Nodes wait
+// out their `delay_before`, are held back while their step is at its
+// concurrency limit, and are paced by their `rate_limit`, all without taking
+// a worker. A new node is only taken from the scheduler once the previous one
+// has been handed to a worker, so that the backlog stays in the scheduler's
+// priority-ordered queue. dispatch closes work once the scheduler is done and
+// nothing is held back.
+func (e *Executor) dispatch(ctx context.Context, ready <-chan *node.Node, work chan<- *node.Node) {
+	defer close(work)
+	var outbox []*node.Node
+	timers := make(chan *node.Node)
+	waiting := 0
+
+	// after hands n back to enqueue once d has elapsed, unless ctx ends first.
+	after := func(d time.Duration, n *node.Node) {
+		waiting++
+		time.AfterFunc(d, func() {
+			select {
+			case timers <- n:
+			case <-ctx.Done():
+			}
+		})
+	}
+	enqueue := func(nodes ...*node.Node) {
+		for _, n := range nodes {
+			// A node that already timed out goes straight to a worker,
+			// which drops it and frees its `concurrency` slot.
+			if e.watchdog.expired(n) {
+				outbox = append(outbox, n)
+				continue
+			}
+			if d := e.takeDelay(n); d > 0 {
+				after(d, n)
+				continue
+			}
+			if d := e.limiter.reserve(n); d > 0 {
+				after(d, n)
+				continue
+			}
+			outbox = append(outbox, n)
+		}
+	}
+
+	for ready != nil || len(outbox) > 0 || waiting > 0 || !e.gate.idle() {
+		var send chan<- *node.Node
+		var next *node.Node
+		recv := ready
+		if len(outbox) > 0 {
+			send = work
+			next = outbox[0]
+			recv = nil
+		}
+		select {
+		case n, ok := <-recv:
+			if !ok {
+				ready = nil
+				continue
+			}
+			if e.admit(ctx, n) {
+				enqueue(n)
+			}
+		case send <- next:
+			outbox = outbox[1:]
+		case <-e.gate.wake:
+			enqueue(e.gate.take()...)
+		case n := <-timers:
+			waiting--
+			enqueue(n)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// admit decides what happens to a node handed over by the scheduler. A node
+// whose `enabled` is false is skipped. Otherwise its `delay_before`,
+// `rate_limit`, `concurrency` and `queue`/`start` timeouts are evaluated, its
+// timeout timer is armed and the gate is asked for a slot. A node whose
+// attributes cannot be evaluated is failed instead.
+func (e *Executor) admit(ctx context.Context, n *node.Node) bool {
+	step := n.Config
+	if step == nil || n.Placeholder {
+		return true
+	}
+	if step.Enabled == nil && step.DelayBefore == nil && step.RateLimit == nil && step.Concurrency == nil && step.Timeouts == nil {
+		return true
+	}
+	evalCtx, err := e.builder.EvalContext(ctx, n, e.graph)
+	if err != nil {
+		return e.reject(ctx, n, fmt.Errorf("failed to build evaluation context: %w", err))
+	}
+
+	if step.Enabled != nil {
+		enabled, err := evalEnabled(step, evalCtx)
+		if err != nil {
+			return e.reject(ctx, n, err)
+		}
+		if !enabled {
+			e.skip(ctx, n)
+			e.scheduler.Notify()
+			return false
+		}
+	}
+
+	before, err := evalDelay(step.DelayBefore, evalCtx, "delay_before")
+	if err != nil {
+		return e.reject(ctx, n, err)
+	}
+	if before > 0 {
+		e.delays[n.ID.String()] = before
+	}
+
+	name := "step." + step.RunnerType + "." + step.Name
+	if step.RateLimit != nil {
+		spec, err := evalRateSpec(name, step.RateLimit, evalCtx)
+		if err != nil {
+			return e.reject(ctx, n, err)
+		}
+		e.limiter.plan(n, spec)
+	}
+
+	a, limited, err := evalConcurrency(name, step, evalCtx)
+	if err != nil {
+		return e.reject(ctx, n, err)
+	}
+	kind, limit, err := waitLimit(step.Timeouts, evalCtx)
+	if err != nil {
+		return e.reject(ctx, n, err)
+	}
+	e.watch(ctx, n, before, kind, limit)
+	if limited && !e.gate.admit(n, a) {
+		ctxlog.FromContext(ctx).Debug("Node held back by concurrency limit.", "node", n.ID.String(), "bucket", a.bucket, "limit", a.limit)
+		return false
+	}
+	return true
+}
+
+// reject fails a node that never reached a worker.
+func (e *Executor) reject(ctx context.Context, n *node.Node, err error) bool {
+	e.fail(ctx, n, err)
+	e.scheduler.Notify()
+	return false
+}
+
+// takeDelay returns the pending `delay_before` of n, once.
+func (e *Executor) takeDelay(n *node.Node) time.Duration {
+	id := n.ID.String()
+	d := e.delays[id]
+	delete(e.delays, id)
+	return d
+}
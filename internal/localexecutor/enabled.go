@@ -0,0 +1,35 @@
+package localexecutor
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/specialistvlad/burstgridgo/internal/bggoexpr"
+	"github.com/specialistvlad/burstgridgo/internal/ctxlog"
+	"github.com/specialistvlad/burstgridgo/internal/model"
+	"github.com/specialistvlad/burstgridgo/internal/node"
+)
+
+// evalEnabled evaluates a step's `enabled` attribute. It is evaluated once
+// the node's dependencies are done, so it may reference their outputs. A null
+// value means enabled.
+func evalEnabled(step *model.Step, evalCtx *hcl.EvalContext) (bool, error) {
+	enabled, ok, diags := bggoexpr.EvalBool(*step.Enabled, evalCtx, "enabled")
+	if diags.HasErrors() {
+		return false, fmt.Errorf("invalid enabled: %w", diags)
+	}
+	return enabled || !ok, nil
+}
+
+// skip marks a disabled node Skipped, along with the dependents that do not
+// continue on failure.
+func (e *Executor) skip(ctx context.Context, n *node.Node) {
+	logger := ctxlog.FromContext(ctx)
+	if err := e.graph.MarkSkipped(ctx, n.ID); err != nil {
+		logger.Warn("Failed to skip node.", "node", n.ID.String(), "error", err)
+		return
+	}
+	logger.Info("Node skipped: not enabled.", "node", n.ID.String())
+	e.skipDependents(ctx, n.ID)
+}
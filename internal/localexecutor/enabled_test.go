@@ -0,0 +1,180 @@
+package localexecutor
+
+import (
+	"context"
+	"testing"
+
+	"github.com/specialistvlad/burstgridgo/internal/builder"
+	"github.com/specialistvlad/burstgridgo/internal/handlers"
+	"github.com/specialistvlad/burstgridgo/internal/node"
+	"github.com/specialistvlad/burstgridgo/internal/registry"
+	"github.com/specialistvlad/burstgridgo/internal/scheduler"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const flagManifest = `
+runner "flag" {
+  input "on" {
+    type = bool
+  }
+  output "on" {
+    type = bool
+  }
+  lifecycle {
+    on_run = "OnRunFlag"
+  }
+}
+`
+
+// enabledRegistry combines the consumer runner with a flag runner that
+// echoes its `on` input as an output.
+func enabledRegistry(t *testing.T, c *consumed) *registry.Registry {
+	type flag struct {
+		On bool `bggo:"on" cty:"on"`
+	}
+	hs := onErrorHandlers(c)
+	hs["OnRunFlag"] = &handlers.RegisteredHandler{
+		Input: func() any { return new(flag) },
+		Fn: func(ctx context.Context, deps any, input *flag) (any, error) {
+			return &flag{On: input.On}, nil
+		},
+	}
+	return newRegistry(t, onErrorManifest+flagManifest, hs)
+}
+
+func TestEnabled(t *testing.T) {
+	testCases := []struct {
+		name     string
+		grid     string
+		want     map[string]node.Status
+		wantRan  []string
+		wantFail bool
+	}{
+		{
+			name: "disabled step and its dependents are skipped",
+			grid: `
+				step "consumer" "off" {
+					enabled = false
+				}
+				step "consumer" "child" {
+					depends_on = [step.consumer.off]
+				}
+				step "consumer" "grandchild" {
+					depends_on = [step.consumer.child]
+				}
+				step "consumer" "other" {
+					arguments {
+						value = "other"
+					}
+				}
+			`,
+			want: map[string]node.Status{
+				"step.consumer.off":        node.StatusSkipped,
+				"step.consumer.child":      node.StatusSkipped,
+				"step.consumer.grandchild": node.StatusSkipped,
+				"step.consumer.other":      node.StatusCompleted,
+			},
+			wantRan: []string{"other"},
+		},
+		{
+			name: "enabled reads upstream outputs",
+			grid: `
+				step "flag" "gate" {
+					arguments {
+						on = false
+					}
+				}
+				step "consumer" "guarded" {
+					enabled = step.flag.gate.output.on
+				}
+				step "consumer" "open" {
+					enabled = !step.flag.gate.output.on
+					arguments {
+						value = "open"
+					}
+				}
+			`,
+			want: map[string]node.Status{
+				"step.flag.gate":        node.StatusCompleted,
+				"step.consumer.guarded": node.StatusSkipped,
+				"step.consumer.open":    node.StatusCompleted,
+			},
+			wantRan: []string{"open"},
+		},
+		{
+			name: "continue_on_failure dependents still run",
+			grid: `
+				step "consumer" "off" {
+					enabled = false
+				}
+				step "consumer" "cleanup" {
+					depends_on          = [step.consumer.off]
+					continue_on_failure = true
+					arguments {
+						value = "cleanup"
+					}
+				}
+				step "consumer" "after" {
+					depends_on = [step.consumer.cleanup]
+					arguments {
+						value = "after"
+					}
+				}
+			`,
+			want: map[string]node.Status{
+				"step.consumer.off":     node.StatusSkipped,
+				"step.consumer.cleanup": node.StatusCompleted,
+				"step.consumer.after":   node.StatusCompleted,
+			},
+			wantRan: []string{"cleanup", "after"},
+		},
+		{
+			name: "instances are enabled individually",
+			grid: `
+				step "consumer" "items" {
+					count   = 3
+					enabled = count.index != 1
+					arguments {
+						value = "item${count.index}"
+					}
+				}
+			`,
+			want: map[string]node.Status{
+				"step.consumer.items[0]": node.StatusCompleted,
+				"step.consumer.items[1]": node.StatusSkipped,
+				"step.consumer.items[2]": node.StatusCompleted,
+			},
+			wantRan: []string{"item0", "item2"},
+		},
+		{
+			name: "invalid value fails the step",
+			grid: `
+				step "consumer" "bad" {
+					enabled = "sometimes"
+				}
+			`,
+			want:     map[string]node.Status{"step.consumer.bad": node.StatusFailed},
+			wantFail: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var c consumed
+			reg := enabledRegistry(t, &c)
+			g := compileGraph(t, tc.grid)
+
+			err := New(scheduler.New(g), g, builder.New(reg), reg, 2).Execute(testContext())
+			if tc.wantFail {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), "Invalid enabled value")
+			} else {
+				require.NoError(t, err)
+			}
+			for id, want := range tc.want {
+				assert.Equal(t, want, status(t, g, id), id)
+			}
+			assert.ElementsMatch(t, tc.wantRan, c.values)
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package localexecutor
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/specialistvlad/burstgridgo/internal/handlers"
+	"github.com/specialistvlad/burstgridgo/internal/model"
+	"github.com/specialistvlad/burstgridgo/internal/sensitive"
+	"github.com/specialistvlad/burstgridgo/internal/task"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+)
+
+// evalEnv evaluates the environment overlay of a step's handler, along with
+// the secrets among its values. The overlay is the `env` of each resource the
+// step uses, in the order of its `uses` list, overlaid by the step's own `env`.
+// Every task gets a map of its own, so consumers of a resource never share an
+// overlay. Resource env is evaluated in the step's evaluation context. Unset or
+// null attributes add nothing; with none set there is no overlay.
+func (e *Executor) evalEnv(t *task.Task) (handlers.Env, sensitive.Secrets, error) {
+	step := t.Node.Config
+	if step == nil {
+		return nil, nil, nil
+	}
+
+	var env handlers.Env
+	var secrets sensitive.Secrets
+	add := func(expr hcl.Expression) error {
+		vars, s, err := evalEnvExpr(expr, t.EvalContext)
+		if err != nil || vars == nil {
+			return err
+		}
+		if env == nil {
+			env = make(handlers.Env, len(vars))
+		}
+		for k, v := range vars {
+			env[k] = v
+		}
+		secrets = append(secrets, s...)
+		return nil
+	}
+
+	for _, trav := range step.ResourceReferences() {
+		typ, name, _ := model.ParseResourceReference(trav)
+		r, ok := e.resources[fmt.Sprintf("resource.%s.%s", typ, name)]
+		if !ok || r.Env == nil {
+			continue
+		}
+		if err := add(r.Env); err != nil {
+			return nil, nil, fmt.Errorf("resource %s: %w", r.Address(), err)
+		}
+	}
+	if step.Env != nil {
+		if err := add(step.Env); err != nil {
+			return nil, nil, err
+		}
+	}
+	return env, secrets, nil
+}
+
+// evalEnvExpr evaluates an `env` attribute into a set of environment variables,
+// along with the secrets among its values. A null value yields no variables.
+func evalEnvExpr(expr hcl.Expression, evalCtx *hcl.EvalContext) (handlers.Env, sensitive.Secrets, error) {
+	val, diags := expr.Value(evalCtx)
+	if diags.HasErrors() {
+		return nil, nil, fmt.Errorf("invalid env: %w", diags)
+	}
+	val, secrets := sensitive.Unmark(val)
+	if val.IsNull() {
+		return nil, nil, nil
+	}
+
+	invalid := func(detail string) error {
+		return fmt.Errorf("invalid env: %w", hcl.Diagnostics{{
+			Severity: hcl.DiagError,
+			Summary:  "Invalid env value",
+			Detail:   fmt.Sprintf("Inappropriate value: %s.", detail),
+			Subject:  expr.Range().Ptr(),
+		}})
+	}
+	if !val.IsWhollyKnown() {
+		return nil, nil, invalid("the value is not known yet")
+	}
+	converted, err := convert.Convert(val, cty.Map(cty.String))
+	if err != nil {
+		return nil, nil, invalid(fmt.Sprintf("map of string is required: %s", err))
+	}
+
+	env := make(handlers.Env, converted.LengthInt())
+	for it := converted.ElementIterator(); it.Next(); {
+		k, v := it.Element()
+		name := k.AsString()
+		if name == "" || strings.ContainsAny(name, "=\x00") {
+			return nil, nil, invalid(fmt.Sprintf("%q is not a valid environment variable name", name))
+		}
+		if v.IsNull() {
+			return nil, nil, invalid(fmt.Sprintf("variable %q must not be null", name))
+		}
+		env[name] = v.AsString()
+	}
+	return env, secrets, nil
+}
@@ -0,0 +1,167 @@
+package localexecutor
+
+import (
+	"context"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/specialistvlad/burstgridgo/internal/builder"
+	"github.com/specialistvlad/burstgridgo/internal/handlers"
+	"github.com/specialistvlad/burstgridgo/internal/registry"
+	"github.com/specialistvlad/burstgridgo/internal/scheduler"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const envManifest = `
+runner "envy" {
+  lifecycle {
+    on_run = "OnRunEnvy"
+  }
+}
+`
+
+// seenEnv records the environment each envy node saw, by node ID.
+type seenEnv struct {
+	mu      sync.Mutex
+	lookup  map[string]string
+	environ map[string][]string
+	command map[string][]string
+}
+
+func envRegistry(t *testing.T, seen *seenEnv) *registry.Registry {
+	seen.lookup = make(map[string]string)
+	seen.environ = make(map[string][]string)
+	seen.command = make(map[string][]string)
+	return newRegistry(t, envManifest, map[string]*handlers.RegisteredHandler{
+		"OnRunEnvy": {
+			Input: func() any { return new(struct{}) },
+			Fn: func(ctx context.Context, deps any, input *struct{}) (any, error) {
+				region, _ := handlers.LookupEnv(ctx, "BGGO_TEST_REGION")
+				env := handlers.EnvFrom(ctx)
+				id := env["ID"]
+				seen.mu.Lock()
+				defer seen.mu.Unlock()
+				seen.lookup[id] = region
+				seen.environ[id] = handlers.Environ(ctx)
+				seen.command[id] = handlers.Command(ctx, "true").Env
+				// A handler writing to its overlay must not affect other steps.
+				if env != nil {
+					env["BGGO_TEST_LEAK"] = id
+				}
+				return nil, nil
+			},
+		},
+	})
+}
+
+func TestEnv(t *testing.T) {
+	t.Setenv("BGGO_TEST_REGION", "process")
+	t.Setenv("BGGO_TEST_KEEP", "kept")
+
+	var seen seenEnv
+	reg := envRegistry(t, &seen)
+	g := compileGraph(t, `
+		step "envy" "regions" {
+			for_each = ["eu", "us"]
+			env = {
+				ID               = each.key
+				BGGO_TEST_REGION = each.value
+			}
+		}
+		step "envy" "plain" {
+			env = {
+				ID = "plain"
+			}
+		}
+	`)
+	require.NoError(t, New(scheduler.New(g), g, builder.New(reg), reg, 3).Execute(testContext()))
+
+	assert.Equal(t, map[string]string{"eu": "eu", "us": "us", "plain": "process"}, seen.lookup)
+	assert.Contains(t, seen.environ["eu"], "BGGO_TEST_REGION=eu")
+	assert.NotContains(t, seen.environ["eu"], "BGGO_TEST_REGION=process", "the overlay replaces the variable")
+	assert.Contains(t, seen.environ["eu"], "BGGO_TEST_KEEP=kept")
+	assert.Contains(t, seen.command["us"], "BGGO_TEST_REGION=us")
+	assert.Equal(t, "process", os.Getenv("BGGO_TEST_REGION"), "the process environment is untouched")
+}
+
+func TestEnv_Resources(t *testing.T) {
+	t.Setenv("BGGO_TEST_REGION", "process")
+	environ := os.Environ()
+
+	var seen seenEnv
+	reg := envRegistry(t, &seen)
+	g, grid := compileGrid(t, `
+		resource "vault" "shared" {
+			env = {
+				BGGO_TEST_REGION = "resource"
+				BGGO_TEST_TOKEN  = "from-resource"
+			}
+		}
+		resource "vault" "quiet" {}
+		step "envy" "first" {
+			uses = [resource.vault.shared, resource.vault.quiet]
+			env = {
+				ID = "first"
+			}
+		}
+		step "envy" "second" {
+			uses       = [resource.vault.shared]
+			depends_on = [step.envy.first]
+			env = {
+				ID               = "second"
+				BGGO_TEST_REGION = "step"
+			}
+		}
+		step "envy" "bystander" {
+			depends_on = [step.envy.second]
+			env = {
+				ID = "bystander"
+			}
+		}
+	`)
+	exec := New(scheduler.New(g), g, builder.New(reg), reg, 1, WithResources(grid.Resources))
+	require.NoError(t, exec.Execute(testContext()))
+
+	assert.Equal(t, map[string]string{"first": "resource", "second": "step", "bystander": "process"}, seen.lookup,
+		"the step's env overrides its resources'")
+	assert.Contains(t, seen.environ["first"], "BGGO_TEST_TOKEN=from-resource")
+	assert.Contains(t, seen.command["second"], "BGGO_TEST_TOKEN=from-resource")
+	assert.NotContains(t, seen.environ["bystander"], "BGGO_TEST_TOKEN=from-resource", "only consumers see a resource's env")
+	for id, env := range seen.environ {
+		for _, kv := range env {
+			assert.NotContains(t, kv, "BGGO_TEST_LEAK", "%s saw another step's overlay", id)
+		}
+	}
+
+	_, ok := os.LookupEnv("BGGO_TEST_TOKEN")
+	assert.False(t, ok, "the process environment is untouched")
+	assert.Equal(t, environ, os.Environ(), "the process environment is untouched")
+}
+
+func TestEnv_InvalidValue(t *testing.T) {
+	testCases := []struct {
+		name string
+		env  string
+	}{
+		{name: "not a map", env: `"REGION=eu"`},
+		{name: "invalid name", env: `{ "A=B" = "x" }`},
+		{name: "null value", env: `{ ID = null }`},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var seen seenEnv
+			reg := envRegistry(t, &seen)
+			g := compileGraph(t, `
+				step "envy" "bad" {
+					env = `+tc.env+`
+				}
+			`)
+			err := New(scheduler.New(g), g, builder.New(reg), reg, 1).Execute(testContext())
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "Invalid env value")
+			assert.Empty(t, seen.lookup)
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package localexecutor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/specialistvlad/burstgridgo/internal/handlers"
+	"github.com/specialistvlad/burstgridgo/internal/nodeid"
+	"github.com/specialistvlad/burstgridgo/internal/scheduler"
+)
+
+// NodeError associates an execution error with the node that produced it.
+type NodeError struct {
+	Node     nodeid.Address
+	Err      error
+	Attempts int // number of times the node was run; 0 if it never ran
+}
+
+func (e *NodeError) Error() string {
+	if e.Attempts > 1 {
+		return fmt.Sprintf("%s (after %d attempts): %v", e.Node.String(), e.Attempts, e.Err)
+	}
+	return fmt.Sprintf("%s: %v", e.Node.String(), e.Err)
+}
+
+func (e *NodeError) Unwrap() error {
+	return e.Err
+}
+
+// TimeoutKind names the `timeouts` attribute that a node exceeded.
+type TimeoutKind string
+
+const (
+	TimeoutExecution TimeoutKind = "execution"
+	TimeoutStart     TimeoutKind = "start"
+	TimeoutQueue     TimeoutKind = "queue"
+	TimeoutDeadline  TimeoutKind = "deadline"
+)
+
+// TimeoutError reports that a node exceeded one of its `timeouts`. Its error
+// class is handlers.ClassTimeout, so `retry_on = ["timeout"]` matches it, and
+// it unwraps to context.DeadlineExceeded.
+type TimeoutError struct {
+	Kind TimeoutKind
+
+	// Limit is the configured duration; for TimeoutDeadline it is zero and
+	// Deadline holds the wall-clock time instead.
+	Limit    time.Duration
+	Deadline time.Time
+}
+
+func (e *TimeoutError) Error() string {
+	if e.Kind == TimeoutDeadline {
+		return fmt.Sprintf("deadline %s exceeded", e.Deadline.Format(time.RFC3339))
+	}
+	return fmt.Sprintf("%s timeout of %s exceeded", e.Kind, e.Limit)
+}
+
+func (e *TimeoutError) Unwrap() error {
+	return context.DeadlineExceeded
+}
+
+// ErrorClass implements handlers.Classifier.
+func (e *TimeoutError) ErrorClass() string {
+	return handlers.ClassTimeout
+}
+
+// RunError is returned by Executor.Execute when a run did not fully succeed.
+// It describes every failed node and every node that could never be scheduled.
+type RunError struct {
+	Outcome  scheduler.Outcome
+	Failures []*NodeError
+	Stalled  []nodeid.Address
+
+	// Aborted is set when a failed node's `on_error` action ended the run early.
+	Aborted bool
+}
+
+// errAborted is the cancellation cause of a run ended by `abort_run`.
+var errAborted = errors.New("run aborted by on_error")
+
+func (e *RunError) Error() string {
+	var b strings.Builder
+	if e.Aborted {
+		b.WriteString("run aborted: ")
+	}
+	switch {
+	case len(e.Failures) > 0:
+		fmt.Fprintf(&b, "%d node(s) failed", len(e.Failures))
+	case e.Outcome == scheduler.OutcomeDeadlock:
+		b.WriteString("deadlock: no node can make progress")
+	default:
+		b.WriteString("run did not complete")
+	}
+	for _, f := range e.Failures {
+		fmt.Fprintf(&b, "\n  - %s", f.Error())
+	}
+	if len(e.Stalled) > 0 {
+		names := make([]string, len(e.Stalled))
+		for i, id := range e.Stalled {
+			names[i] = id.String()
+		}
+		fmt.Fprintf(&b, "\n  not run: %s", strings.Join(names, ", "))
+	}
+	return b.String()
+}
+
+// Unwrap exposes the individual node errors to errors.Is and errors.As.
+func (e *RunError) Unwrap() []error {
+	errs := make([]error, len(e.Failures))
+	for i, f := range e.Failures {
+		errs[i] = f
+	}
+	return errs
+}
@@ -1,33 +1,479 @@
 // Package localexecutor provides a concrete, in-process implementation of the
 // executor.Executor interface.
+//
+// # Worker Pool
+//
+// The executor runs a fixed pool of worker goroutines that all consume the same
+// Scheduler.ReadyNodes() channel. Each worker processes one node at a time:
+//
+//  1. **Claim:** Mark the node Running in the graph
+//  2. **Build:** Resolve the node into a task.Task via builder.Builder
+//  3. **Run:** Invoke the runner's on_run handler with the task's inputs,
+//     retrying failed attempts as the step's `retry` block allows
+//  4. **Record:** Mark the node Completed (with its output) or Failed (with its error)
+//  5. **Notify:** Wake the scheduler so it can emit newly-ready dependents
+//
+// The pool size bounds how many nodes execute concurrently; it comes from
+// Config.WorkerCount (the --workers flag).
+//
+// # Concurrency Limits
+//
+// Ready nodes pass through a dispatcher before reaching the workers. A step
+// with a `concurrency` block runs at most `limit` of its instances at once;
+// with `per_key`, the limit applies separately to each evaluated key (and
+// defaults to 1). Nodes over the limit are parked without occupying a worker
+// and are released, in the step's `order` ("fifo", "lifo" or "priority",
+// highest `priority` first), as running instances finish.
+//
+// A step with a `rate_limit` block takes a token from a bucket that refills
+// at `limit` per `per` and holds at most `burst` tokens, keyed by step or by
+// step and evaluated `key`. Nodes that find the bucket empty wait on a timer
+// rather than a worker. The observed per-step and per-key rates are logged at
+// the end of the run and returned by Executor.Metrics.
+//
+// # Conditional Steps
+//
+// A step's `enabled` attribute is evaluated when the scheduler hands its node
+// over, so it may reference upstream outputs. A node that is not enabled is
+// marked Skipped and never built. Skipping propagates: every pending node that
+// transitively depends on it is Skipped too, except nodes whose step sets
+// `continue_on_failure = true`. Those run once all of their dependencies are
+// final, as they do after a failed dependency, and propagation stops there.
+//
+// # Priorities and Delays
+//
+// The dispatcher only takes a node from the scheduler when it has none left
+// for the workers, so that when workers are saturated the backlog stays in
+// the scheduler's ready queue, which dispatches higher `priority` nodes first
+// (see Priority and scheduler.WithPriority).
+//
+// `delay_before` holds a node back on a timer before it reaches a worker, and
+// `delay_after` defers marking a finished node Completed, keeping its
+// dependents waiting while the worker moves on. Both timers end early when
+// the run is cancelled.
+//
+// # Dynamic Expansion
+//
+// A placeholder node (a step whose `count` or `for_each` depends on upstream
+// outputs) is not built or run. Instead the worker evaluates its `count` or
+// `for_each`, inserts the resulting instances into the graph with Graph.Expand
+// and marks the placeholder Completed. The scheduler then picks up the new
+// instances on its next scan, and the placeholder's dependents wait for them.
+//
+// # Retries
+//
+// A step with a `retry` block is re-run after a failed attempt while attempts
+// remain, the error's class passes `retry_on`/`abort_on`, and `max_duration`
+// is not exceeded. The worker waits out the backoff delay between attempts.
+// Each attempt is recorded with Graph.RecordAttempt, and a node that still
+// fails reports how many attempts it took. Build errors are never retried.
+//
+// # Caching
+//
+// A step with a `cache` block is looked up in the cache store of its `scope`
+// once its task is built (see package cache). On a hit the node is marked
+// Completed with the cached output without running, and the hit is logged;
+// otherwise a successful output is stored for the block's `ttl`. Stores are
+// configured with WithCache.
+//
+// # Deduplication and Idempotency
+//
+// A step with a `dedupe` block claims its evaluated `key` (by default its
+// runner type and input hash) in the table of its `scope`: "run" (the
+// default) or "global", which spans every run in the process but, being held
+// in memory, not the process itself. The first node to claim a key runs;
+// later ones either wait, without holding a worker, and complete or fail with
+// its outcome ("share", the default) or are Skipped along with their
+// dependents ("skip"). A key is released when its node fails, so the next
+// node to claim it runs again.
+//
+// A step with an `idempotency_key` records its output under that key once it
+// succeeds, under that key and the step's address. A node whose step already
+// recorded its key, typically in an earlier run of the same grid, is completed
+// with the recorded output instead of running again. The store is set with
+// WithIdempotencyStore.
+//
+// # Sensitive Values
+//
+// The output of a step with `sensitive = true` is recorded with the
+// sensitive.Mark cty mark, which expression evaluation carries over to
+// anything derived from it. Handlers receive unmarked inputs, but the logger
+// in their context redacts the strings of sensitive inputs, and so is the
+// message of any error they return, which keeps them out of the run report.
+//
+// # Environment
+//
+// A step's `env` map is evaluated once its task is built and handed to the
+// handler as an overlay on the process environment, through its context (see
+// handlers.LookupEnv, handlers.Environ and handlers.Command for subprocesses).
+// os.Environ is never modified, so each step sees only its own variables.
+// Sensitive values in the map are redacted from the handler's logs and errors.
+//
+// # Timeouts
+//
+// A step's `timeouts` block is evaluated once its task is built:
+//
+//   - `queue` bounds the time between the scheduler finding the node ready
+//     (Scheduler.ReadyAt) and a worker receiving it
+//   - `start` bounds the time between the node becoming ready and its first
+//     attempt starting, which also covers building the task
+//   - `execution` bounds each attempt through a context deadline on the
+//     handler call
+//   - `deadline` is a wall-clock limit (an RFC 3339 timestamp, or a duration
+//     from the start of the run) covering every attempt and retry delay
+//
+// `queue` and `start` are also evaluated when the dispatcher takes the node
+// from the scheduler, which arms a timer that fails it as soon as the sooner
+// of the two passes, so a node held back by busy workers, a `concurrency`
+// limit or a `rate_limit` does not wait for them to free up before failing.
+// Time spent in `delay_before` does not count against either. The timer is
+// armed from Scheduler.ReadyAt, so a node taken from the scheduler's ready
+// queue after its limit has passed fails right away.
+//
+// A node that exceeds any of them fails with a *TimeoutError, whose error
+// class is "timeout" so that `retry_on` can match it.
+//
+// # Failures
+//
+// A failed node is handled according to its step's `on_error` block. By
+// default ("fail") the failure is reported and the node's dependents never
+// run. "continue" completes the node with its `fallback` value (null if
+// unset, and the default action when a fallback is given) so that dependents
+// receive it as the node's output. "skip_dependents" marks every transitive
+// dependent Skipped without failing the run, and "abort_run" cancels every
+// other node and ends the run with a *RunError whose Aborted field is set.
+//
+// # Termination
+//
+// Execute returns once the scheduler closes the ready channel and every worker
+// has finished its current node. Failures of individual nodes do not stop the
+// run: independent branches keep executing and every failure is reported in a
+// single *RunError.
 package localexecutor
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
 
 	"github.com/specialistvlad/burstgridgo/internal/builder"
+	"github.com/specialistvlad/burstgridgo/internal/cache"
 	"github.com/specialistvlad/burstgridgo/internal/ctxlog"
 	"github.com/specialistvlad/burstgridgo/internal/executor"
 	"github.com/specialistvlad/burstgridgo/internal/graph"
 	"github.com/specialistvlad/burstgridgo/internal/handlers"
+	"github.com/specialistvlad/burstgridgo/internal/model"
+	"github.com/specialistvlad/burstgridgo/internal/node"
+	"github.com/specialistvlad/burstgridgo/internal/registry"
 	"github.com/specialistvlad/burstgridgo/internal/scheduler"
+	"github.com/specialistvlad/burstgridgo/internal/sensitive"
+	"github.com/specialistvlad/burstgridgo/internal/task"
+	"github.com/zclconf/go-cty/cty"
 )
 
 // Executor implements the executor.Executor interface for local execution.
-type Executor struct{}
+type Executor struct {
+	scheduler scheduler.Scheduler
+	graph     graph.Graph
+	builder   builder.Builder
+	registry  *registry.Registry
+	workers   int
+	gate      *gate
+	limiter   *limiter
+	watchdog  *watchdog
+	started   time.Time               // start of the run, the origin of relative deadlines
+	abort     context.CancelCauseFunc // cancels the run for `on_error { action = "abort_run" }`
 
-// New creates a new local executor.
+	caches      map[string]cache.Store     // output cache backend by `scope`
+	idempotency cache.Store                // outputs recorded by `idempotency_key`
+	dedupes     map[string]*DedupeTable    // dedupe keys by `scope`
+	resources   map[string]*model.Resource // grid resources by address
+	leading     map[string]leadership      // dedupe flights led by node ID; guarded by mu
+	delays      map[string]time.Duration   // pending `delay_before` by node ID; owned by dispatch
+	delayed     sync.WaitGroup             // nodes waiting out their `delay_after`
+
+	mu       sync.Mutex
+	failures []*NodeError
+}
+
+// Option configures an Executor.
+type Option func(*Executor)
+
+// WithCache sets the backend used by steps whose `cache` block has the given
+// `scope`. By default each scope uses its own in-memory store.
+func WithCache(scope string, store cache.Store) Option {
+	return func(e *Executor) {
+		e.caches[scope] = store
+	}
+}
+
+// WithIdempotencyStore sets where outputs of steps with an `idempotency_key`
+// are recorded. By default records live in memory for the executor's run.
+func WithIdempotencyStore(store cache.Store) Option {
+	return func(e *Executor) {
+		e.idempotency = store
+	}
+}
+
+// WithDedupe sets the table of dedupe keys used by steps whose `dedupe` block
+// has the given `scope`. By default "run" uses a table of its own and
+// "global" one shared by every executor in the process, for its lifetime.
+func WithDedupe(scope string, table *DedupeTable) Option {
+	return func(e *Executor) {
+		e.dedupes[scope] = table
+	}
+}
+
+// WithResources sets the grid's resources. The `env` of each resource a step
+// lists in `uses` is applied beneath the step's own `env` (see evalEnv).
+func WithResources(resources []*model.Resource) Option {
+	return func(e *Executor) {
+		for _, r := range resources {
+			e.resources[r.Address()] = r
+		}
+	}
+}
+
+// New creates a new local executor. A workers value below 1 is treated as 1.
 func New(
 	sch scheduler.Scheduler,
 	g graph.Graph,
 	b builder.Builder,
-	reg *handlers.Handlers,
+	reg *registry.Registry,
+	workers int,
+	opts ...Option,
 ) executor.Executor {
-	return &Executor{}
+	if workers < 1 {
+		workers = 1
+	}
+	e := &Executor{
+		scheduler: sch,
+		graph:     g,
+		builder:   b,
+		registry:  reg,
+		workers:   workers,
+		gate:      newGate(),
+		limiter:   newLimiter(),
+		watchdog:  newWatchdog(),
+		delays:    make(map[string]time.Duration),
+		caches: map[string]cache.Store{
+			cache.ScopeRun:    cache.NewMemory(),
+			cache.ScopeGlobal: cache.NewMemory(),
+		},
+		idempotency: cache.NewMemory(),
+		dedupes: map[string]*DedupeTable{
+			cache.ScopeRun:    NewDedupeTable(),
+			cache.ScopeGlobal: globalDedupe,
+		},
+		leading:   make(map[string]leadership),
+		resources: make(map[string]*model.Resource),
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
 }
 
+// Execute runs the graph to completion on a bounded pool of workers.
+//
+// It returns nil when every node completed, a *RunError when nodes failed or
+// could never be scheduled, and the context error when ctx is cancelled.
 func (e *Executor) Execute(ctx context.Context) error {
 	logger := ctxlog.FromContext(ctx)
-	logger.Debug("localexecutor.Executor.Execute called (placeholder)")
-	return nil
+	logger.Debug("Starting local executor.", "workers", e.workers)
+
+	parent := ctx
+	ctx, e.abort = context.WithCancelCause(ctx)
+	defer e.abort(nil)
+
+	e.started = time.Now()
+	ready := e.scheduler.ReadyNodes(ctx)
+	work := make(chan *node.Node)
+	go e.dispatch(ctx, ready, work)
+
+	var wg sync.WaitGroup
+	for i := 0; i < e.workers; i++ {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			workerCtx := ctxlog.WithLogger(ctx, logger.With("worker", id))
+			for n := range work {
+				e.process(workerCtx, n)
+				e.gate.release(n)
+			}
+		}(i)
+	}
+	wg.Wait()
+	e.disarm()
+	e.delayed.Wait()
+
+	for _, r := range e.Metrics().Rates {
+		logger.Info("Observed dispatch rate.", "step", r.Step, "key", r.Key, "dispatches", r.Dispatches, "per_second", r.PerSecond)
+	}
+	res := e.scheduler.Result()
+	logger.Debug("Local executor finished.", "outcome", res.Outcome, "failed", len(e.failures))
+
+	if parent.Err() != nil {
+		return parent.Err()
+	}
+	aborted := errors.Is(context.Cause(ctx), errAborted)
+	if res.Outcome == scheduler.OutcomeCancelled && !aborted {
+		return ctx.Err()
+	}
+	if len(e.failures) == 0 && len(res.Stalled) == 0 {
+		return nil
+	}
+	sort.Slice(e.failures, func(i, j int) bool {
+		return e.failures[i].Node.String() < e.failures[j].Node.String()
+	})
+	return &RunError{
+		Outcome:  res.Outcome,
+		Failures: e.failures,
+		Stalled:  res.Stalled,
+		Aborted:  aborted,
+	}
+}
+
+// process drives a single node through its lifecycle and always notifies the
+// scheduler afterwards, whatever the result.
+func (e *Executor) process(ctx context.Context, n *node.Node) {
+	received := time.Now()
+	if !e.claim(n) {
+		return
+	}
+	logger := ctxlog.FromContext(ctx).With("node", n.ID.String())
+	defer e.scheduler.Notify()
+
+	if err := e.graph.MarkRunning(ctx, n.ID); err != nil {
+		e.fail(ctx, n, fmt.Errorf("failed to mark node running: %w", err))
+		return
+	}
+	logger.Debug("Node started.")
+
+	if n.Placeholder {
+		if err := e.expand(ctx, n); err != nil {
+			e.fail(ctx, n, err)
+			return
+		}
+		if err := e.graph.MarkCompleted(ctx, n.ID, nil); err != nil {
+			e.fail(ctx, n, fmt.Errorf("failed to mark node completed: %w", err))
+		}
+		return
+	}
+
+	t, err := e.builder.Build(ctx, n, e.graph)
+	if err != nil {
+		e.fail(ctx, n, fmt.Errorf("failed to build task: %w", err))
+		return
+	}
+
+	lim, err := e.limits(t)
+	if err != nil {
+		e.fail(ctx, n, err)
+		return
+	}
+	before, after, err := delays(t)
+	if err != nil {
+		e.fail(ctx, n, err)
+		return
+	}
+	sens, err := evalSensitive(t)
+	if err != nil {
+		e.fail(ctx, n, err)
+		return
+	}
+	env, secrets, err := e.evalEnv(t)
+	if err != nil {
+		e.fail(ctx, n, err)
+		return
+	}
+	// Time spent in `delay_before` does not count against `queue` or `start`.
+	readyAt, _ := e.scheduler.ReadyAt(n.ID)
+	if !readyAt.IsZero() {
+		readyAt = readyAt.Add(before)
+	}
+	if err := lim.checkWait(readyAt, received, time.Now()); err != nil {
+		e.fail(ctx, n, err)
+		return
+	}
+	idemKey, err := idempotencyKey(t)
+	if err != nil {
+		e.fail(ctx, n, err)
+		return
+	}
+	if idemKey != "" {
+		if output, ok := e.recorded(ctx, idemKey); ok {
+			if err := e.complete(ctx, n, output); err != nil {
+				e.fail(ctx, n, fmt.Errorf("failed to mark node completed: %w", err))
+				return
+			}
+			logger.Info("Idempotency key already recorded, node completed from its earlier run.")
+			return
+		}
+	}
+	slot, err := e.cacheSlot(t)
+	if err != nil {
+		e.fail(ctx, n, err)
+		return
+	}
+	if slot != nil && slot.restore {
+		if output, ok := slot.get(ctx); ok {
+			if err := e.complete(ctx, n, output); err != nil {
+				e.fail(ctx, n, fmt.Errorf("failed to mark node completed: %w", err))
+				return
+			}
+			logger.Info("Cache hit, node completed from cache.", "scope", slot.scope, "key", slot.key)
+			return
+		}
+	}
+	if handled, err := e.dedupe(ctx, t); err != nil || handled {
+		if err != nil {
+			e.fail(ctx, n, err)
+		}
+		return
+	}
+
+	runCtx, cancel := lim.withDeadline(handlers.WithEnv(ctxlog.WithSecrets(ctx, secrets), env))
+	defer cancel()
+
+	output, err := e.runWithRetry(runCtx, t, lim)
+	if err != nil {
+		e.fail(ctx, n, secrets.RedactError(err))
+		return
+	}
+	if sens {
+		output = output.Mark(sensitive.Mark)
+	}
+	if slot != nil {
+		slot.put(ctx, output)
+	}
+	if idemKey != "" {
+		e.record(ctx, idemKey, output)
+	}
+	if after > 0 {
+		logger.Debug("Node finished, waiting before completing it.", "delay_after", after)
+		e.completeAfter(ctx, n, output, after)
+		return
+	}
+
+	if err := e.complete(ctx, n, output); err != nil {
+		e.fail(ctx, n, fmt.Errorf("failed to mark node completed: %w", err))
+		return
+	}
+	logger.Debug("Node completed.")
+}
+
+// run executes a built task. A panic inside the task is converted into an
+// error so that one misbehaving node cannot take down the whole pool.
+func (e *Executor) run(ctx context.Context, t *task.Task) (output cty.Value, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic during execution: %v", r)
+		}
+	}()
+
+	return e.invoke(ctx, t)
 }
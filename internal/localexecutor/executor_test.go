@@ -0,0 +1,205 @@
+package localexecutor
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/specialistvlad/burstgridgo/internal/ctxlog"
+	"github.com/specialistvlad/burstgridgo/internal/graph"
+	"github.com/specialistvlad/burstgridgo/internal/handlers"
+	"github.com/specialistvlad/burstgridgo/internal/inmemorystore"
+	"github.com/specialistvlad/burstgridgo/internal/inmemorytopology"
+	"github.com/specialistvlad/burstgridgo/internal/node"
+	"github.com/specialistvlad/burstgridgo/internal/nodeid"
+	"github.com/specialistvlad/burstgridgo/internal/registry"
+	"github.com/specialistvlad/burstgridgo/internal/scheduler"
+	"github.com/specialistvlad/burstgridgo/internal/task"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeBuilder lets tests fail specific nodes and observe concurrency.
+type fakeBuilder struct {
+	fail  map[string]error
+	delay time.Duration
+
+	mu      sync.Mutex
+	order   []string
+	running atomic.Int32
+	peak    atomic.Int32
+}
+
+func (b *fakeBuilder) EvalContext(ctx context.Context, n *node.Node, g graph.Graph) (*hcl.EvalContext, error) {
+	return &hcl.EvalContext{}, nil
+}
+
+func (b *fakeBuilder) Build(ctx context.Context, n *node.Node, g graph.Graph) (*task.Task, error) {
+	cur := b.running.Add(1)
+	defer b.running.Add(-1)
+	for {
+		peak := b.peak.Load()
+		if cur <= peak || b.peak.CompareAndSwap(peak, cur) {
+			break
+		}
+	}
+
+	b.mu.Lock()
+	b.order = append(b.order, n.ID.String())
+	b.mu.Unlock()
+
+	time.Sleep(b.delay)
+	if err, ok := b.fail[n.ID.String()]; ok {
+		return nil, err
+	}
+	return &task.Task{Node: n}, nil
+}
+
+// noopManifest declares the runner used by every node built with newGraph.
+const noopManifest = `
+runner "noop" {
+  lifecycle {
+    on_run = "OnRunNoop"
+  }
+}
+`
+
+// newRegistry loads the given manifest and registers the given handlers.
+func newRegistry(t *testing.T, manifest string, hs map[string]*handlers.RegisteredHandler) *registry.Registry {
+	t.Helper()
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "manifest.hcl"), []byte(manifest), 0644))
+
+	h := handlers.New()
+	for name, handler := range hs {
+		h.RegisterHandler(name, handler)
+	}
+	reg := registry.New(h)
+	require.NoError(t, reg.LoadGridsRecursively(testContext(), dir))
+	return reg
+}
+
+// noopRegistry returns a registry whose "noop" runner does nothing.
+func noopRegistry(t *testing.T) *registry.Registry {
+	return newRegistry(t, noopManifest, map[string]*handlers.RegisteredHandler{
+		"OnRunNoop": {
+			Input: func() any { return new(struct{}) },
+			Fn:    func(ctx context.Context, deps any, input *struct{}) (any, error) { return nil, nil },
+		},
+	})
+}
+
+func testContext() context.Context {
+	return ctxlog.WithLogger(context.Background(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+func newGraph(t *testing.T, ids []string, edges [][2]string) graph.Graph {
+	t.Helper()
+	ctx := testContext()
+	topo := inmemorytopology.New()
+	for _, id := range ids {
+		addr, err := nodeid.Parse(id)
+		require.NoError(t, err)
+		require.NoError(t, topo.AddNode(ctx, &node.Node{ID: *addr, Type: "noop"}))
+	}
+	for _, e := range edges {
+		from, err := nodeid.Parse(e[0])
+		require.NoError(t, err)
+		to, err := nodeid.Parse(e[1])
+		require.NoError(t, err)
+		require.NoError(t, topo.AddDependency(ctx, *from, *to))
+	}
+	return graph.New(topo, inmemorystore.New())
+}
+
+func status(t *testing.T, g graph.Graph, id string) node.Status {
+	t.Helper()
+	addr, err := nodeid.Parse(id)
+	require.NoError(t, err)
+	s, _ := g.NodeStatus(testContext(), *addr)
+	return s
+}
+
+func TestExecutor_RunsAllNodesInDependencyOrder(t *testing.T) {
+	g := newGraph(t,
+		[]string{"step.a", "step.b", "step.c"},
+		[][2]string{{"step.a", "step.b"}, {"step.b", "step.c"}},
+	)
+	b := &fakeBuilder{}
+	exec := New(scheduler.New(g), g, b, noopRegistry(t), 4)
+
+	require.NoError(t, exec.Execute(testContext()))
+
+	assert.Equal(t, []string{"step.a", "step.b", "step.c"}, b.order)
+	for _, id := range b.order {
+		assert.Equal(t, node.StatusCompleted, status(t, g, id))
+	}
+}
+
+func TestExecutor_BoundsConcurrencyByWorkerCount(t *testing.T) {
+	ids := []string{"step.a", "step.b", "step.c", "step.d", "step.e", "step.f"}
+	g := newGraph(t, ids, nil)
+	b := &fakeBuilder{delay: 20 * time.Millisecond}
+	exec := New(scheduler.New(g), g, b, noopRegistry(t), 2)
+
+	require.NoError(t, exec.Execute(testContext()))
+
+	assert.Len(t, b.order, len(ids))
+	assert.Equal(t, int32(2), b.peak.Load())
+}
+
+func TestExecutor_AggregatesFailures(t *testing.T) {
+	// a and c fail; b depends on a and never runs; d is independent and succeeds.
+	g := newGraph(t,
+		[]string{"step.a", "step.b", "step.c", "step.d"},
+		[][2]string{{"step.a", "step.b"}},
+	)
+	errA := errors.New("boom a")
+	errC := errors.New("boom c")
+	b := &fakeBuilder{fail: map[string]error{"step.a": errA, "step.c": errC}}
+	exec := New(scheduler.New(g), g, b, noopRegistry(t), 3)
+
+	err := exec.Execute(testContext())
+	require.Error(t, err)
+
+	var runErr *RunError
+	require.ErrorAs(t, err, &runErr)
+	require.Len(t, runErr.Failures, 2)
+	assert.Equal(t, "step.a", runErr.Failures[0].Node.String())
+	assert.Equal(t, "step.c", runErr.Failures[1].Node.String())
+	require.Len(t, runErr.Stalled, 1)
+	assert.Equal(t, "step.b", runErr.Stalled[0].String())
+
+	assert.ErrorIs(t, err, errA)
+	assert.ErrorIs(t, err, errC)
+	assert.Contains(t, err.Error(), "2 node(s) failed")
+	assert.Contains(t, err.Error(), "step.a")
+	assert.Contains(t, err.Error(), "step.c")
+
+	assert.Equal(t, node.StatusFailed, status(t, g, "step.a"))
+	assert.Equal(t, node.StatusPending, status(t, g, "step.b"))
+	assert.Equal(t, node.StatusCompleted, status(t, g, "step.d"))
+}
+
+func TestExecutor_ReportsDeadlock(t *testing.T) {
+	g := newGraph(t,
+		[]string{"step.a", "step.b"},
+		[][2]string{{"step.a", "step.b"}, {"step.b", "step.a"}},
+	)
+	exec := New(scheduler.New(g), g, &fakeBuilder{}, noopRegistry(t), 1)
+
+	err := exec.Execute(testContext())
+
+	var runErr *RunError
+	require.ErrorAs(t, err, &runErr)
+	assert.Equal(t, scheduler.OutcomeDeadlock, runErr.Outcome)
+	assert.Len(t, runErr.Stalled, 2)
+}
@@ -0,0 +1,33 @@
+package localexecutor
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/specialistvlad/burstgridgo/internal/compiler"
+	"github.com/specialistvlad/burstgridgo/internal/ctxlog"
+	"github.com/specialistvlad/burstgridgo/internal/node"
+)
+
+// expand resolves a placeholder node: it evaluates the step's dynamic `count`
+// or `for_each` against the outputs of its completed dependencies and inserts
+// the resulting instances into the graph. The placeholder itself produces no
+// output; its dependents run once every instance has completed.
+func (e *Executor) expand(ctx context.Context, n *node.Node) error {
+	logger := ctxlog.FromContext(ctx)
+
+	evalCtx, err := e.builder.EvalContext(ctx, n, e.graph)
+	if err != nil {
+		return fmt.Errorf("failed to build evaluation context: %w", err)
+	}
+	instances, err := compiler.Instances(n, evalCtx)
+	if err != nil {
+		return fmt.Errorf("failed to expand step: %w", err)
+	}
+	if err := e.graph.Expand(ctx, n.ID, instances); err != nil {
+		return fmt.Errorf("failed to insert instances: %w", err)
+	}
+
+	logger.Debug("Placeholder expanded.", "node", n.ID.String(), "instances", len(instances))
+	return nil
+}
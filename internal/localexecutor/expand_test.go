@@ -0,0 +1,173 @@
+package localexecutor
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/specialistvlad/burstgridgo/internal/builder"
+	"github.com/specialistvlad/burstgridgo/internal/compiler"
+	"github.com/specialistvlad/burstgridgo/internal/graph"
+	"github.com/specialistvlad/burstgridgo/internal/handlers"
+	"github.com/specialistvlad/burstgridgo/internal/inmemorystore"
+	"github.com/specialistvlad/burstgridgo/internal/inmemorytopology"
+	"github.com/specialistvlad/burstgridgo/internal/model"
+	"github.com/specialistvlad/burstgridgo/internal/node"
+	"github.com/specialistvlad/burstgridgo/internal/registry"
+	"github.com/specialistvlad/burstgridgo/internal/scheduler"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const dynamicManifest = `
+runner "source" {
+  output "n" {
+    type = number
+  }
+  lifecycle {
+    on_run = "OnRunSource"
+  }
+}
+
+runner "work" {
+  input "index" {
+    type = number
+  }
+  lifecycle {
+    on_run = "OnRunWork"
+  }
+}
+
+runner "sink" {
+  lifecycle {
+    on_run = "OnRunSink"
+  }
+}
+`
+
+const dynamicGrid = `
+step "source" "config" {}
+
+step "work" "items" {
+  count = step.source.config.output.n
+  arguments {
+    index = count.index
+  }
+}
+
+step "sink" "done" {
+  depends_on = ["work.items"]
+}
+`
+
+// dynamicRun records the order in which the dynamic test runners ran.
+type dynamicRun struct {
+	mu      sync.Mutex
+	indexes []int
+	sinkSaw int // number of work instances finished when the sink ran
+}
+
+func dynamicRegistry(t *testing.T, count int, run *dynamicRun) *registry.Registry {
+	type sourceOut struct {
+		N int `cty:"n"`
+	}
+	type workIn struct {
+		Index int `bggo:"index"`
+	}
+	return newRegistry(t, dynamicManifest, map[string]*handlers.RegisteredHandler{
+		"OnRunSource": {
+			Input: func() any { return new(struct{}) },
+			Fn: func(ctx context.Context, deps any, input *struct{}) (*sourceOut, error) {
+				return &sourceOut{N: count}, nil
+			},
+		},
+		"OnRunWork": {
+			Input: func() any { return new(workIn) },
+			Fn: func(ctx context.Context, deps any, input *workIn) (any, error) {
+				run.mu.Lock()
+				defer run.mu.Unlock()
+				run.indexes = append(run.indexes, input.Index)
+				return nil, nil
+			},
+		},
+		"OnRunSink": {
+			Input: func() any { return new(struct{}) },
+			Fn: func(ctx context.Context, deps any, input *struct{}) (any, error) {
+				run.mu.Lock()
+				defer run.mu.Unlock()
+				run.sinkSaw = len(run.indexes)
+				return nil, nil
+			},
+		},
+	})
+}
+
+// compileGraph parses and compiles src into a fresh graph.
+func compileGraph(t *testing.T, src string) graph.Graph {
+	t.Helper()
+	g, _ := compileGrid(t, src)
+	return g
+}
+
+// compileGrid is compileGraph that also returns the loaded grid.
+func compileGrid(t *testing.T, src string) (graph.Graph, *model.Grid) {
+	t.Helper()
+	ctx := testContext()
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.hcl"), []byte(src), 0644))
+	grid, err := model.LoadGridsRecursively(ctx, dir)
+	require.NoError(t, err)
+
+	topo := inmemorytopology.New()
+	diags := compiler.Compile(ctx, grid, topo)
+	require.False(t, diags.HasErrors(), diags.Error())
+	return graph.New(topo, inmemorystore.New()), grid
+}
+
+func TestExecutor_ExpandsDynamicCount(t *testing.T) {
+	testCases := []struct {
+		name  string
+		count int
+	}{
+		{name: "several instances", count: 3},
+		{name: "zero instances", count: 0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			run := &dynamicRun{}
+			reg := dynamicRegistry(t, tc.count, run)
+			g := compileGraph(t, dynamicGrid)
+			exec := New(scheduler.New(g), g, builder.New(reg), reg, 4)
+
+			require.NoError(t, exec.Execute(testContext()))
+
+			sort.Ints(run.indexes)
+			require.Len(t, run.indexes, tc.count)
+			for i, idx := range run.indexes {
+				assert.Equal(t, i, idx)
+			}
+			assert.Equal(t, tc.count, run.sinkSaw, "sink must wait for every instance")
+			assert.Equal(t, node.StatusCompleted, status(t, g, "step.work.items"))
+			assert.Equal(t, node.StatusCompleted, status(t, g, "step.sink.done"))
+		})
+	}
+}
+
+func TestExecutor_DynamicCountFailure(t *testing.T) {
+	run := &dynamicRun{}
+	reg := dynamicRegistry(t, -1, run)
+	g := compileGraph(t, dynamicGrid)
+	exec := New(scheduler.New(g), g, builder.New(reg), reg, 2)
+
+	err := exec.Execute(testContext())
+	var runErr *RunError
+	require.ErrorAs(t, err, &runErr)
+	require.Len(t, runErr.Failures, 1)
+	assert.Equal(t, "step.work.items", runErr.Failures[0].Node.String())
+	assert.Contains(t, runErr.Failures[0].Error(), "must not be negative")
+	assert.Equal(t, node.StatusPending, status(t, g, "step.sink.done"))
+}
@@ -0,0 +1,49 @@
+package localexecutor
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/specialistvlad/burstgridgo/internal/bggoexpr"
+	"github.com/specialistvlad/burstgridgo/internal/cache"
+	"github.com/specialistvlad/burstgridgo/internal/ctxlog"
+	"github.com/specialistvlad/burstgridgo/internal/task"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// idempotencyKey evaluates a step's `idempotency_key` into the key of its
+// record in the idempotency store, or "" when it is unset. The record is
+// keyed by the step's address as well, so that two steps using the same key
+// do not complete each other.
+func idempotencyKey(t *task.Task) (string, error) {
+	step := t.Node.Config
+	if step == nil {
+		return "", nil
+	}
+	key, ok, diags := bggoexpr.EvalString(step.IdempotencyKey, t.EvalContext, "idempotency_key")
+	if diags.HasErrors() {
+		return "", fmt.Errorf("invalid idempotency_key: %w", diags)
+	}
+	if !ok {
+		return "", nil
+	}
+	return cache.Key(key, "step."+step.RunnerType+"."+step.Name, nil)
+}
+
+// recorded returns the output recorded under an idempotency key by an
+// earlier run. Store errors count as not recorded.
+func (e *Executor) recorded(ctx context.Context, key string) (cty.Value, bool) {
+	entry, ok, err := e.idempotency.Get(ctx, key)
+	if err != nil {
+		ctxlog.FromContext(ctx).Warn("Failed to read idempotency record.", "key", key, "error", err)
+		return cty.NilVal, false
+	}
+	return entry.Output, ok
+}
+
+// record stores the output of a successful node under its idempotency key.
+func (e *Executor) record(ctx context.Context, key string, output cty.Value) {
+	if err := e.idempotency.Put(ctx, key, cache.Entry{Output: output}); err != nil {
+		ctxlog.FromContext(ctx).Warn("Failed to write idempotency record.", "key", key, "error", err)
+	}
+}
@@ -0,0 +1,73 @@
+package localexecutor
+
+import (
+	"testing"
+
+	"github.com/specialistvlad/burstgridgo/internal/builder"
+	"github.com/specialistvlad/burstgridgo/internal/cache"
+	"github.com/specialistvlad/burstgridgo/internal/node"
+	"github.com/specialistvlad/burstgridgo/internal/scheduler"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIdempotencyKey(t *testing.T) {
+	testCases := []struct {
+		name      string
+		keys      []string // idempotency_key of each successive run
+		wantCalls int32
+	}{
+		{name: "re-run with the same key is skipped", keys: []string{"deploy-1", "deploy-1"}, wantCalls: 1},
+		{name: "new key runs again", keys: []string{"deploy-1", "deploy-2"}, wantCalls: 2},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var c consumed
+			reg := onErrorRegistry(t, &c)
+			store := cache.NewDisk(t.TempDir())
+			for _, key := range tc.keys {
+				g := compileGraph(t, `
+					step "consumer" "deploy" {
+						idempotency_key = "`+key+`"
+					}
+				`)
+				e := New(scheduler.New(g), g, builder.New(reg), reg, 1, WithIdempotencyStore(store))
+				require.NoError(t, e.Execute(testContext()))
+				assert.Equal(t, node.StatusCompleted, status(t, g, "step.consumer.deploy"))
+			}
+			assert.Equal(t, tc.wantCalls, c.calls.Load())
+		})
+	}
+}
+
+func TestIdempotencyKey_ScopedToStep(t *testing.T) {
+	var c consumed
+	reg := onErrorRegistry(t, &c)
+	g := compileGraph(t, `
+		step "consumer" "deploy" {
+			idempotency_key = "release-1"
+		}
+		step "consumer" "notify" {
+			depends_on      = [step.consumer.deploy]
+			idempotency_key = "release-1"
+		}
+	`)
+	require.NoError(t, New(scheduler.New(g), g, builder.New(reg), reg, 1).Execute(testContext()))
+	assert.Equal(t, int32(2), c.calls.Load(), "steps sharing a key must both run")
+}
+
+func TestIdempotencyKey_FailuresAreNotRecorded(t *testing.T) {
+	var c consumed
+	reg := onErrorRegistry(t, &c)
+	store := cache.NewMemory()
+	for range 2 {
+		g := compileGraph(t, `
+			step "broken" "deploy" {
+				idempotency_key = "deploy-1"
+			}
+		`)
+		err := New(scheduler.New(g), g, builder.New(reg), reg, 1, WithIdempotencyStore(store)).Execute(testContext())
+		assert.ErrorIs(t, err, errBroken)
+		assert.Equal(t, node.StatusFailed, status(t, g, "step.broken.deploy"))
+	}
+}
@@ -0,0 +1,106 @@
+package localexecutor
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/specialistvlad/burstgridgo/internal/bggocty"
+	"github.com/specialistvlad/burstgridgo/internal/bggohcl"
+	"github.com/specialistvlad/burstgridgo/internal/ctxlog"
+	"github.com/specialistvlad/burstgridgo/internal/handlers"
+	"github.com/specialistvlad/burstgridgo/internal/model"
+	"github.com/specialistvlad/burstgridgo/internal/sensitive"
+	"github.com/specialistvlad/burstgridgo/internal/task"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// invoke resolves the handler behind a task's runner, calls it with the
+// task's inputs and converts the result into the step's output object.
+//
+// Resolution follows the manifest: the node's Type names a runner, and the
+// runner's lifecycle.on_run names the registered Go handler.
+func (e *Executor) invoke(ctx context.Context, t *task.Task) (cty.Value, error) {
+	logger := ctxlog.FromContext(ctx)
+	runnerType := t.Node.Type
+
+	if e.registry == nil {
+		return cty.NilVal, fmt.Errorf("no registry configured to resolve runner %q", runnerType)
+	}
+	runner, ok := e.registry.Runner(runnerType)
+	if !ok {
+		return cty.NilVal, fmt.Errorf("runner %q is not defined by any loaded module", runnerType)
+	}
+	handlerName := runner.Lifecycle.OnRun
+	if handlerName == "" {
+		return cty.NilVal, fmt.Errorf("runner %q does not declare lifecycle.on_run", runnerType)
+	}
+	handler, ok := e.registry.Handlers().Get(handlerName)
+	if !ok {
+		return cty.NilVal, fmt.Errorf("handler %q for runner %q is not registered", handlerName, runnerType)
+	}
+
+	input, err := handler.NewInput()
+	if err != nil {
+		return cty.NilVal, fmt.Errorf("handler %q: %w", handlerName, err)
+	}
+	// Handlers see plain values. Whatever they log or return as an error is
+	// redacted of the text of their sensitive inputs instead, and they can ask
+	// which inputs are sensitive.
+	inputs := make(map[string]cty.Value, len(t.ResolvedInputs))
+	var secrets sensitive.Secrets
+	var sensitiveInputs []string
+	for name, val := range t.ResolvedInputs {
+		if sensitive.Marked(val) {
+			sensitiveInputs = append(sensitiveInputs, name)
+		}
+		unmarked, s := sensitive.Unmark(val)
+		inputs[name] = unmarked
+		secrets = append(secrets, s...)
+	}
+	if err := bggocty.DecodeInputs(inputs, input); err != nil {
+		return cty.NilVal, fmt.Errorf("failed to decode inputs for handler %q: %w", handlerName, err)
+	}
+
+	logger.Debug("Invoking handler.", "runner", runnerType, "handler", handlerName)
+	result, err := handler.Invoke(handlers.WithSensitiveInputs(ctxlog.WithSecrets(ctx, secrets), sensitiveInputs), input)
+	if err != nil {
+		return cty.NilVal, secrets.RedactError(err)
+	}
+
+	declared := make(map[string]cty.Type, len(runner.Outputs))
+	for name, def := range runner.Outputs {
+		declared[name] = def.Type
+	}
+	output, err := bggocty.ToOutput(result, declared)
+	if err == nil {
+		output, err = outputDefaults(output, runner.Outputs)
+	}
+	if err != nil {
+		return cty.NilVal, fmt.Errorf("invalid output from handler %q: %w", handlerName, err)
+	}
+	return output, nil
+}
+
+// outputDefaults fills in the defaults of the optional object attributes
+// that a handler left null in its outputs, as declared by `optional(type,
+// default)` in their types.
+func outputDefaults(output cty.Value, defs map[string]model.RunnerOutputDefinition) (cty.Value, error) {
+	var attrs map[string]cty.Value
+	for name, def := range defs {
+		if def.TypeDefaults == nil {
+			continue
+		}
+		if attrs == nil {
+			attrs = output.AsValueMap()
+		}
+		val, err := bggohcl.ConvertToType(attrs[name], def.Type, def.TypeDefaults)
+		if err != nil {
+			return cty.NilVal, fmt.Errorf("output %q: %w", name, err)
+		}
+		attrs[name] = val
+	}
+	if attrs == nil {
+		return output, nil
+	}
+	return cty.ObjectVal(attrs), nil
+}
@@ -0,0 +1,270 @@
+package localexecutor
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/specialistvlad/burstgridgo/internal/graph"
+	"github.com/specialistvlad/burstgridgo/internal/handlers"
+	"github.com/specialistvlad/burstgridgo/internal/inmemorystore"
+	"github.com/specialistvlad/burstgridgo/internal/inmemorytopology"
+	"github.com/specialistvlad/burstgridgo/internal/node"
+	"github.com/specialistvlad/burstgridgo/internal/nodeid"
+	"github.com/specialistvlad/burstgridgo/internal/scheduler"
+	"github.com/specialistvlad/burstgridgo/internal/task"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zclconf/go-cty/cty"
+)
+
+const greetManifest = `
+runner "greet" {
+  input "name" {
+    type = string
+  }
+  input "times" {
+    type = number
+  }
+  output "message" {
+    type = string
+  }
+  output "length" {
+    type = number
+  }
+  lifecycle {
+    on_run = "OnRunGreet"
+  }
+}
+`
+
+type greetInput struct {
+	Name  string `bggo:"name"`
+	Times int    `bggo:"times"`
+}
+
+type greetOutput struct {
+	Message string `cty:"message"`
+	Length  int    `cty:"length"`
+}
+
+type greetDeps struct{ Prefix string }
+
+// inputsBuilder returns tasks with a fixed set of resolved inputs.
+type inputsBuilder struct {
+	inputs map[string]cty.Value
+}
+
+func (b *inputsBuilder) EvalContext(ctx context.Context, n *node.Node, g graph.Graph) (*hcl.EvalContext, error) {
+	return &hcl.EvalContext{}, nil
+}
+
+func (b *inputsBuilder) Build(ctx context.Context, n *node.Node, g graph.Graph) (*task.Task, error) {
+	return &task.Task{Node: n, ResolvedInputs: b.inputs}, nil
+}
+
+// handlersSetup pairs a manifest with the Go handlers that implement it.
+type handlersSetup struct {
+	manifest string
+	handlers map[string]*handlers.RegisteredHandler
+}
+
+// runSingle executes a graph with one node of runnerType and returns the run
+// error together with the node's recorded output.
+func runSingle(t *testing.T, runnerType string, setup handlersSetup, inputs map[string]cty.Value) (error, any) {
+	t.Helper()
+	ctx := testContext()
+
+	addr, err := nodeid.Parse("step." + runnerType + ".one")
+	require.NoError(t, err)
+	topo := inmemorytopology.New()
+	require.NoError(t, topo.AddNode(ctx, &node.Node{ID: *addr, Type: runnerType}))
+	states := inmemorystore.New()
+	g := graph.New(topo, states)
+
+	reg := newRegistry(t, setup.manifest, setup.handlers)
+	exec := New(scheduler.New(g), g, &inputsBuilder{inputs: inputs}, reg, 1)
+	runErr := exec.Execute(ctx)
+
+	out, err := states.GetOutput(ctx, *addr)
+	require.NoError(t, err)
+	return runErr, out
+}
+
+func greetSetup(fn any) handlersSetup {
+	return handlersSetup{
+		manifest: greetManifest,
+		handlers: map[string]*handlers.RegisteredHandler{
+			"OnRunGreet": {
+				Input: func() any { return new(greetInput) },
+				Deps:  func() any { return &greetDeps{Prefix: "Hello"} },
+				Fn:    fn,
+			},
+		},
+	}
+}
+
+func TestInvoke_DecodesInputsAndConvertsOutputs(t *testing.T) {
+	var got *greetInput
+	setup := greetSetup(func(ctx context.Context, deps *greetDeps, input *greetInput) (*greetOutput, error) {
+		got = input
+		msg := deps.Prefix + ", " + input.Name
+		return &greetOutput{Message: msg, Length: len(msg) * input.Times}, nil
+	})
+
+	err, out := runSingle(t, "greet", setup, map[string]cty.Value{
+		"name":  cty.StringVal("world"),
+		"times": cty.NumberIntVal(2),
+	})
+	require.NoError(t, err)
+
+	require.NotNil(t, got)
+	assert.Equal(t, greetInput{Name: "world", Times: 2}, *got)
+
+	require.IsType(t, cty.Value{}, out)
+	want := cty.ObjectVal(map[string]cty.Value{
+		"message": cty.StringVal("Hello, world"),
+		"length":  cty.NumberIntVal(24),
+	})
+	assert.True(t, want.RawEquals(out.(cty.Value)), "got %#v", out)
+}
+
+func TestInvoke_AppliesOutputTypeDefaults(t *testing.T) {
+	setup := handlersSetup{
+		manifest: `
+			runner "endpoint" {
+				output "primary" {
+					type = object({
+						host = string
+						port = optional(number, 443)
+						tls  = optional(bool)
+					})
+				}
+				output "replicas" {
+					type = list(object({
+						host = string
+						port = optional(number, 5432)
+					}))
+				}
+				lifecycle {
+					on_run = "OnRunEndpoint"
+				}
+			}
+		`,
+		handlers: map[string]*handlers.RegisteredHandler{
+			"OnRunEndpoint": {
+				Input: func() any { return new(struct{}) },
+				Fn: func(ctx context.Context, deps any, input *struct{}) (map[string]any, error) {
+					return map[string]any{
+						"primary": map[string]any{"host": "db"},
+						"replicas": []map[string]any{
+							{"host": "r1"},
+							{"host": "r2", "port": 6432},
+						},
+					}, nil
+				},
+			},
+		},
+	}
+
+	err, out := runSingle(t, "endpoint", setup, nil)
+	require.NoError(t, err)
+
+	require.IsType(t, cty.Value{}, out)
+	val := out.(cty.Value)
+	primary := val.GetAttr("primary")
+	assert.True(t, cty.NumberIntVal(443).RawEquals(primary.GetAttr("port")), "got %#v", primary)
+	assert.True(t, primary.GetAttr("tls").IsNull(), "optional attributes without defaults stay null")
+
+	replicas := val.GetAttr("replicas")
+	assert.True(t, cty.NumberIntVal(5432).RawEquals(replicas.Index(cty.NumberIntVal(0)).GetAttr("port")))
+	assert.True(t, cty.NumberIntVal(6432).RawEquals(replicas.Index(cty.NumberIntVal(1)).GetAttr("port")),
+		"returned values are kept")
+}
+
+func TestInvoke_Failures(t *testing.T) {
+	boom := errors.New("boom")
+	okFn := func(ctx context.Context, deps *greetDeps, input *greetInput) (*greetOutput, error) {
+		return &greetOutput{}, nil
+	}
+
+	testCases := []struct {
+		name        string
+		runnerType  string
+		setup       handlersSetup
+		inputs      map[string]cty.Value
+		errContains string
+		errIs       error
+	}{
+		{
+			name:        "unknown runner",
+			runnerType:  "missing",
+			setup:       greetSetup(okFn),
+			errContains: `runner "missing" is not defined`,
+		},
+		{
+			name:       "handler not registered",
+			runnerType: "greet",
+			setup: handlersSetup{
+				manifest: greetManifest,
+				handlers: map[string]*handlers.RegisteredHandler{},
+			},
+			errContains: `handler "OnRunGreet" for runner "greet" is not registered`,
+		},
+		{
+			name:        "input without matching field",
+			runnerType:  "greet",
+			setup:       greetSetup(okFn),
+			inputs:      map[string]cty.Value{"unknown": cty.True},
+			errContains: `input "unknown" has no matching field`,
+		},
+		{
+			name:       "handler error",
+			runnerType: "greet",
+			setup: greetSetup(func(ctx context.Context, deps *greetDeps, input *greetInput) (*greetOutput, error) {
+				return nil, boom
+			}),
+			errIs: boom,
+		},
+		{
+			name:       "undeclared output",
+			runnerType: "greet",
+			setup: greetSetup(func(ctx context.Context, deps *greetDeps, input *greetInput) (map[string]any, error) {
+				return map[string]any{"message": "hi", "length": 2, "extra": true}, nil
+			}),
+			errContains: "undeclared output(s): extra",
+		},
+		{
+			name:       "handler panic",
+			runnerType: "greet",
+			setup: greetSetup(func(ctx context.Context, deps *greetDeps, input *greetInput) (*greetOutput, error) {
+				panic("kaboom")
+			}),
+			errContains: "panic during execution: kaboom",
+		},
+		{
+			name:        "bad signature",
+			runnerType:  "greet",
+			setup:       greetSetup(func(input *greetInput) error { return nil }),
+			errContains: "want func(context.Context, deps, input) (output, error)",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err, _ := runSingle(t, tc.runnerType, tc.setup, tc.inputs)
+			require.Error(t, err)
+
+			var runErr *RunError
+			require.ErrorAs(t, err, &runErr)
+			require.Len(t, runErr.Failures, 1)
+			if tc.errContains != "" {
+				assert.Contains(t, err.Error(), tc.errContains)
+			}
+			if tc.errIs != nil {
+				assert.ErrorIs(t, err, tc.errIs)
+			}
+		})
+	}
+}
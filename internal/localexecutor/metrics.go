@@ -0,0 +1,24 @@
+package localexecutor
+
+// Metrics summarizes what the executor observed during a run.
+type Metrics struct {
+	// Rates holds the observed dispatch rate of every step with a `rate_limit`
+	// block: one entry for the step as a whole, followed by one entry per
+	// evaluated `key` when the block sets one.
+	Rates []RateMetric
+}
+
+// RateMetric is the observed dispatch rate of a rate-limited step or key.
+type RateMetric struct {
+	Step       string  // step address, e.g. "step.http.call"
+	Key        string  // evaluated `key`; empty for the step total
+	Keyed      bool    // whether this entry is for a single key
+	Dispatches int     // instances dispatched to a worker
+	PerSecond  float64 // dispatches per second between the first and last; 0 if fewer than two
+}
+
+// Metrics returns the metrics of the last run. It is safe to call while a
+// run is in progress.
+func (e *Executor) Metrics() Metrics {
+	return Metrics{Rates: e.limiter.rates()}
+}
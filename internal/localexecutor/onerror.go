@@ -0,0 +1,171 @@
+package localexecutor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/specialistvlad/burstgridgo/internal/bggoexpr"
+	"github.com/specialistvlad/burstgridgo/internal/ctxlog"
+	"github.com/specialistvlad/burstgridgo/internal/node"
+	"github.com/specialistvlad/burstgridgo/internal/nodeid"
+	"github.com/specialistvlad/burstgridgo/internal/scheduler"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// Actions accepted by an `on_error` block's `action` attribute.
+const (
+	actionFail           = "fail"
+	actionContinue       = "continue"
+	actionSkipDependents = "skip_dependents"
+	actionAbortRun       = "abort_run"
+)
+
+// failurePolicy is the evaluated `on_error` block of a node.
+type failurePolicy struct {
+	action   string
+	fallback cty.Value
+}
+
+// onError evaluates the `on_error` block of n. Without a block, or when the
+// run itself is being cancelled, the action is "fail". The action defaults to
+// "continue" when a `fallback` is given, and the fallback defaults to null.
+func (e *Executor) onError(ctx context.Context, n *node.Node) (failurePolicy, error) {
+	policy := failurePolicy{action: actionFail, fallback: cty.NullVal(cty.DynamicPseudoType)}
+	if n.Config == nil || n.Config.OnError == nil || ctx.Err() != nil {
+		return policy, nil
+	}
+	oe := n.Config.OnError
+	evalCtx, err := e.builder.EvalContext(ctx, n, e.graph)
+	if err != nil {
+		return policy, fmt.Errorf("failed to build evaluation context: %w", err)
+	}
+
+	var diags hcl.Diagnostics
+	if oe.Fallback != nil {
+		val, d := oe.Fallback.Value(evalCtx)
+		diags = append(diags, d...)
+		if !d.HasErrors() && !val.IsNull() {
+			policy.action = actionContinue
+			policy.fallback = val
+		}
+	}
+
+	action, ok, d := bggoexpr.EvalString(oe.Action, evalCtx, "action")
+	diags = append(diags, d...)
+	if ok {
+		switch action {
+		case actionFail, actionContinue, actionSkipDependents, actionAbortRun:
+			policy.action = action
+		default:
+			diags = diags.Append(&hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid action value",
+				Detail: fmt.Sprintf("Inappropriate value: must be %q, %q, %q or %q, got %q.",
+					actionFail, actionContinue, actionSkipDependents, actionAbortRun, action),
+				Subject: oe.Action.Range().Ptr(),
+			})
+		}
+	}
+
+	if diags.HasErrors() {
+		return failurePolicy{action: actionFail}, fmt.Errorf("invalid on_error: %w", diags)
+	}
+	return policy, nil
+}
+
+// fail handles a node failure according to the step's `on_error` action:
+//
+//   - "fail" records the failure in the graph and in the run report; the
+//     node's dependents never run
+//   - "continue" completes the node with its `fallback` output so that its
+//     dependents run as if it had succeeded
+//   - "skip_dependents" records the failure in the graph and marks every
+//     transitive dependent Skipped, without failing the run
+//   - "abort_run" records the failure and cancels the rest of the run
+func (e *Executor) fail(ctx context.Context, n *node.Node, err error) {
+	logger := ctxlog.FromContext(ctx).With("node", n.ID.String())
+	policy, policyErr := e.onError(ctx, n)
+	if policyErr != nil {
+		err = errors.Join(err, policyErr)
+	}
+
+	switch policy.action {
+	case actionContinue:
+		markErr := e.complete(ctx, n, policy.fallback)
+		if markErr == nil {
+			logger.Warn("Node failed, continuing with its fallback output.", "error", err)
+			return
+		}
+		logger.Warn("Failed to record fallback output.", "error", markErr)
+	case actionSkipDependents:
+		logger.Warn("Node failed, skipping its dependents.", "error", err)
+		e.markFailed(ctx, n, err)
+		e.skipDependents(ctx, n.ID)
+		return
+	case actionAbortRun:
+		logger.Error("Node failed, aborting the run.", "error", err)
+		e.markFailed(ctx, n, err)
+		e.report(ctx, n, err)
+		e.abort(fmt.Errorf("%w: %s", errAborted, n.ID.String()))
+		return
+	}
+
+	logger.Error("Node failed.", "error", err)
+	e.markFailed(ctx, n, err)
+	e.report(ctx, n, err)
+}
+
+// markFailed records a node failure in the graph and fails the nodes
+// waiting on its dedupe key.
+func (e *Executor) markFailed(ctx context.Context, n *node.Node, err error) {
+	if markErr := e.graph.MarkFailed(ctx, n.ID, err); markErr != nil {
+		ctxlog.FromContext(ctx).Warn("Failed to record node failure.", "node", n.ID.String(), "error", markErr)
+	}
+	e.land(n, cty.NilVal, err)
+}
+
+// report adds a node failure to the executor's run report.
+func (e *Executor) report(ctx context.Context, n *node.Node, err error) {
+	attempts, _ := e.graph.NodeAttempts(ctx, n.ID)
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.failures = append(e.failures, &NodeError{Node: n.ID, Err: err, Attempts: attempts})
+}
+
+// skipDependents marks every pending node that transitively depends on id
+// Skipped. A dependent whose step sets `continue_on_failure` is left to run,
+// and the walk does not continue past it.
+func (e *Executor) skipDependents(ctx context.Context, id nodeid.Address) {
+	logger := ctxlog.FromContext(ctx)
+	queue := []nodeid.Address{id}
+	seen := map[string]struct{}{id.String(): {}}
+	for len(queue) > 0 {
+		dependents, err := e.graph.DependentsOf(ctx, queue[0])
+		queue = queue[1:]
+		if err != nil {
+			logger.Warn("Failed to resolve node dependents.", "node", id.String(), "error", err)
+			continue
+		}
+		for _, d := range dependents {
+			key := d.ID.String()
+			if _, ok := seen[key]; ok {
+				continue
+			}
+			seen[key] = struct{}{}
+			if scheduler.ContinuesOnFailure(d) {
+				continue
+			}
+			if status, _ := e.graph.NodeStatus(ctx, d.ID); status != node.StatusPending {
+				continue
+			}
+			if err := e.graph.MarkSkipped(ctx, d.ID); err != nil {
+				logger.Warn("Failed to skip node.", "node", key, "error", err)
+				continue
+			}
+			logger.Debug("Node skipped.", "node", key, "because", id.String())
+			queue = append(queue, d.ID)
+		}
+	}
+}
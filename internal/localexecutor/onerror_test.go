@@ -0,0 +1,238 @@
+package localexecutor
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/specialistvlad/burstgridgo/internal/builder"
+	"github.com/specialistvlad/burstgridgo/internal/handlers"
+	"github.com/specialistvlad/burstgridgo/internal/node"
+	"github.com/specialistvlad/burstgridgo/internal/registry"
+	"github.com/specialistvlad/burstgridgo/internal/scheduler"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const onErrorManifest = `
+runner "broken" {
+  output "body" {
+    type = string
+  }
+  lifecycle {
+    on_run = "OnRunBroken"
+  }
+}
+
+runner "consumer" {
+  input "value" {
+    type    = string
+    default = "none"
+  }
+  input "ms" {
+    type    = number
+    default = 0
+  }
+  lifecycle {
+    on_run = "OnRunConsumer"
+  }
+}
+`
+
+var errBroken = errors.New("upstream unavailable")
+
+// consumed records the values the consumer runner received.
+type consumed struct {
+	mu     sync.Mutex
+	values []string
+	calls  atomic.Int32
+}
+
+func onErrorRegistry(t *testing.T, c *consumed) *registry.Registry {
+	return newRegistry(t, onErrorManifest, onErrorHandlers(c))
+}
+
+// onErrorHandlers returns handlers for the runners of onErrorManifest.
+func onErrorHandlers(c *consumed) map[string]*handlers.RegisteredHandler {
+	type consumerIn struct {
+		Value string `bggo:"value"`
+		Ms    int    `bggo:"ms"`
+	}
+	return map[string]*handlers.RegisteredHandler{
+		"OnRunBroken": {
+			Input: func() any { return new(struct{}) },
+			Fn: func(ctx context.Context, deps any, input *struct{}) (any, error) {
+				return nil, errBroken
+			},
+		},
+		"OnRunConsumer": {
+			Input: func() any { return new(consumerIn) },
+			Fn: func(ctx context.Context, deps any, input *consumerIn) (any, error) {
+				c.calls.Add(1)
+				select {
+				case <-time.After(time.Duration(input.Ms) * time.Millisecond):
+				case <-ctx.Done():
+					return nil, ctx.Err()
+				}
+				c.mu.Lock()
+				defer c.mu.Unlock()
+				c.values = append(c.values, input.Value)
+				return nil, nil
+			},
+		},
+	}
+}
+
+func TestOnError_Actions(t *testing.T) {
+	testCases := []struct {
+		name        string
+		onError     string
+		wantErr     bool
+		wantBroken  node.Status
+		wantUse     node.Status
+		wantValues  []string
+		wantStalled bool
+	}{
+		{
+			name:        "default fails and stalls dependents",
+			wantErr:     true,
+			wantBroken:  node.StatusFailed,
+			wantUse:     node.StatusPending,
+			wantStalled: true,
+		},
+		{
+			name:        "explicit fail",
+			onError:     `action = "fail"`,
+			wantErr:     true,
+			wantBroken:  node.StatusFailed,
+			wantUse:     node.StatusPending,
+			wantStalled: true,
+		},
+		{
+			name:       "fallback is passed downstream",
+			onError:    `fallback = { body = "cached" }`,
+			wantBroken: node.StatusCompleted,
+			wantUse:    node.StatusCompleted,
+			wantValues: []string{"cached"},
+		},
+		{
+			name: "continue with fallback",
+			onError: `
+				action   = "continue"
+				fallback = { body = "cached" }`,
+			wantBroken: node.StatusCompleted,
+			wantUse:    node.StatusCompleted,
+			wantValues: []string{"cached"},
+		},
+		{
+			name:       "skip_dependents",
+			onError:    `action = "skip_dependents"`,
+			wantBroken: node.StatusFailed,
+			wantUse:    node.StatusSkipped,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var c consumed
+			reg := onErrorRegistry(t, &c)
+			g := compileGraph(t, `
+				step "broken" "api" {
+					on_error {
+						`+tc.onError+`
+					}
+				}
+				step "consumer" "use" {
+					arguments {
+						value = step.broken.api.output.body
+					}
+				}
+				step "consumer" "after" {
+					depends_on = [step.consumer.use]
+				}
+				step "consumer" "independent" {
+					arguments {
+						value = "independent"
+					}
+				}
+			`)
+
+			err := New(scheduler.New(g), g, builder.New(reg), reg, 2).Execute(testContext())
+			if tc.wantErr {
+				var runErr *RunError
+				require.ErrorAs(t, err, &runErr)
+				assert.ErrorIs(t, err, errBroken)
+				assert.False(t, runErr.Aborted)
+				assert.Equal(t, tc.wantStalled, len(runErr.Stalled) > 0)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, tc.wantBroken, status(t, g, "step.broken.api"))
+			assert.Equal(t, tc.wantUse, status(t, g, "step.consumer.use"))
+			if tc.wantUse == node.StatusSkipped {
+				assert.Equal(t, node.StatusSkipped, status(t, g, "step.consumer.after"), "skips are transitive")
+			}
+			assert.Equal(t, node.StatusCompleted, status(t, g, "step.consumer.independent"))
+			assert.ElementsMatch(t, append([]string{"independent"}, tc.wantValues...), withoutNone(c.values))
+		})
+	}
+}
+
+// withoutNone drops the values of consumers that received no argument.
+func withoutNone(values []string) []string {
+	var out []string
+	for _, v := range values {
+		if v != "none" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+func TestOnError_AbortRun(t *testing.T) {
+	var c consumed
+	reg := onErrorRegistry(t, &c)
+	g := compileGraph(t, `
+		step "broken" "api" {
+			on_error {
+				action = "abort_run"
+			}
+		}
+		step "consumer" "slow" {
+			arguments {
+				ms = 5000
+			}
+		}
+	`)
+
+	start := time.Now()
+	err := New(scheduler.New(g), g, builder.New(reg), reg, 2).Execute(testContext())
+	assert.Less(t, time.Since(start), time.Second, "the abort must cancel running nodes")
+
+	var runErr *RunError
+	require.ErrorAs(t, err, &runErr)
+	assert.True(t, runErr.Aborted)
+	assert.ErrorIs(t, err, errBroken)
+	assert.Contains(t, err.Error(), "run aborted")
+	assert.Equal(t, node.StatusFailed, status(t, g, "step.broken.api"))
+	assert.NotEqual(t, node.StatusCompleted, status(t, g, "step.consumer.slow"))
+}
+
+func TestOnError_InvalidAction(t *testing.T) {
+	var c consumed
+	reg := onErrorRegistry(t, &c)
+	g := compileGraph(t, `
+		step "broken" "api" {
+			on_error {
+				action = "ignore"
+			}
+		}
+	`)
+	err := New(scheduler.New(g), g, builder.New(reg), reg, 1).Execute(testContext())
+	require.Error(t, err)
+	assert.ErrorIs(t, err, errBroken)
+	assert.Contains(t, err.Error(), "Invalid action value")
+	assert.Equal(t, node.StatusFailed, status(t, g, "step.broken.api"))
+}
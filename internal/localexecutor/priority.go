@@ -0,0 +1,40 @@
+package localexecutor
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/specialistvlad/burstgridgo/internal/bggoexpr"
+	"github.com/specialistvlad/burstgridgo/internal/builder"
+	"github.com/specialistvlad/burstgridgo/internal/graph"
+	"github.com/specialistvlad/burstgridgo/internal/model"
+	"github.com/specialistvlad/burstgridgo/internal/node"
+	"github.com/specialistvlad/burstgridgo/internal/scheduler"
+)
+
+// evalPriority evaluates a step's `priority` attribute; unset means 0.
+func evalPriority(step *model.Step, evalCtx *hcl.EvalContext) (int, hcl.Diagnostics) {
+	priority, _, diags := bggoexpr.EvalInt(step.Priority, evalCtx, "priority")
+	return priority, diags
+}
+
+// Priority returns a scheduler.PriorityFunc that evaluates each ready node's
+// `priority` attribute in the same evaluation context the builder uses, so
+// it may reference `count.index`, `each` and upstream outputs.
+func Priority(b builder.Builder, g graph.Graph) scheduler.PriorityFunc {
+	return func(ctx context.Context, n *node.Node) (int, error) {
+		if n.Config == nil || n.Config.Priority == nil || n.Placeholder {
+			return 0, nil
+		}
+		evalCtx, err := b.EvalContext(ctx, n, g)
+		if err != nil {
+			return 0, fmt.Errorf("failed to build evaluation context: %w", err)
+		}
+		priority, diags := evalPriority(n.Config, evalCtx)
+		if diags.HasErrors() {
+			return 0, diags
+		}
+		return priority, nil
+	}
+}
@@ -0,0 +1,141 @@
+package localexecutor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/specialistvlad/burstgridgo/internal/builder"
+	"github.com/specialistvlad/burstgridgo/internal/scheduler"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPriority_OrdersDispatch(t *testing.T) {
+	var c consumed
+	reg := onErrorRegistry(t, &c)
+	g := compileGraph(t, `
+		step "consumer" "low" {
+			priority = 1
+			arguments {
+				value = "low"
+			}
+		}
+		step "consumer" "high" {
+			priority = 10
+			arguments {
+				value = "high"
+			}
+		}
+		step "consumer" "none" {
+			arguments {
+				value = "none-set"
+			}
+		}
+		step "consumer" "items" {
+			count    = 2
+			priority = 5 - count.index
+			arguments {
+				value = "item${count.index}"
+			}
+		}
+	`)
+	b := builder.New(reg)
+	sch := scheduler.New(g, scheduler.WithPriority(Priority(b, g)))
+
+	require.NoError(t, New(sch, g, b, reg, 1).Execute(testContext()))
+	assert.Equal(t, []string{"high", "item0", "item1", "low", "none-set"}, c.values)
+}
+
+func TestDelays(t *testing.T) {
+	testCases := []struct {
+		name string
+		grid string
+		want []string
+	}{
+		{
+			name: "delay_before does not hold a worker",
+			grid: `
+				step "consumer" "later" {
+					delay_before = "50ms"
+					arguments {
+						value = "later"
+					}
+				}
+				step "consumer" "now" {
+					arguments {
+						value = "now"
+					}
+				}
+			`,
+			want: []string{"now", "later"},
+		},
+		{
+			name: "delay_after holds back dependents only",
+			grid: `
+				step "consumer" "first" {
+					delay_after = "50ms"
+					arguments {
+						value = "first"
+					}
+				}
+				step "consumer" "dependent" {
+					depends_on = [step.consumer.first]
+					arguments {
+						value = "dependent"
+					}
+				}
+				step "consumer" "other" {
+					delay_before = "10ms"
+					arguments {
+						value = "other"
+					}
+				}
+			`,
+			want: []string{"first", "other", "dependent"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var c consumed
+			reg := onErrorRegistry(t, &c)
+			g := compileGraph(t, tc.grid)
+
+			start := time.Now()
+			require.NoError(t, New(scheduler.New(g), g, builder.New(reg), reg, 1).Execute(testContext()))
+			assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+			assert.Equal(t, tc.want, c.values)
+		})
+	}
+}
+
+func TestDelays_CancelledRun(t *testing.T) {
+	var c consumed
+	reg := onErrorRegistry(t, &c)
+	g := compileGraph(t, `
+		step "consumer" "slow" {
+			delay_before = "1h"
+		}
+	`)
+	ctx, cancel := context.WithTimeout(testContext(), 20*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err := New(scheduler.New(g), g, builder.New(reg), reg, 1).Execute(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Empty(t, c.values)
+}
+
+func TestDelays_InvalidValue(t *testing.T) {
+	var c consumed
+	reg := onErrorRegistry(t, &c)
+	g := compileGraph(t, `
+		step "consumer" "bad" {
+			delay_after = "later"
+		}
+	`)
+	err := New(scheduler.New(g), g, builder.New(reg), reg, 1).Execute(testContext())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Invalid delay_after value")
+}
@@ -0,0 +1,214 @@
+package localexecutor
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/specialistvlad/burstgridgo/internal/bggoexpr"
+	"github.com/specialistvlad/burstgridgo/internal/model"
+	"github.com/specialistvlad/burstgridgo/internal/node"
+)
+
+// defaultPer is the `rate_limit` period used when `per` is not set.
+const defaultPer = time.Second
+
+// limiter paces the dispatch of steps with a `rate_limit` block through token
+// buckets: one per step, or one per step and evaluated `key`.
+type limiter struct {
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+	pending map[string]rateSpec // node ID -> bucket it must take a token from
+	now     func() time.Time
+}
+
+// rateSpec is the evaluated `rate_limit` block of a node.
+type rateSpec struct {
+	step  string
+	key   string
+	keyed bool
+	rate  float64 // tokens per second
+	burst float64
+}
+
+// tokenBucket hands out tokens at a fixed rate up to burst. Tokens may go
+// negative: a dispatch that finds none reserves the next one and waits for it.
+type tokenBucket struct {
+	spec   rateSpec
+	tokens float64
+	last   time.Time
+
+	dispatches int
+	first      time.Time
+	latest     time.Time
+}
+
+func newLimiter() *limiter {
+	return &limiter{
+		buckets: make(map[string]*tokenBucket),
+		pending: make(map[string]rateSpec),
+		now:     time.Now,
+	}
+}
+
+// plan records that n must take a token from the bucket described by spec
+// before it is dispatched.
+func (l *limiter) plan(n *node.Node, spec rateSpec) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.pending[n.ID.String()] = spec
+}
+
+// reserve takes a token for n and returns how long n must wait before it is
+// dispatched. Nodes without a `rate_limit` never wait.
+func (l *limiter) reserve(n *node.Node) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	id := n.ID.String()
+	spec, ok := l.pending[id]
+	if !ok {
+		return 0
+	}
+	delete(l.pending, id)
+
+	name := spec.step
+	if spec.keyed {
+		name += "\x00" + spec.key
+	}
+	now := l.now()
+	b := l.buckets[name]
+	if b == nil {
+		b = &tokenBucket{spec: spec, tokens: spec.burst, last: now}
+		l.buckets[name] = b
+	}
+	wait := b.take(now)
+	b.record(now.Add(wait))
+	return wait
+}
+
+// take refills the bucket up to now and takes one token, returning how long
+// to wait for it.
+func (b *tokenBucket) take(now time.Time) time.Duration {
+	b.tokens = min(b.spec.burst, b.tokens+now.Sub(b.last).Seconds()*b.spec.rate)
+	b.last = now
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.spec.rate * float64(time.Second))
+}
+
+// record counts a dispatch scheduled for at.
+func (b *tokenBucket) record(at time.Time) {
+	if b.dispatches == 0 || at.Before(b.first) {
+		b.first = at
+	}
+	if at.After(b.latest) {
+		b.latest = at
+	}
+	b.dispatches++
+}
+
+// rates reports the observed dispatch rate of every rate-limited step, and of
+// every key of the steps that set one.
+func (l *limiter) rates() []RateMetric {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	steps := make(map[string]*tokenBucket)
+	var out []RateMetric
+	for _, b := range l.buckets {
+		total := steps[b.spec.step]
+		if total == nil {
+			total = &tokenBucket{spec: rateSpec{step: b.spec.step}}
+			steps[b.spec.step] = total
+		}
+		if total.dispatches == 0 || b.first.Before(total.first) {
+			total.first = b.first
+		}
+		if b.latest.After(total.latest) {
+			total.latest = b.latest
+		}
+		total.dispatches += b.dispatches
+		if b.spec.keyed {
+			out = append(out, b.metric())
+		}
+	}
+	for _, total := range steps {
+		out = append(out, total.metric())
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Step != out[j].Step {
+			return out[i].Step < out[j].Step
+		}
+		if out[i].Keyed != out[j].Keyed {
+			return !out[i].Keyed
+		}
+		return out[i].Key < out[j].Key
+	})
+	return out
+}
+
+func (b *tokenBucket) metric() RateMetric {
+	m := RateMetric{Step: b.spec.step, Key: b.spec.key, Keyed: b.spec.keyed, Dispatches: b.dispatches}
+	if span := b.latest.Sub(b.first); b.dispatches > 1 && span > 0 {
+		m.PerSecond = float64(b.dispatches-1) / span.Seconds()
+	}
+	return m
+}
+
+// evalRateSpec evaluates a step's `rate_limit` block. `limit` tokens are added
+// every `per` (default one second); `burst` caps how many may be saved up and
+// defaults to 1, which spaces dispatches evenly.
+func evalRateSpec(stepName string, rl *model.RateLimit, evalCtx *hcl.EvalContext) (rateSpec, error) {
+	var diags, d hcl.Diagnostics
+	spec := rateSpec{step: stepName, burst: 1}
+
+	limit, hasLimit, d := bggoexpr.EvalNumber(rl.Limit, evalCtx, "limit")
+	diags = append(diags, d...)
+	if !d.HasErrors() && (!hasLimit || limit <= 0) {
+		diags = diags.Append(&hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Invalid limit value",
+			Detail:   "Inappropriate value: a rate_limit block requires a positive limit.",
+			Subject:  rl.Limit.Range().Ptr(),
+		})
+	}
+
+	per, hasPer, d := bggoexpr.EvalDuration(rl.Per, evalCtx, "per")
+	diags = append(diags, d...)
+	if !hasPer {
+		per = defaultPer
+	} else if !d.HasErrors() && per <= 0 {
+		diags = diags.Append(&hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Invalid per value",
+			Detail:   "Inappropriate value: must be a positive duration.",
+			Subject:  rl.Per.Range().Ptr(),
+		})
+	}
+
+	burst, hasBurst, d := bggoexpr.EvalInt(rl.Burst, evalCtx, "burst")
+	diags = append(diags, d...)
+	if hasBurst {
+		if burst < 1 && !d.HasErrors() {
+			diags = diags.Append(&hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid burst value",
+				Detail:   fmt.Sprintf("Inappropriate value: must be at least 1, got %d.", burst),
+				Subject:  rl.Burst.Range().Ptr(),
+			})
+		}
+		spec.burst = float64(burst)
+	}
+
+	spec.key, spec.keyed, d = bggoexpr.EvalString(rl.Key, evalCtx, "key")
+	diags = append(diags, d...)
+
+	if diags.HasErrors() {
+		return rateSpec{}, fmt.Errorf("invalid rate_limit: %w", diags)
+	}
+	spec.rate = limit / per.Seconds()
+	return spec, nil
+}
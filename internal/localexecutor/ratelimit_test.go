@@ -0,0 +1,118 @@
+package localexecutor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/specialistvlad/burstgridgo/internal/builder"
+	"github.com/specialistvlad/burstgridgo/internal/scheduler"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenBucket_Take(t *testing.T) {
+	start := time.Unix(0, 0)
+	b := &tokenBucket{spec: rateSpec{rate: 10, burst: 2}, tokens: 2, last: start}
+
+	assert.Zero(t, b.take(start), "the burst is available immediately")
+	assert.Zero(t, b.take(start))
+	assert.Equal(t, 100*time.Millisecond, b.take(start), "the next token arrives after 1/rate")
+	assert.Equal(t, 200*time.Millisecond, b.take(start), "reservations queue up")
+	assert.Zero(t, b.take(start.Add(time.Second)), "the bucket refills over time")
+}
+
+func runRateLimited(t *testing.T, src string) (*Executor, *tracker, time.Duration) {
+	t.Helper()
+	tr := newTracker()
+	reg := limitedRegistry(t, tr)
+	g := compileGraph(t, src)
+	e := New(scheduler.New(g), g, builder.New(reg), reg, 8).(*Executor)
+	start := time.Now()
+	require.NoError(t, e.Execute(testContext()))
+	return e, tr, time.Since(start)
+}
+
+func TestRateLimit_PacesDispatches(t *testing.T) {
+	e, tr, took := runRateLimited(t, `
+		step "limited" "work" {
+			count = 5
+			arguments {
+				index = count.index
+			}
+			rate_limit {
+				limit = 50
+				per   = "1s"
+			}
+		}
+	`)
+	assert.Len(t, tr.started, 5)
+	assert.GreaterOrEqual(t, took, 80*time.Millisecond, "five dispatches at 50/s span at least 80ms")
+
+	rates := e.Metrics().Rates
+	require.Len(t, rates, 1)
+	assert.Equal(t, "step.limited.work", rates[0].Step)
+	assert.False(t, rates[0].Keyed)
+	assert.Equal(t, 5, rates[0].Dispatches)
+	assert.InDelta(t, 50, rates[0].PerSecond, 1)
+}
+
+func TestRateLimit_Burst(t *testing.T) {
+	_, tr, took := runRateLimited(t, `
+		step "limited" "work" {
+			count = 4
+			rate_limit {
+				limit = 1
+				per   = "1h"
+				burst = 4
+			}
+		}
+	`)
+	assert.Len(t, tr.started, 4)
+	assert.Less(t, took, time.Second, "a full bucket dispatches its burst at once")
+}
+
+func TestRateLimit_PerKey(t *testing.T) {
+	e, tr, _ := runRateLimited(t, `
+		step "limited" "work" {
+			for_each = {
+				a1 = "a"
+				a2 = "a"
+				a3 = "a"
+				b1 = "b"
+			}
+			arguments {
+				key = each.value
+			}
+			rate_limit {
+				limit = 40
+				key   = each.value
+			}
+		}
+	`)
+	assert.Len(t, tr.started, 4)
+
+	rates := e.Metrics().Rates
+	require.Len(t, rates, 3)
+	assert.Equal(t, RateMetric{Step: "step.limited.work", Dispatches: 4, PerSecond: rates[0].PerSecond}, rates[0])
+	assert.Equal(t, "a", rates[1].Key)
+	assert.True(t, rates[1].Keyed)
+	assert.Equal(t, 3, rates[1].Dispatches)
+	assert.InDelta(t, 40, rates[1].PerSecond, 1)
+	assert.Equal(t, RateMetric{Step: "step.limited.work", Key: "b", Keyed: true, Dispatches: 1}, rates[2])
+}
+
+func TestRateLimit_InvalidValue(t *testing.T) {
+	tr := newTracker()
+	reg := limitedRegistry(t, tr)
+	g := compileGraph(t, `
+		step "limited" "bad" {
+			rate_limit {
+				per = "1s"
+			}
+		}
+	`)
+	err := New(scheduler.New(g), g, builder.New(reg), reg, 1).Execute(testContext())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Invalid limit value")
+	assert.Empty(t, tr.started)
+}
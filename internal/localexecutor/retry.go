@@ -0,0 +1,67 @@
+package localexecutor
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/specialistvlad/burstgridgo/internal/ctxlog"
+	"github.com/specialistvlad/burstgridgo/internal/handlers"
+	"github.com/specialistvlad/burstgridgo/internal/retry"
+	"github.com/specialistvlad/burstgridgo/internal/task"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// runWithRetry runs a built task, re-running it after a failure for as long
+// as the step's `retry` policy allows. Every attempt is recorded in the graph.
+// The error of the last attempt is returned when the task never succeeds.
+func (e *Executor) runWithRetry(ctx context.Context, t *task.Task, lim limits) (cty.Value, error) {
+	logger := ctxlog.FromContext(ctx)
+	policy := retry.None
+	if step := t.Node.Config; step != nil && step.Retry != nil {
+		p, diags := retry.Evaluate(step.Retry, t.EvalContext)
+		if diags.HasErrors() {
+			return cty.NilVal, fmt.Errorf("invalid retry policy: %w", diags)
+		}
+		policy = p
+	}
+
+	start := time.Now()
+	var delay time.Duration
+	for attempt := 1; ; attempt++ {
+		if err := e.graph.RecordAttempt(ctx, t.Node.ID, attempt); err != nil {
+			logger.Warn("Failed to record attempt.", "attempt", attempt, "error", err)
+		}
+		output, err := e.attempt(ctx, t, lim)
+		if err == nil {
+			return output, nil
+		}
+		if attempt >= policy.Attempts || !policy.Retryable(err) || ctx.Err() != nil {
+			return cty.NilVal, err
+		}
+
+		delay = policy.Backoff.Delay(attempt, delay, rand.Float64)
+		if policy.MaxDuration > 0 && time.Since(start)+delay >= policy.MaxDuration {
+			logger.Debug("Retry budget exhausted.", "attempt", attempt, "max_duration", policy.MaxDuration)
+			return cty.NilVal, err
+		}
+		logger.Warn("Attempt failed, retrying.",
+			"attempt", attempt, "attempts", policy.Attempts, "class", handlers.ErrorClass(err), "delay", delay, "error", err)
+		if !sleep(ctx, delay) {
+			return cty.NilVal, err
+		}
+	}
+}
+
+// sleep waits for d and reports false if ctx was cancelled first.
+func sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,174 @@
+package localexecutor
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+
+	"github.com/specialistvlad/burstgridgo/internal/builder"
+	"github.com/specialistvlad/burstgridgo/internal/graph"
+	"github.com/specialistvlad/burstgridgo/internal/handlers"
+	"github.com/specialistvlad/burstgridgo/internal/node"
+	"github.com/specialistvlad/burstgridgo/internal/nodeid"
+	"github.com/specialistvlad/burstgridgo/internal/scheduler"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const flakyManifest = `
+runner "flaky" {
+  lifecycle {
+    on_run = "OnRunFlaky"
+  }
+}
+`
+
+// fastBackoff keeps retry tests quick.
+const fastBackoff = `
+backoff {
+  strategy = "constant"
+  initial  = "1ms"
+}
+`
+
+// flakySetup registers a handler that fails with err on its first failures
+// calls and succeeds afterwards.
+func flakySetup(failures int32, err error, calls *atomic.Int32) handlersSetup {
+	return handlersSetup{
+		manifest: flakyManifest,
+		handlers: map[string]*handlers.RegisteredHandler{
+			"OnRunFlaky": {
+				Input: func() any { return new(struct{}) },
+				Fn: func(ctx context.Context, deps any, input *struct{}) (any, error) {
+					if calls.Add(1) <= failures {
+						return nil, err
+					}
+					return nil, nil
+				},
+			},
+		},
+	}
+}
+
+func attemptsOf(t *testing.T, g graph.Graph, id string) int {
+	t.Helper()
+	addr, err := nodeid.Parse(id)
+	require.NoError(t, err)
+	n, err := g.NodeAttempts(testContext(), *addr)
+	require.NoError(t, err)
+	return n
+}
+
+func TestExecutor_Retry(t *testing.T) {
+	errUnavailable := handlers.Classify("http_5xx", errors.New("service unavailable"))
+	errNotFound := handlers.Classify("http_4xx", errors.New("not found"))
+
+	testCases := []struct {
+		name         string
+		retry        string
+		failures     int32
+		err          error
+		wantAttempts int
+		wantFailed   bool
+	}{
+		{
+			name:         "succeeds after retries",
+			retry:        `attempts = 3` + fastBackoff,
+			failures:     2,
+			err:          errUnavailable,
+			wantAttempts: 3,
+		},
+		{
+			name:         "gives up after the last attempt",
+			retry:        `attempts = 2` + fastBackoff,
+			failures:     5,
+			err:          errUnavailable,
+			wantAttempts: 2,
+			wantFailed:   true,
+		},
+		{
+			name: "abort_on stops immediately",
+			retry: `
+				attempts = 5
+				abort_on = ["http_4xx"]` + fastBackoff,
+			failures:     5,
+			err:          errNotFound,
+			wantAttempts: 1,
+			wantFailed:   true,
+		},
+		{
+			name: "retry_on skips other classes",
+			retry: `
+				attempts = 5
+				retry_on = ["http_5xx"]` + fastBackoff,
+			failures:     5,
+			err:          errNotFound,
+			wantAttempts: 1,
+			wantFailed:   true,
+		},
+		{
+			name: "max_duration bounds the retries",
+			retry: `
+				attempts     = 5
+				max_duration = "50ms"
+				backoff {
+					strategy = "constant"
+					initial  = "1h"
+				}`,
+			failures:     5,
+			err:          errUnavailable,
+			wantAttempts: 1,
+			wantFailed:   true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var calls atomic.Int32
+			setup := flakySetup(tc.failures, tc.err, &calls)
+			reg := newRegistry(t, setup.manifest, setup.handlers)
+			g := compileGraph(t, `
+				step "flaky" "call" {
+					retry {
+						`+tc.retry+`
+					}
+				}
+			`)
+
+			err := New(scheduler.New(g), g, builder.New(reg), reg, 1).Execute(testContext())
+			assert.Equal(t, int32(tc.wantAttempts), calls.Load())
+			assert.Equal(t, tc.wantAttempts, attemptsOf(t, g, "step.flaky.call"))
+			if !tc.wantFailed {
+				require.NoError(t, err)
+				assert.Equal(t, node.StatusCompleted, status(t, g, "step.flaky.call"))
+				return
+			}
+			var runErr *RunError
+			require.ErrorAs(t, err, &runErr)
+			require.Len(t, runErr.Failures, 1)
+			assert.Equal(t, tc.wantAttempts, runErr.Failures[0].Attempts)
+			assert.ErrorIs(t, err, tc.err)
+			assert.Equal(t, node.StatusFailed, status(t, g, "step.flaky.call"))
+		})
+	}
+}
+
+func TestExecutor_RetryReportsAttempts(t *testing.T) {
+	var calls atomic.Int32
+	setup := flakySetup(10, errors.New("boom"), &calls)
+	reg := newRegistry(t, setup.manifest, setup.handlers)
+	g := compileGraph(t, `
+		step "flaky" "call" {
+			retry {
+				attempts = 3
+				backoff {
+					strategy = "exponential"
+					initial  = "1ms"
+				}
+			}
+		}
+	`)
+	err := New(scheduler.New(g), g, builder.New(reg), reg, 1).Execute(testContext())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "step.flaky.call (after 3 attempts): boom")
+}
@@ -0,0 +1,22 @@
+package localexecutor
+
+import (
+	"fmt"
+
+	"github.com/specialistvlad/burstgridgo/internal/bggoexpr"
+	"github.com/specialistvlad/burstgridgo/internal/task"
+)
+
+// evalSensitive evaluates a step's `sensitive` attribute. When it is true the
+// node's output is marked sensitive, and so is everything derived from it.
+func evalSensitive(t *task.Task) (bool, error) {
+	step := t.Node.Config
+	if step == nil || step.Sensitive == nil {
+		return false, nil
+	}
+	sens, _, diags := bggoexpr.EvalBool(*step.Sensitive, t.EvalContext, "sensitive")
+	if diags.HasErrors() {
+		return false, fmt.Errorf("invalid sensitive: %w", diags)
+	}
+	return sens, nil
+}
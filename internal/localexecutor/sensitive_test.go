@@ -0,0 +1,167 @@
+package localexecutor
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/specialistvlad/burstgridgo/internal/builder"
+	"github.com/specialistvlad/burstgridgo/internal/ctxlog"
+	"github.com/specialistvlad/burstgridgo/internal/handlers"
+	"github.com/specialistvlad/burstgridgo/internal/nodeid"
+	"github.com/specialistvlad/burstgridgo/internal/registry"
+	"github.com/specialistvlad/burstgridgo/internal/scheduler"
+	"github.com/specialistvlad/burstgridgo/internal/sensitive"
+	prnt "github.com/specialistvlad/burstgridgo/modules/print"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zclconf/go-cty/cty"
+)
+
+const sensitiveManifest = `
+runner "secret" {
+  output "token" {
+    type = string
+  }
+  lifecycle {
+    on_run = "OnRunSecret"
+  }
+}
+
+runner "leak" {
+  input "value" {
+    type = string
+  }
+  lifecycle {
+    on_run = "OnRunLeak"
+  }
+}
+`
+
+var errRejected = errors.New("rejected")
+
+// sensitiveRegistry registers a runner that returns the token "hunter2" and
+// one that logs its input and fails with it in the error message.
+func sensitiveRegistry(t *testing.T) *registry.Registry {
+	type secretOut struct {
+		Token string `cty:"token"`
+	}
+	type leakIn struct {
+		Value string `bggo:"value"`
+	}
+	return newRegistry(t, sensitiveManifest, map[string]*handlers.RegisteredHandler{
+		"OnRunSecret": {
+			Input: func() any { return new(struct{}) },
+			Fn: func(ctx context.Context, deps any, input *struct{}) (any, error) {
+				return &secretOut{Token: "hunter2"}, nil
+			},
+		},
+		"OnRunLeak": {
+			Input: func() any { return new(leakIn) },
+			Fn: func(ctx context.Context, deps any, input *leakIn) (any, error) {
+				ctxlog.FromContext(ctx).Info("Printing input value", "value", input.Value)
+				return nil, fmt.Errorf("%w: %s", errRejected, input.Value)
+			},
+		},
+	})
+}
+
+func TestSensitive(t *testing.T) {
+	testCases := []struct {
+		name      string
+		sensitive string
+		wantLeak  bool
+	}{
+		{name: "sensitive output is redacted downstream", sensitive: "true"},
+		{name: "plain output is logged as is", sensitive: "false", wantLeak: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			reg := sensitiveRegistry(t)
+			g := compileGraph(t, `
+				step "secret" "src" {
+					sensitive = `+tc.sensitive+`
+				}
+				step "leak" "use" {
+					arguments {
+						value = "Bearer ${step.secret.src.output.token}"
+					}
+				}
+			`)
+			var logs bytes.Buffer
+			ctx := ctxlog.WithLogger(context.Background(), slog.New(slog.NewTextHandler(&logs, nil)))
+
+			err := New(scheduler.New(g), g, builder.New(reg), reg, 1).Execute(ctx)
+			require.Error(t, err)
+			assert.ErrorIs(t, err, errRejected, "redaction keeps the error chain")
+
+			addr, parseErr := nodeid.Parse("step.secret.src")
+			require.NoError(t, parseErr)
+			out, outErr := g.NodeOutput(ctx, *addr)
+			require.NoError(t, outErr)
+			assert.Equal(t, !tc.wantLeak, sensitive.Marked(out.(cty.Value)))
+
+			if tc.wantLeak {
+				assert.Contains(t, logs.String(), "hunter2")
+				assert.Contains(t, err.Error(), "hunter2")
+				return
+			}
+			assert.NotContains(t, logs.String(), "hunter2")
+			assert.Contains(t, logs.String(), `value="`+sensitive.Redacted+`"`, "values derived from a sensitive one are sensitive")
+			assert.NotContains(t, err.Error(), "hunter2")
+			assert.Contains(t, err.Error(), sensitive.Redacted)
+		})
+	}
+}
+
+func TestSensitive_PrintRedactsNonStrings(t *testing.T) {
+	manifest, err := os.ReadFile("../../modules/print/manifest.hcl")
+	require.NoError(t, err)
+	reg := newRegistry(t, string(manifest), map[string]*handlers.RegisteredHandler{
+		"OnRunPrint": {
+			Input:     func() any { return new(prnt.Input) },
+			InputType: reflect.TypeOf(prnt.Input{}),
+			Deps:      func() any { return new(prnt.Deps) },
+			Fn:        prnt.OnRunPrint,
+		},
+	})
+	g := compileGraph(t, `
+		variable "port" {}
+		variable "tls" {}
+		step "print" "port" {
+			arguments {
+				input = tonumber(var.port) + 1
+			}
+		}
+		step "print" "tls" {
+			arguments {
+				input = var.tls
+			}
+		}
+		step "print" "plain" {
+			arguments {
+				input = 8080
+			}
+		}
+	`)
+	vars := cty.ObjectVal(map[string]cty.Value{
+		"port": cty.NumberIntVal(5431).Mark(sensitive.Mark),
+		"tls":  cty.True.Mark(sensitive.Mark),
+	})
+	var logs bytes.Buffer
+	ctx := ctxlog.WithLogger(context.Background(), slog.New(slog.NewTextHandler(&logs, nil)))
+
+	b := builder.New(reg, builder.WithVariables(vars))
+	require.NoError(t, New(scheduler.New(g), g, b, reg, 1).Execute(ctx))
+
+	assert.NotContains(t, logs.String(), "5432")
+	assert.NotContains(t, logs.String(), "value=true")
+	assert.Equal(t, 2, strings.Count(logs.String(), `value="`+sensitive.Redacted+`"`), logs.String())
+	assert.Contains(t, logs.String(), "value=8080", "values that are not sensitive are printed")
+}
@@ -0,0 +1,278 @@
+package localexecutor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/specialistvlad/burstgridgo/internal/bggoexpr"
+	"github.com/specialistvlad/burstgridgo/internal/ctxlog"
+	"github.com/specialistvlad/burstgridgo/internal/model"
+	"github.com/specialistvlad/burstgridgo/internal/node"
+	"github.com/specialistvlad/burstgridgo/internal/task"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// limits is the evaluated form of a step's `timeouts` block. Zero values
+// mean "no limit".
+type limits struct {
+	execution time.Duration
+	start     time.Duration
+	queue     time.Duration
+	deadline  time.Time
+}
+
+// limits evaluates the `timeouts` block of a built task. A `deadline` is
+// either an RFC 3339 timestamp or a duration measured from the start of the run.
+func (e *Executor) limits(t *task.Task) (limits, error) {
+	var lim limits
+	if t.Node.Config == nil || t.Node.Config.Timeouts == nil {
+		return lim, nil
+	}
+	tm := t.Node.Config.Timeouts
+	var diags, d hcl.Diagnostics
+
+	lim.execution, _, d = bggoexpr.EvalDuration(tm.Execution, t.EvalContext, "execution")
+	diags = append(diags, d...)
+	lim.start, _, d = bggoexpr.EvalDuration(tm.Start, t.EvalContext, "start")
+	diags = append(diags, d...)
+	lim.queue, _, d = bggoexpr.EvalDuration(tm.Queue, t.EvalContext, "queue")
+	diags = append(diags, d...)
+
+	if tm.Deadline != nil {
+		val, d := tm.Deadline.Value(t.EvalContext)
+		diags = append(diags, d...)
+		if !d.HasErrors() && !val.IsNull() {
+			at, err := e.deadline(val)
+			if err != nil {
+				diags = diags.Append(&hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Invalid deadline value",
+					Detail:   fmt.Sprintf("Inappropriate value: must be an RFC 3339 timestamp or a duration from the start of the run: %s.", err),
+					Subject:  tm.Deadline.Range().Ptr(),
+				})
+			}
+			lim.deadline = at
+		}
+	}
+
+	if diags.HasErrors() {
+		return limits{}, fmt.Errorf("invalid timeouts: %w", diags)
+	}
+	return lim, nil
+}
+
+// deadline converts a `deadline` value into a wall-clock time.
+func (e *Executor) deadline(val cty.Value) (time.Time, error) {
+	val, _ = val.UnmarkDeep()
+	if val.Type() == cty.String {
+		if at, err := time.Parse(time.RFC3339, val.AsString()); err == nil {
+			return at, nil
+		}
+	}
+	after, err := bggoexpr.ToDuration(val)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return e.started.Add(after), nil
+}
+
+// checkWait enforces the `queue` and `start` timeouts for a node that became
+// ready at readyAt, was received by a worker at received, and is about to run now.
+func (lim limits) checkWait(readyAt, received, now time.Time) error {
+	if readyAt.IsZero() {
+		return nil
+	}
+	if lim.queue > 0 && received.Sub(readyAt) > lim.queue {
+		return &TimeoutError{Kind: TimeoutQueue, Limit: lim.queue}
+	}
+	if lim.start > 0 && now.Sub(readyAt) > lim.start {
+		return &TimeoutError{Kind: TimeoutStart, Limit: lim.start}
+	}
+	return nil
+}
+
+// waitLimit evaluates the `queue` and `start` timeouts of a step and returns
+// whichever runs out first. A zero limit means neither is set.
+func waitLimit(tm *model.Timeouts, evalCtx *hcl.EvalContext) (TimeoutKind, time.Duration, error) {
+	if tm == nil {
+		return "", 0, nil
+	}
+	queue, _, diags := bggoexpr.EvalDuration(tm.Queue, evalCtx, "queue")
+	start, _, d := bggoexpr.EvalDuration(tm.Start, evalCtx, "start")
+	diags = append(diags, d...)
+	if diags.HasErrors() {
+		return "", 0, fmt.Errorf("invalid timeouts: %w", diags)
+	}
+	if queue > 0 && (start == 0 || queue <= start) {
+		return TimeoutQueue, queue, nil
+	}
+	return TimeoutStart, start, nil
+}
+
+// watchdog fails nodes that wait too long between being emitted by the
+// scheduler and being received by a worker, while they are held back by
+// `delay_before`, `rate_limit`, `concurrency` or busy workers.
+type watchdog struct {
+	mu     sync.Mutex
+	timers map[string]*time.Timer // armed timers by node ID
+	failed map[string]struct{}    // nodes failed by their timer, not yet received
+}
+
+func newWatchdog() *watchdog {
+	return &watchdog{
+		timers: make(map[string]*time.Timer),
+		failed: make(map[string]struct{}),
+	}
+}
+
+// watch arms a timer that fails n with a `queue` or `start` TimeoutError as
+// soon as limit has passed since it became ready (plus its `delay_before`),
+// unless a worker receives it first. The worker still checks `start` itself
+// for the time it spends building the task (see limits.checkWait).
+func (e *Executor) watch(ctx context.Context, n *node.Node, before time.Duration, kind TimeoutKind, limit time.Duration) {
+	readyAt, ok := e.scheduler.ReadyAt(n.ID)
+	if !ok || limit <= 0 {
+		return
+	}
+	id := n.ID.String()
+	w := e.watchdog
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	e.delayed.Add(1)
+	w.timers[id] = time.AfterFunc(time.Until(readyAt.Add(before+limit)), func() {
+		defer e.delayed.Done()
+		w.mu.Lock()
+		if _, armed := w.timers[id]; !armed {
+			w.mu.Unlock()
+			return
+		}
+		delete(w.timers, id)
+		w.failed[id] = struct{}{}
+		w.mu.Unlock()
+
+		ctxlog.FromContext(ctx).Debug("Node timed out before reaching a worker.", "node", id, "timeout", kind)
+		e.fail(ctx, n, &TimeoutError{Kind: kind, Limit: limit})
+		e.scheduler.Notify()
+	})
+}
+
+// claim disarms the timer of n when a worker receives it. It returns false if
+// the timer already failed the node, which must then be dropped.
+func (e *Executor) claim(n *node.Node) bool {
+	id := n.ID.String()
+	w := e.watchdog
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if _, ok := w.failed[id]; ok {
+		delete(w.failed, id)
+		return false
+	}
+	if t, ok := w.timers[id]; ok {
+		delete(w.timers, id)
+		if t.Stop() {
+			e.delayed.Done()
+		}
+	}
+	return true
+}
+
+// expired reports whether the timer of n already failed it.
+func (w *watchdog) expired(n *node.Node) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	_, ok := w.failed[n.ID.String()]
+	return ok
+}
+
+// disarm stops every timer still armed once the workers are done, so that a
+// cancelled run does not wait for them.
+func (e *Executor) disarm() {
+	w := e.watchdog
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for id, t := range w.timers {
+		delete(w.timers, id)
+		if t.Stop() {
+			e.delayed.Done()
+		}
+	}
+}
+
+// withDeadline bounds ctx by the step's `deadline`, which covers every attempt.
+func (lim limits) withDeadline(ctx context.Context) (context.Context, context.CancelFunc) {
+	if lim.deadline.IsZero() {
+		return ctx, func() {}
+	}
+	return context.WithDeadline(ctx, lim.deadline)
+}
+
+// attempt runs a single attempt of a task, bounded by the step's `execution`
+// timeout and by any deadline already carried by ctx. The handler runs on its
+// own goroutine so that the attempt ends when the timeout fires, even if the
+// handler ignores its context; such a handler keeps running in the background
+// until it returns, and its result is discarded.
+func (e *Executor) attempt(ctx context.Context, t *task.Task, lim limits) (cty.Value, error) {
+	if err := lim.expired(ctx); err != nil {
+		return cty.NilVal, err
+	}
+	attemptCtx := ctx
+	if lim.execution > 0 {
+		var cancel context.CancelFunc
+		attemptCtx, cancel = context.WithTimeout(ctx, lim.execution)
+		defer cancel()
+	}
+
+	type result struct {
+		output cty.Value
+		err    error
+	}
+	done := make(chan result, 1)
+	go func() {
+		output, err := e.run(attemptCtx, t)
+		done <- result{output, err}
+	}()
+
+	select {
+	case r := <-done:
+		if r.err != nil {
+			if err := lim.timedOut(ctx, attemptCtx); err != nil {
+				return cty.NilVal, err
+			}
+		}
+		return r.output, r.err
+	case <-attemptCtx.Done():
+		if err := lim.timedOut(ctx, attemptCtx); err != nil {
+			return cty.NilVal, err
+		}
+		return cty.NilVal, attemptCtx.Err()
+	}
+}
+
+// expired returns a deadline TimeoutError if ctx has already passed the
+// step's deadline, and the context error if it was cancelled.
+func (lim limits) expired(ctx context.Context) error {
+	err := ctx.Err()
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, context.DeadlineExceeded) && !lim.deadline.IsZero() {
+		return &TimeoutError{Kind: TimeoutDeadline, Deadline: lim.deadline}
+	}
+	return err
+}
+
+// timedOut explains why an attempt's context ended: the step deadline (ctx)
+// or the execution timeout (attemptCtx). It returns nil if neither expired.
+func (lim limits) timedOut(ctx, attemptCtx context.Context) error {
+	if err := lim.expired(ctx); err != nil {
+		return err
+	}
+	if lim.execution > 0 && errors.Is(attemptCtx.Err(), context.DeadlineExceeded) {
+		return &TimeoutError{Kind: TimeoutExecution, Limit: lim.execution}
+	}
+	return nil
+}
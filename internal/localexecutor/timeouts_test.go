@@ -0,0 +1,304 @@
+package localexecutor
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/specialistvlad/burstgridgo/internal/builder"
+	"github.com/specialistvlad/burstgridgo/internal/handlers"
+	"github.com/specialistvlad/burstgridgo/internal/node"
+	"github.com/specialistvlad/burstgridgo/internal/registry"
+	"github.com/specialistvlad/burstgridgo/internal/scheduler"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const sleepyManifest = `
+runner "sleepy" {
+  input "ms" {
+    type    = number
+    default = 0
+  }
+  input "ignore_ctx" {
+    type    = bool
+    default = false
+  }
+  lifecycle {
+    on_run = "OnRunSleepy"
+  }
+}
+`
+
+// sleepyRegistry registers a handler that sleeps for `ms` milliseconds,
+// returning early when its context ends unless `ignore_ctx` is set. The
+// first `slowCalls` calls sleep; later calls return immediately.
+func sleepyRegistry(t *testing.T, slowCalls int32, calls *atomic.Int32) *registry.Registry {
+	type sleepyIn struct {
+		Ms        int  `bggo:"ms"`
+		IgnoreCtx bool `bggo:"ignore_ctx"`
+	}
+	return newRegistry(t, sleepyManifest, map[string]*handlers.RegisteredHandler{
+		"OnRunSleepy": {
+			Input: func() any { return new(sleepyIn) },
+			Fn: func(ctx context.Context, deps any, input *sleepyIn) (any, error) {
+				if calls.Add(1) > slowCalls {
+					return nil, nil
+				}
+				d := time.Duration(input.Ms) * time.Millisecond
+				if input.IgnoreCtx {
+					time.Sleep(d)
+					return nil, nil
+				}
+				select {
+				case <-time.After(d):
+					return nil, nil
+				case <-ctx.Done():
+					return nil, ctx.Err()
+				}
+			},
+		},
+	})
+}
+
+// runTimeouts executes src with the sleepy runner on the given number of
+// workers and returns the run error and how long the run took.
+func runTimeouts(t *testing.T, src string, workers int, slowCalls int32, calls *atomic.Int32) (error, time.Duration) {
+	t.Helper()
+	reg := sleepyRegistry(t, slowCalls, calls)
+	g := compileGraph(t, src)
+	start := time.Now()
+	err := New(scheduler.New(g), g, builder.New(reg), reg, workers).Execute(testContext())
+	return err, time.Since(start)
+}
+
+func timeoutOf(t *testing.T, err error, id string) *TimeoutError {
+	t.Helper()
+	var runErr *RunError
+	require.ErrorAs(t, err, &runErr)
+	for _, f := range runErr.Failures {
+		if f.Node.String() == id {
+			var te *TimeoutError
+			require.ErrorAs(t, f, &te)
+			assert.ErrorIs(t, f, context.DeadlineExceeded)
+			assert.Equal(t, handlers.ClassTimeout, handlers.ErrorClass(f))
+			return te
+		}
+	}
+	t.Fatalf("node %s did not fail: %v", id, err)
+	return nil
+}
+
+func TestTimeouts_Execution(t *testing.T) {
+	testCases := []struct {
+		name      string
+		ignoreCtx bool
+	}{
+		{name: "handler honours its context"},
+		{name: "handler ignores its context", ignoreCtx: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var calls atomic.Int32
+			ignore := "false"
+			if tc.ignoreCtx {
+				ignore = "true"
+			}
+			err, took := runTimeouts(t, `
+				step "sleepy" "slow" {
+					arguments {
+						ms         = 2000
+						ignore_ctx = `+ignore+`
+					}
+					timeouts {
+						execution = "20ms"
+					}
+				}
+			`, 1, 1, &calls)
+			te := timeoutOf(t, err, "step.sleepy.slow")
+			assert.Equal(t, TimeoutExecution, te.Kind)
+			assert.Equal(t, 20*time.Millisecond, te.Limit)
+			assert.Less(t, took, time.Second, "the attempt must end when the timeout fires")
+		})
+	}
+}
+
+func TestTimeouts_ExecutionTimeoutIsRetried(t *testing.T) {
+	var calls atomic.Int32
+	err, _ := runTimeouts(t, `
+		step "sleepy" "flaky" {
+			arguments {
+				ms = 2000
+			}
+			timeouts {
+				execution = "20ms"
+			}
+			retry {
+				attempts = 3
+				retry_on = ["timeout"]
+				backoff {
+					strategy = "constant"
+					initial  = "1ms"
+				}
+			}
+		}
+	`, 1, 1, &calls)
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestTimeouts_Deadline(t *testing.T) {
+	testCases := []struct {
+		name      string
+		deadline  string
+		wantCalls int32
+	}{
+		{name: "relative deadline stops retries", deadline: `"60ms"`, wantCalls: 3},
+		{name: "deadline in the past", deadline: `"2000-01-01T00:00:00Z"`, wantCalls: 0},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var calls atomic.Int32
+			err, took := runTimeouts(t, `
+				step "sleepy" "slow" {
+					arguments {
+						ms = 500
+					}
+					timeouts {
+						execution = "20ms"
+						deadline  = `+tc.deadline+`
+					}
+					retry {
+						attempts = 100
+						backoff {
+							strategy = "constant"
+							initial  = "1ms"
+						}
+					}
+				}
+			`, 1, 100, &calls)
+			te := timeoutOf(t, err, "step.sleepy.slow")
+			assert.Equal(t, TimeoutDeadline, te.Kind)
+			assert.LessOrEqual(t, calls.Load(), tc.wantCalls)
+			assert.Less(t, took, time.Second)
+		})
+	}
+}
+
+func TestTimeouts_QueueAndStart(t *testing.T) {
+	testCases := []struct {
+		name string
+		attr string
+		kind TimeoutKind
+	}{
+		{name: "queue", attr: `queue = "10ms"`, kind: TimeoutQueue},
+		{name: "start", attr: `start = "10ms"`, kind: TimeoutStart},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// With a single worker, b waits in the ready queue while a sleeps.
+			var calls atomic.Int32
+			err, _ := runTimeouts(t, `
+				step "sleepy" "a" {
+					arguments {
+						ms = 50
+					}
+				}
+				step "sleepy" "b" {
+					timeouts {
+						`+tc.attr+`
+					}
+				}
+			`, 1, 1, &calls)
+			te := timeoutOf(t, err, "step.sleepy.b")
+			assert.Equal(t, tc.kind, te.Kind)
+			assert.Equal(t, int32(1), calls.Load(), "b must not run")
+		})
+	}
+}
+
+func TestTimeouts_QueueAndStartFailBeforeWorkerFrees(t *testing.T) {
+	testCases := []struct {
+		name string
+		attr string
+		kind TimeoutKind
+	}{
+		{name: "queue", attr: `queue = "50ms"`, kind: TimeoutQueue},
+		{name: "start", attr: `start = "50ms"`, kind: TimeoutStart},
+		{name: "sooner of both", attr: `queue = "1m"
+						start = "50ms"`, kind: TimeoutStart},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// The only worker is busy with a for a second; b must fail as
+			// soon as its limit passes, not once a finishes.
+			var calls atomic.Int32
+			reg := sleepyRegistry(t, 1, &calls)
+			g := compileGraph(t, `
+				step "sleepy" "a" {
+					arguments {
+						ms = 1000
+					}
+				}
+				step "sleepy" "b" {
+					timeouts {
+						`+tc.attr+`
+					}
+				}
+			`)
+			done := make(chan error, 1)
+			start := time.Now()
+			go func() {
+				done <- New(scheduler.New(g), g, builder.New(reg), reg, 1).Execute(testContext())
+			}()
+
+			require.Eventually(t, func() bool {
+				return status(t, g, "step.sleepy.b") == node.StatusFailed
+			}, 500*time.Millisecond, 5*time.Millisecond, "b must fail near its limit")
+			assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+			assert.Equal(t, node.StatusRunning, status(t, g, "step.sleepy.a"))
+
+			te := timeoutOf(t, <-done, "step.sleepy.b")
+			assert.Equal(t, tc.kind, te.Kind)
+			assert.Equal(t, 50*time.Millisecond, te.Limit)
+			assert.Equal(t, int32(1), calls.Load(), "b must not run")
+		})
+	}
+}
+
+func TestTimeouts_NotExceeded(t *testing.T) {
+	var calls atomic.Int32
+	reg := sleepyRegistry(t, 1, &calls)
+	g := compileGraph(t, `
+		step "sleepy" "quick" {
+			arguments {
+				ms = 1
+			}
+			timeouts {
+				execution = "1s"
+				start     = "1s"
+				queue     = "1s"
+				deadline  = "1m"
+			}
+		}
+	`)
+	require.NoError(t, New(scheduler.New(g), g, builder.New(reg), reg, 1).Execute(testContext()))
+	assert.Equal(t, node.StatusCompleted, status(t, g, "step.sleepy.quick"))
+}
+
+func TestTimeouts_InvalidValue(t *testing.T) {
+	var calls atomic.Int32
+	err, _ := runTimeouts(t, `
+		step "sleepy" "bad" {
+			timeouts {
+				execution = "soon"
+			}
+		}
+	`, 1, 0, &calls)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Invalid execution value")
+	assert.False(t, errors.Is(err, context.DeadlineExceeded))
+	assert.Equal(t, int32(0), calls.Load())
+}
@@ -0,0 +1,333 @@
+// Package locals orders and evaluates the values of a grid's `locals` blocks.
+//
+// A local may reference variables (`var.<name>`), other locals
+// (`local.<name>`) and step outputs (`step.<runner>.<name>...`). Analyze orders
+// the locals so that each one comes after the locals it references, and
+// rejects cycles.
+//
+// A local is static when neither it nor any local it references reads a step
+// output. Static locals are evaluated once, before the run, by Set.Evaluate.
+// The others are dynamic: they are evaluated lazily by Values.Object for each
+// node that uses them, against that node's evaluation context, since the step
+// outputs they read only exist once the node's dependencies have completed.
+package locals
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/specialistvlad/burstgridgo/internal/bggohcl"
+	"github.com/specialistvlad/burstgridgo/internal/funcs"
+	"github.com/specialistvlad/burstgridgo/internal/model"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// Traversal root names a local may reference.
+const (
+	localRoot = "local"
+	stepRoot  = "step"
+	varRoot   = "var"
+)
+
+// Set is a grid's locals, analyzed but not yet evaluated.
+type Set struct {
+	order  []*model.Local // each local after the locals it references
+	byName map[string]*model.Local
+	deps   map[string][]string        // locals referenced directly by each local
+	steps  map[string][]hcl.Traversal // step references of each local, direct or through other locals
+}
+
+// Analyze checks the references of decls and orders them for evaluation.
+func Analyze(decls []*model.Local) (*Set, hcl.Diagnostics) {
+	s := &Set{
+		byName: make(map[string]*model.Local, len(decls)),
+		deps:   make(map[string][]string),
+		steps:  make(map[string][]hcl.Traversal),
+	}
+	for _, l := range decls {
+		s.byName[l.Name] = l
+	}
+
+	var diags hcl.Diagnostics
+	for _, l := range decls {
+		seen := make(map[string]struct{})
+		for _, trav := range l.Expr.Variables() {
+			switch trav.RootName() {
+			case localRoot:
+				name, ok := localName(trav)
+				if !ok {
+					diags = diags.Append(invalidReference(trav))
+					continue
+				}
+				if _, declared := s.byName[name]; !declared {
+					diags = diags.Append(undeclared(name, trav))
+					continue
+				}
+				if _, dup := seen[name]; !dup {
+					seen[name] = struct{}{}
+					s.deps[l.Name] = append(s.deps[l.Name], name)
+				}
+			case stepRoot:
+				s.steps[l.Name] = append(s.steps[l.Name], trav)
+			case varRoot:
+				// Checked by the compiler, which knows the declared variables.
+			default:
+				diags = diags.Append(&hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Invalid reference in local value",
+					Detail:   fmt.Sprintf("Local value %q references %q, but local values may only reference var, local and step values.", l.Name, trav.RootName()),
+					Subject:  trav.SourceRange().Ptr(),
+				})
+			}
+		}
+	}
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	const (
+		visiting = iota + 1
+		visited
+	)
+	state := make(map[string]int, len(decls))
+	var visit func(name string, path []string) *hcl.Diagnostic
+	visit = func(name string, path []string) *hcl.Diagnostic {
+		switch state[name] {
+		case visited:
+			return nil
+		case visiting:
+			return cycleDiag(s.byName, path, name)
+		}
+		state[name] = visiting
+		path = append(path, name)
+		for _, dep := range s.deps[name] {
+			if d := visit(dep, path); d != nil {
+				return d
+			}
+		}
+		state[name] = visited
+		s.order = append(s.order, s.byName[name])
+		for _, dep := range s.deps[name] {
+			s.steps[name] = appendUnique(s.steps[name], s.steps[dep]...)
+		}
+		return nil
+	}
+	for _, l := range decls {
+		if d := visit(l.Name, nil); d != nil {
+			return nil, diags.Append(d)
+		}
+	}
+	return s, diags
+}
+
+// Declared reports whether a local named name is declared.
+func (s *Set) Declared(name string) bool {
+	_, ok := s.byName[name]
+	return ok
+}
+
+// StepReferences returns the step references the named locals make, directly
+// or through the locals they reference.
+func (s *Set) StepReferences(names []string) []hcl.Traversal {
+	var travs []hcl.Traversal
+	for _, name := range names {
+		travs = appendUnique(travs, s.steps[name]...)
+	}
+	return travs
+}
+
+// CheckReference checks a `local.<name>` traversal against s, returning a
+// diagnostic if it is malformed or names an undeclared local.
+func (s *Set) CheckReference(trav hcl.Traversal) *hcl.Diagnostic {
+	name, ok := localName(trav)
+	if !ok {
+		return invalidReference(trav)
+	}
+	if !s.Declared(name) {
+		return undeclared(name, trav)
+	}
+	return nil
+}
+
+// dynamic reports whether the named local reads a step output.
+func (s *Set) dynamic(name string) bool {
+	return len(s.steps[name]) > 0
+}
+
+// Evaluate evaluates the static locals, with vars exposed as `var`.
+func (s *Set) Evaluate(vars cty.Value) (*Values, hcl.Diagnostics) {
+	v := &Values{set: s, static: make(map[string]cty.Value)}
+	var diags hcl.Diagnostics
+	for _, l := range s.order {
+		if s.dynamic(l.Name) {
+			continue
+		}
+		ctx := funcs.EvalContext(map[string]cty.Value{
+			varRoot:   vars,
+			localRoot: cty.ObjectVal(v.static),
+		})
+		val, valDiags := l.Expr.Value(ctx)
+		diags = append(diags, valDiags...)
+		if valDiags.HasErrors() {
+			continue
+		}
+		v.static[l.Name] = val
+	}
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	return v, diags
+}
+
+// Values is a grid's locals with their static values evaluated.
+type Values struct {
+	set    *Set
+	static map[string]cty.Value
+}
+
+// Set returns the analyzed locals the values were evaluated from.
+func (v *Values) Set() *Set {
+	return v.set
+}
+
+// Object returns the value of `local` for ctx: every static local, plus the
+// named dynamic locals and the dynamic locals they reference, evaluated
+// against ctx in dependency order.
+func (v *Values) Object(ctx *hcl.EvalContext, names []string) (cty.Value, hcl.Diagnostics) {
+	vals := make(map[string]cty.Value, len(v.static))
+	for name, val := range v.static {
+		vals[name] = val
+	}
+
+	needed := make(map[string]struct{})
+	var need func(name string)
+	need = func(name string) {
+		if _, ok := needed[name]; ok || !v.set.dynamic(name) {
+			return
+		}
+		needed[name] = struct{}{}
+		for _, dep := range v.set.deps[name] {
+			need(dep)
+		}
+	}
+	for _, name := range names {
+		need(name)
+	}
+	if len(needed) == 0 {
+		return cty.ObjectVal(vals), nil
+	}
+
+	var diags hcl.Diagnostics
+	for _, l := range v.set.order {
+		if _, ok := needed[l.Name]; !ok {
+			continue
+		}
+		evalCtx := &hcl.EvalContext{
+			Variables: make(map[string]cty.Value, len(ctx.Variables)+1),
+			Functions: ctx.Functions,
+		}
+		for name, val := range ctx.Variables {
+			evalCtx.Variables[name] = val
+		}
+		evalCtx.Variables[localRoot] = cty.ObjectVal(vals)
+		val, valDiags := l.Expr.Value(evalCtx)
+		diags = append(diags, valDiags...)
+		if valDiags.HasErrors() {
+			return cty.NilVal, diags
+		}
+		vals[l.Name] = val
+	}
+	return cty.ObjectVal(vals), diags
+}
+
+// Referenced returns the names of the locals referenced by travs, in order
+// and without duplicates. Traversals that are not `local.<name>` are ignored.
+func Referenced(travs []hcl.Traversal) []string {
+	var names []string
+	seen := make(map[string]struct{})
+	for _, trav := range travs {
+		if trav.RootName() != localRoot {
+			continue
+		}
+		name, ok := localName(trav)
+		if !ok {
+			continue
+		}
+		if _, dup := seen[name]; !dup {
+			seen[name] = struct{}{}
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// localName extracts the name from a `local.<name>` traversal.
+func localName(trav hcl.Traversal) (string, bool) {
+	if len(trav) < 2 {
+		return "", false
+	}
+	attr, ok := trav[1].(hcl.TraverseAttr)
+	if !ok {
+		return "", false
+	}
+	return attr.Name, true
+}
+
+func invalidReference(trav hcl.Traversal) *hcl.Diagnostic {
+	return &hcl.Diagnostic{
+		Severity: hcl.DiagError,
+		Summary:  "Invalid local value reference",
+		Detail:   "A reference to a local value must be of the form local.<name>.",
+		Subject:  trav.SourceRange().Ptr(),
+	}
+}
+
+func undeclared(name string, trav hcl.Traversal) *hcl.Diagnostic {
+	return &hcl.Diagnostic{
+		Severity: hcl.DiagError,
+		Summary:  "Reference to undeclared local value",
+		Detail:   fmt.Sprintf("A local value named %q has not been declared. Declare it in a `locals` block.", name),
+		Subject:  trav.SourceRange().Ptr(),
+	}
+}
+
+// cycleDiag reports the cycle closed by a reference to name from the last
+// local in path.
+func cycleDiag(byName map[string]*model.Local, path []string, name string) *hcl.Diagnostic {
+	start := 0
+	for i, p := range path {
+		if p == name {
+			start = i
+			break
+		}
+	}
+	cycle := make([]string, 0, len(path)-start+1)
+	for _, p := range path[start:] {
+		cycle = append(cycle, "local."+p)
+	}
+	cycle = append(cycle, "local."+name)
+	return &hcl.Diagnostic{
+		Severity: hcl.DiagError,
+		Summary:  "Cycle in local values",
+		Detail:   fmt.Sprintf("Local values reference each other in a cycle: %s.", strings.Join(cycle, " -> ")),
+		Subject:  byName[name].Expr.Range().Ptr(),
+	}
+}
+
+// appendUnique appends the traversals of more that are not already in travs.
+func appendUnique(travs []hcl.Traversal, more ...hcl.Traversal) []hcl.Traversal {
+	seen := make(map[string]struct{}, len(travs))
+	for _, t := range travs {
+		seen[bggohcl.TraversalKey(t)] = struct{}{}
+	}
+	for _, t := range more {
+		key := bggohcl.TraversalKey(t)
+		if _, dup := seen[key]; dup {
+			continue
+		}
+		seen[key] = struct{}{}
+		travs = append(travs, t)
+	}
+	return travs
+}
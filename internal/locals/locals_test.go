@@ -0,0 +1,191 @@
+package locals
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/specialistvlad/burstgridgo/internal/ctxlog"
+	"github.com/specialistvlad/burstgridgo/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// declare parses the `locals` blocks in src.
+func declare(t *testing.T, src string) []*model.Local {
+	t.Helper()
+	ctx := ctxlog.WithLogger(context.Background(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.hcl"), []byte(src), 0644))
+	grid, err := model.LoadGridsRecursively(ctx, dir)
+	require.NoError(t, err)
+	return grid.Locals
+}
+
+func names(set *Set) []string {
+	var out []string
+	for _, l := range set.order {
+		out = append(out, l.Name)
+	}
+	return out
+}
+
+func TestAnalyze_OrdersByReference(t *testing.T) {
+	set, diags := Analyze(declare(t, `
+		locals {
+			url     = "${local.base}/users/${local.user}"
+			base    = "https://${var.host}"
+			user    = step.auth.login.output.user
+			unused  = 1
+		}
+		locals {
+			payload = { url = local.url }
+		}
+	`))
+	require.False(t, diags.HasErrors(), diags.Error())
+
+	order := names(set)
+	assert.ElementsMatch(t, []string{"url", "base", "user", "unused", "payload"}, order)
+	index := func(name string) int {
+		for i, n := range order {
+			if n == name {
+				return i
+			}
+		}
+		return -1
+	}
+	assert.Less(t, index("base"), index("url"))
+	assert.Less(t, index("user"), index("url"))
+	assert.Less(t, index("url"), index("payload"))
+
+	assert.True(t, set.dynamic("payload"), "step references are inherited through locals")
+	assert.False(t, set.dynamic("base"))
+	require.Len(t, set.StepReferences([]string{"payload", "user"}), 1)
+	assert.Empty(t, set.StepReferences([]string{"base", "unused"}))
+}
+
+func TestAnalyze_Errors(t *testing.T) {
+	testCases := []struct {
+		name        string
+		src         string
+		wantSummary string
+		wantDetail  string
+	}{
+		{
+			name:        "self reference",
+			src:         `locals { a = local.a }`,
+			wantSummary: "Cycle in local values",
+			wantDetail:  "local.a -> local.a",
+		},
+		{
+			name: "indirect cycle",
+			src: `
+				locals {
+					a = local.b
+					b = "${local.c}!"
+					c = [local.a]
+				}`,
+			wantSummary: "Cycle in local values",
+			wantDetail:  "local.a -> local.b -> local.c -> local.a",
+		},
+		{
+			name:        "undeclared local",
+			src:         `locals { a = local.missing }`,
+			wantSummary: "Reference to undeclared local value",
+			wantDetail:  `"missing"`,
+		},
+		{
+			name:        "whole local object",
+			src:         `locals { a = local }`,
+			wantSummary: "Invalid local value reference",
+		},
+		{
+			name:        "instance variable",
+			src:         `locals { a = count.index }`,
+			wantSummary: "Invalid reference in local value",
+			wantDetail:  `"count"`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, diags := Analyze(declare(t, tc.src))
+			require.True(t, diags.HasErrors())
+			assert.Equal(t, tc.wantSummary, diags[0].Summary)
+			assert.Contains(t, diags[0].Detail, tc.wantDetail)
+			assert.NotNil(t, diags[0].Subject)
+		})
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	set, diags := Analyze(declare(t, `
+		locals {
+			url   = "${local.base}/users"
+			base  = "https://${var.host}"
+			token = step.auth.login.output.token
+			auth  = "Bearer ${local.token}"
+			other = step.auth.other.output
+		}
+	`))
+	require.False(t, diags.HasErrors(), diags.Error())
+
+	vals, diags := set.Evaluate(cty.ObjectVal(map[string]cty.Value{"host": cty.StringVal("example.com")}))
+	require.False(t, diags.HasErrors(), diags.Error())
+	assert.Equal(t, map[string]cty.Value{
+		"base": cty.StringVal("https://example.com"),
+		"url":  cty.StringVal("https://example.com/users"),
+	}, vals.static, "only static locals are evaluated up front")
+
+	ctx := &hcl.EvalContext{Variables: map[string]cty.Value{
+		"step": cty.ObjectVal(map[string]cty.Value{
+			"auth": cty.ObjectVal(map[string]cty.Value{
+				"login": cty.ObjectVal(map[string]cty.Value{
+					"output": cty.ObjectVal(map[string]cty.Value{"token": cty.StringVal("abc123")}),
+				}),
+			}),
+		}),
+	}}
+
+	obj, diags := vals.Object(ctx, nil)
+	require.False(t, diags.HasErrors(), diags.Error())
+	assert.False(t, obj.Type().HasAttribute("auth"), "dynamic locals are evaluated only when used")
+
+	obj, diags = vals.Object(ctx, []string{"auth"})
+	require.False(t, diags.HasErrors(), diags.Error())
+	assert.Equal(t, cty.StringVal("Bearer abc123"), obj.GetAttr("auth"))
+	assert.Equal(t, cty.StringVal("abc123"), obj.GetAttr("token"))
+	assert.Equal(t, cty.StringVal("https://example.com/users"), obj.GetAttr("url"))
+	assert.False(t, obj.Type().HasAttribute("other"))
+
+	_, diags = vals.Object(ctx, []string{"other"})
+	assert.True(t, diags.HasErrors(), "a dynamic local fails if its step output is unavailable")
+}
+
+func TestEvaluate_Errors(t *testing.T) {
+	set, diags := Analyze(declare(t, `locals { url = "https://${var.missing}" }`))
+	require.False(t, diags.HasErrors(), diags.Error())
+
+	_, diags = set.Evaluate(cty.EmptyObjectVal)
+	require.True(t, diags.HasErrors())
+	assert.Equal(t, "Unsupported attribute", diags[0].Summary)
+}
+
+func TestReferenced(t *testing.T) {
+	travs := func(exprs ...string) []hcl.Traversal {
+		var out []hcl.Traversal
+		for _, src := range exprs {
+			trav, diags := hclsyntax.ParseTraversalAbs([]byte(src), "", hcl.InitialPos)
+			require.False(t, diags.HasErrors(), diags.Error())
+			out = append(out, trav)
+		}
+		return out
+	}
+	got := Referenced(travs("local.a", "var.b", "local.c[0]", "local.a.x", "step.r.n"))
+	assert.Equal(t, []string{"a", "c"}, got)
+}
@@ -4,40 +4,74 @@ package localsession
 
 import (
 	"context"
+	"path/filepath"
 
 	"github.com/specialistvlad/burstgridgo/internal/builder"
+	"github.com/specialistvlad/burstgridgo/internal/cache"
+	"github.com/specialistvlad/burstgridgo/internal/compiler"
 	"github.com/specialistvlad/burstgridgo/internal/ctxlog"
 	"github.com/specialistvlad/burstgridgo/internal/executor"
 	"github.com/specialistvlad/burstgridgo/internal/graph"
-	"github.com/specialistvlad/burstgridgo/internal/handlers"
 	"github.com/specialistvlad/burstgridgo/internal/inmemorystore"
 	"github.com/specialistvlad/burstgridgo/internal/inmemorytopology"
 	"github.com/specialistvlad/burstgridgo/internal/localexecutor"
+	"github.com/specialistvlad/burstgridgo/internal/locals"
 	"github.com/specialistvlad/burstgridgo/internal/model"
+	"github.com/specialistvlad/burstgridgo/internal/registry"
 	"github.com/specialistvlad/burstgridgo/internal/scheduler"
 	"github.com/specialistvlad/burstgridgo/internal/session"
+	"github.com/zclconf/go-cty/cty"
 )
 
 // SessionFactory implements session.SessionFactory for local runs.
-type SessionFactory struct{}
+type SessionFactory struct {
+	// Workers is the number of nodes the executor may run concurrently.
+	Workers int
+	// CacheDir holds the step output cache of the "global" scope and, under
+	// "idempotency", the records of steps with an `idempotency_key`. Empty
+	// means cache.DefaultDir.
+	CacheDir string
+	// Variables holds the grid's resolved `var.<name>` values, as returned by
+	// variables.Resolve. The zero value means the grid has no variables.
+	Variables cty.Value
+	// Locals holds the grid's `local.<name>` values, as returned by
+	// locals.Set.Evaluate. Nil means the grid has no locals.
+	Locals *locals.Values
+}
 
 // NewSession creates and configures a new local session.
 func (f *SessionFactory) NewSession(
 	ctx context.Context,
 	cfg *model.Grid,
-	reg *handlers.Handlers,
+	reg *registry.Registry,
 ) (session.Session, error) {
 	logger := ctxlog.FromContext(ctx)
 	logger.Debug("localsession.SessionFactory.NewSession called")
 
 	// --- This is where the dependency injection wiring happens ---
-	// For now, we are creating placeholder instances of each component.
 	topoStore := inmemorytopology.New()
+	if diags := compiler.Compile(ctx, cfg, topoStore); diags.HasErrors() {
+		return nil, diags
+	}
 	nodeStore := inmemorystore.New()
 	graph := graph.New(topoStore, nodeStore)
-	taskBuilder := builder.New()
-	sched := scheduler.New(graph)
-	exec := localexecutor.New(sched, graph, taskBuilder, reg)
+	var builderOpts []builder.Option
+	if f.Variables != cty.NilVal {
+		builderOpts = append(builderOpts, builder.WithVariables(f.Variables))
+	}
+	if f.Locals != nil {
+		builderOpts = append(builderOpts, builder.WithLocals(f.Locals))
+	}
+	taskBuilder := builder.New(reg, builderOpts...)
+	sched := scheduler.New(graph, scheduler.WithPriority(localexecutor.Priority(taskBuilder, graph)))
+	cacheDir := f.CacheDir
+	if cacheDir == "" {
+		cacheDir = cache.DefaultDir()
+	}
+	exec := localexecutor.New(sched, graph, taskBuilder, reg, f.Workers,
+		localexecutor.WithCache(cache.ScopeGlobal, cache.NewDisk(cacheDir)),
+		localexecutor.WithIdempotencyStore(cache.NewDisk(filepath.Join(cacheDir, "idempotency"))),
+		localexecutor.WithResources(cfg.Resources))
 	// --- End of dependency injection ---
 
 	return &Session{
@@ -22,6 +22,7 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/gohcl"
 	"github.com/hashicorp/hcl/v2/hclparse"
 	"github.com/specialistvlad/burstgridgo/internal/ctxlog"
@@ -30,25 +31,33 @@ import (
 
 // Grid represents the user's execution graph definition.
 type Grid struct {
-	Steps []*Step
+	Steps     []*Step
+	Resources []*Resource
+	Variables []*Variable
+	Locals    []*Local
 }
 
 // NewGrid creates and returns an initialized Grid.
 func NewGrid() *Grid {
 	return &Grid{
-		Steps: []*Step{},
+		Steps:     []*Step{},
+		Resources: []*Resource{},
+		Variables: []*Variable{},
+		Locals:    []*Local{},
 	}
 }
 
 // hclGridFile represents the top-level structure of a grid file for decoding.
 type hclGridFile struct {
 	Steps     []*hclStep          `hcl:"step,block"`
+	Resources []*hclResourceBlock `hcl:"resource,block"`
 	Locals    []*hclLocalsBlock   `hcl:"locals,block"`
 	Variables []*hclVariableBlock `hcl:"variable,block"`
 }
 
-// newGridFromHCL parses a single HCL file and returns the Steps found within it.
-func newGridFromHCL(filePath string, parser *hclparse.Parser) ([]*Step, error) {
+// newGridFromHCL parses a single HCL file into a Grid holding the Steps,
+// Resources, Variables and Locals found within it.
+func newGridFromHCL(filePath string, parser *hclparse.Parser) (*Grid, error) {
 	hclFile, diags := parser.ParseHCLFile(filePath)
 	if diags.HasErrors() {
 		return nil, fmt.Errorf("failed to parse HCL file %s: %w", filePath, diags)
@@ -60,19 +69,58 @@ func newGridFromHCL(filePath string, parser *hclparse.Parser) ([]*Step, error) {
 		return nil, fmt.Errorf("failed to decode HCL file %s: %w", filePath, diags)
 	}
 
-	// For now, we only parse steps. The presence of the `Locals` and `Variables`
-	// fields in the struct is enough to prevent the parser from erroring.
-
-	steps := make([]*Step, 0, len(parsedFile.Steps))
+	grid := NewGrid()
 	for _, parsedStep := range parsedFile.Steps {
 		step, stepDiags := NewStepFromHCL(parsedStep, filePath)
 		if stepDiags.HasErrors() {
 			return nil, fmt.Errorf("error parsing step in file %s: %w", filePath, stepDiags)
 		}
-		steps = append(steps, step)
+		grid.Steps = append(grid.Steps, step)
+	}
+	for _, parsedResource := range parsedFile.Resources {
+		resource, resourceDiags := NewResourceFromHCL(parsedResource, filePath)
+		if resourceDiags.HasErrors() {
+			return nil, fmt.Errorf("error parsing resource in file %s: %w", filePath, resourceDiags)
+		}
+		if err := grid.addResources([]*Resource{resource}); err != nil {
+			return nil, err
+		}
+	}
+	for _, parsedVariable := range parsedFile.Variables {
+		variable, varDiags := NewVariableFromHCL(parsedVariable)
+		if varDiags.HasErrors() {
+			return nil, fmt.Errorf("error parsing variable in file %s: %w", filePath, varDiags)
+		}
+		grid.Variables = append(grid.Variables, variable)
+	}
+	for _, parsedLocals := range parsedFile.Locals {
+		locals, localDiags := NewLocalsFromHCL(parsedLocals)
+		if localDiags.HasErrors() {
+			return nil, fmt.Errorf("error parsing locals in file %s: %w", filePath, localDiags)
+		}
+		grid.Locals = append(grid.Locals, locals...)
 	}
 
-	return steps, nil
+	return grid, nil
+}
+
+// addVariables appends vars to the grid, rejecting names that are already
+// declared, possibly in another file.
+func (g *Grid) addVariables(vars []*Variable) error {
+	for _, v := range vars {
+		for _, prev := range g.Variables {
+			if prev.Name == v.Name {
+				return fmt.Errorf("duplicate variable: %w", hcl.Diagnostics{{
+					Severity: hcl.DiagError,
+					Summary:  "Duplicate variable definition",
+					Detail:   fmt.Sprintf("A variable named '%s' was already declared at %s.", v.Name, prev.DeclRange.String()),
+					Subject:  v.DeclRange.Ptr(),
+				}})
+			}
+		}
+		g.Variables = append(g.Variables, v)
+	}
+	return nil
 }
 
 // LoadGridsRecursively finds and parses all HCL files in a given path into a Grid model.
@@ -93,11 +141,20 @@ func LoadGridsRecursively(ctx context.Context, gridPath string) (*Grid, error) {
 
 	parser := hclparse.NewParser()
 	for _, file := range files {
-		steps, err := newGridFromHCL(file, parser)
+		fileGrid, err := newGridFromHCL(file, parser)
 		if err != nil {
 			return nil, err
 		}
-		grid.Steps = append(grid.Steps, steps...)
+		grid.Steps = append(grid.Steps, fileGrid.Steps...)
+		if err := grid.addResources(fileGrid.Resources); err != nil {
+			return nil, err
+		}
+		if err := grid.addVariables(fileGrid.Variables); err != nil {
+			return nil, err
+		}
+		if err := grid.addLocals(fileGrid.Locals); err != nil {
+			return nil, err
+		}
 	}
 
 	return grid, nil
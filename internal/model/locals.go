@@ -1,23 +1,85 @@
 // SPDX-License-Identifier: MIT
 // Copyright (c) 2025 Vladyslav Kazantsev
 //
-// This file provides a struct to formally recognize the HCL `locals` block.
+// This file defines the Local structure and the logic for parsing `locals`
+// blocks from a grid.
 //
-// Why recognize but not process this block?
+// Why have locals?
 //
-// The purpose of this struct is to make the HCL parser aware of the `locals`
-// block. This prevents the parser from throwing an "unrecognized block" error,
-// allowing users to include standard HCL constructs in their files. The actual
-// processing and evaluation of locals is handled by the HCL evaluation context
-// at a later stage, not during initial model parsing.
+// Grids repeat themselves: the same URL prefix in every request, the same
+// computed payload in several steps. A `locals` block gives such a value a name
+// once, and expressions use it as `local.<name>`. A local may reference
+// variables, other locals and step outputs.
+//
+// Like step attributes, locals are kept as raw expressions. Ordering them by
+// their references and evaluating them is done later (see package locals), once
+// variables are known and, for locals that read step outputs, once those
+// outputs exist.
 package model
 
-// (A nearly identical comment would go into `variable.go`)
+import (
+	"fmt"
+	"sort"
+
+	"github.com/hashicorp/hcl/v2"
+)
+
+// Local is a named value declared in a `locals` block.
+type Local struct {
+	// Name is the name of the local, taken from the attribute name.
+	// For example, in `locals { base_url = "..." }`, the Name is "base_url".
+	Name string
 
-import "github.com/hashicorp/hcl/v2"
+	// Expr is the unevaluated value of the local.
+	Expr hcl.Expression
+
+	// DeclRange is the source range of the attribute.
+	DeclRange hcl.Range
+}
 
 // hclLocalsBlock is a struct to allow the parser to recognize `locals` blocks.
-// Its body is not processed at this stage.
+// Its body holds only attributes, one per local.
 type hclLocalsBlock struct {
 	Body hcl.Body `hcl:",remain"`
 }
+
+// NewLocalsFromHCL converts a decoded `locals` block into Locals, in
+// declaration order.
+func NewLocalsFromHCL(block *hclLocalsBlock) ([]*Local, hcl.Diagnostics) {
+	attrs, diags := block.Body.JustAttributes()
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	locals := make([]*Local, 0, len(attrs))
+	for _, attr := range attrs {
+		locals = append(locals, &Local{
+			Name:      attr.Name,
+			Expr:      attr.Expr,
+			DeclRange: attr.Range,
+		})
+	}
+	sort.Slice(locals, func(i, j int) bool {
+		return locals[i].DeclRange.Start.Byte < locals[j].DeclRange.Start.Byte
+	})
+	return locals, diags
+}
+
+// addLocals appends locals to the grid, rejecting names that are already
+// declared, possibly in another block or file.
+func (g *Grid) addLocals(locals []*Local) error {
+	for _, l := range locals {
+		for _, prev := range g.Locals {
+			if prev.Name == l.Name {
+				return fmt.Errorf("duplicate local value: %w", hcl.Diagnostics{{
+					Severity: hcl.DiagError,
+					Summary:  "Duplicate local value definition",
+					Detail:   fmt.Sprintf("A local value named '%s' was already declared at %s.", l.Name, prev.DeclRange.String()),
+					Subject:  l.DeclRange.Ptr(),
+				}})
+			}
+		}
+		g.Locals = append(g.Locals, l)
+	}
+	return nil
+}
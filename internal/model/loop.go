@@ -14,9 +14,22 @@ package model
 
 import (
 	"github.com/hashicorp/hcl/v2"
+	"github.com/specialistvlad/burstgridgo/internal/bggoexpr"
 	"github.com/zclconf/go-cty/cty"
 )
 
+// isLiteral reports whether expr can be evaluated without any context: it
+// references nothing and calls no functions. Other loop expressions are
+// validated by the compiler, which evaluates them with the function table.
+func isLiteral(expr hcl.Expression) bool {
+	if len(expr.Variables()) > 0 {
+		return false
+	}
+	c := bggoexpr.NewContainer()
+	c.Add(expr)
+	return len(c.CalledFunctions()) == 0
+}
+
 // parseCount finds the "count" attribute and performs static type validation on it.
 func parseCount(attrs hcl.Attributes) (hcl.Expression, hcl.Diagnostics) {
 	var diags hcl.Diagnostics
@@ -26,7 +39,7 @@ func parseCount(attrs hcl.Attributes) (hcl.Expression, hcl.Diagnostics) {
 	}
 
 	// If the expression is a literal value, we can validate its type right now.
-	if len(countAttr.Expr.Variables()) == 0 {
+	if isLiteral(countAttr.Expr) {
 		val, valDiags := countAttr.Expr.Value(nil)
 		diags = append(diags, valDiags...)
 		if valDiags.HasErrors() {
@@ -66,7 +79,7 @@ func parseForEach(attrs hcl.Attributes) (hcl.Expression, hcl.Diagnostics) {
 	}
 
 	// If the expression is a literal, we can validate its type.
-	if len(forEachAttr.Expr.Variables()) == 0 {
+	if isLiteral(forEachAttr.Expr) {
 		val, valDiags := forEachAttr.Expr.Value(nil)
 		diags = append(diags, valDiags...)
 		if valDiags.HasErrors() {
@@ -74,7 +87,7 @@ func parseForEach(attrs hcl.Attributes) (hcl.Expression, hcl.Diagnostics) {
 		}
 
 		ty := val.Type()
-		isCollection := ty.IsTupleType() || ty.IsListType() || ty.IsSetType() || ty.IsMapType()
+		isCollection := ty.IsTupleType() || ty.IsListType() || ty.IsSetType() || ty.IsMapType() || ty.IsObjectType()
 
 		if !isCollection {
 			diags = append(diags, &hcl.Diagnostic{
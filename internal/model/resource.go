@@ -0,0 +1,180 @@
+// SPDX-License-Identifier: MIT
+// Copyright (c) 2025 Vladyslav Kazantsev
+//
+// This file defines the Resource structure and the logic for parsing `resource`
+// blocks from a grid.
+//
+// Why have resources?
+//
+// A step is a transient action; a resource is a long-lived thing that steps
+// share, such as an authenticated client (see ADR-001). Steps name the
+// resources they consume in their `uses` attribute:
+//
+//	resource "http_client" "shared" {
+//	  env = { HTTPS_PROXY = "http://proxy:3128" }
+//	}
+//
+//	step "http_request" "get" {
+//	  uses = [resource.http_client.shared]
+//	}
+//
+// A resource's `env` is part of the environment of every step that uses it: it
+// is applied as an overlay for that step alone, beneath the step's own `env`,
+// and the process environment is never modified.
+//
+// Like steps, resources are kept as raw expressions and evaluated later.
+package model
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/specialistvlad/burstgridgo/internal/bggohcl"
+)
+
+// resourceRoot is the traversal root name of resource references.
+const resourceRoot = "resource"
+
+// Resource is the format-agnostic representation of a `resource` block.
+type Resource struct {
+	// AssetType is the type of asset the resource is an instance of, taken
+	// from the first block label. For example, in
+	// `resource "http_client" "shared" {}`, the AssetType is "http_client".
+	AssetType string
+
+	// Name is the name of the resource, taken from the second block label.
+	Name string
+
+	// Arguments are the unevaluated attributes of the `arguments` block.
+	Arguments map[string]hcl.Expression
+
+	// Env is the unevaluated `env` attribute, a map of environment variables
+	// for the steps that use the resource, or nil.
+	Env hcl.Expression
+
+	// FSInformation records the file the resource was declared in.
+	FSInformation *FSInfo
+
+	// DeclRange is the source range of the block header.
+	DeclRange hcl.Range
+}
+
+// Address returns the reference to the resource, `resource.<type>.<name>`.
+func (r *Resource) Address() string {
+	return fmt.Sprintf("%s.%s.%s", resourceRoot, r.AssetType, r.Name)
+}
+
+// Expressions returns every expression of the resource.
+func (r *Resource) Expressions() []hcl.Expression {
+	exprs := make([]hcl.Expression, 0, len(r.Arguments)+1)
+	for _, expr := range r.Arguments {
+		exprs = append(exprs, expr)
+	}
+	if r.Env != nil {
+		exprs = append(exprs, r.Env)
+	}
+	return exprs
+}
+
+// hclResourceBlock represents a single 'resource' block for initial decoding
+// from HCL.
+type hclResourceBlock struct {
+	Type     string    `hcl:"type,label"`
+	Name     string    `hcl:"name,label"`
+	Body     hcl.Body  `hcl:",remain"`
+	DefRange hcl.Range `hcl:",def_range"`
+}
+
+// resourceBodySchema defines the expected structure of a `resource` block's body.
+var resourceBodySchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{{Name: "env"}},
+	Blocks:     []hcl.BlockHeaderSchema{{Type: "arguments"}},
+}
+
+// NewResourceFromHCL creates a new Resource from a parsed HCL resource block.
+func NewResourceFromHCL(block *hclResourceBlock, filePath string) (*Resource, hcl.Diagnostics) {
+	r := &Resource{
+		AssetType:     block.Type,
+		Name:          block.Name,
+		FSInformation: NewFSInfo(filePath),
+		DeclRange:     block.DefRange,
+	}
+
+	content, diags := block.Body.Content(resourceBodySchema)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	if attr, exists := content.Attributes["env"]; exists {
+		r.Env = attr.Expr
+	}
+
+	argBlock, blockDiags := bggohcl.FindUniqueBlock(content.Blocks, "arguments")
+	diags = append(diags, blockDiags...)
+	if argBlock != nil && !blockDiags.HasErrors() {
+		var argDiags hcl.Diagnostics
+		r.Arguments, argDiags = parseArguments(argBlock)
+		diags = append(diags, argDiags...)
+	}
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	return r, diags
+}
+
+// addResources appends resources to the grid, rejecting addresses that are
+// already declared, possibly in another file.
+func (g *Grid) addResources(resources []*Resource) error {
+	for _, r := range resources {
+		for _, prev := range g.Resources {
+			if prev.Address() == r.Address() {
+				return fmt.Errorf("duplicate resource: %w", hcl.Diagnostics{{
+					Severity: hcl.DiagError,
+					Summary:  "Duplicate resource definition",
+					Detail:   fmt.Sprintf("A resource named '%s' was already declared at %s.", r.Address(), prev.DeclRange.String()),
+					Subject:  r.DeclRange.Ptr(),
+				}})
+			}
+		}
+		g.Resources = append(g.Resources, r)
+	}
+	return nil
+}
+
+// Resource returns the declared resource with the given type and name, or nil.
+func (g *Grid) Resource(assetType, name string) *Resource {
+	for _, r := range g.Resources {
+		if r.AssetType == assetType && r.Name == name {
+			return r
+		}
+	}
+	return nil
+}
+
+// ResourceReferences returns the `resource.*` traversals in the step's `uses`
+// attribute, in source order.
+func (s *Step) ResourceReferences() []hcl.Traversal {
+	if s.Uses == nil {
+		return nil
+	}
+	var travs []hcl.Traversal
+	for _, trav := range s.Uses.Variables() {
+		if trav.RootName() == resourceRoot {
+			travs = append(travs, trav)
+		}
+	}
+	return travs
+}
+
+// ParseResourceReference extracts the type and name from a
+// `resource.<type>.<name>` traversal.
+func ParseResourceReference(trav hcl.Traversal) (assetType, name string, ok bool) {
+	if len(trav) < 3 || trav.RootName() != resourceRoot {
+		return "", "", false
+	}
+	typ, ok1 := trav[1].(hcl.TraverseAttr)
+	nm, ok2 := trav[2].(hcl.TraverseAttr)
+	if !ok1 || !ok2 {
+		return "", "", false
+	}
+	return typ.Name, nm.Name, true
+}
@@ -29,6 +29,7 @@ import (
 	"fmt"
 
 	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/ext/typeexpr"
 	"github.com/hashicorp/hcl/v2/gohcl"
 	"github.com/specialistvlad/burstgridgo/internal/bggohcl"
 	"github.com/zclconf/go-cty/cty"
@@ -44,6 +45,10 @@ type RunnerInputDefinition struct {
 	// Type is the value type that this input is expected to have.
 	Type cty.Type
 
+	// TypeDefaults holds the defaults of optional object attributes in Type,
+	// or is nil if there are none.
+	TypeDefaults *typeexpr.Defaults
+
 	// Description is an optional markdown string that describes the input's purpose.
 	Description string
 
@@ -102,7 +107,7 @@ func parseRunnerInputs(blocks hcl.Blocks) (map[string]RunnerInputDefinition, hcl
 			continue
 		}
 
-		ctyType, typeDiags := bggohcl.HCLTypeToCtyType(typeAttr.Expr)
+		ctyType, typeDefaults, typeDiags := bggohcl.HCLTypeToCtyType(typeAttr.Expr)
 		diags = append(diags, typeDiags...)
 		if typeDiags.HasErrors() {
 			continue
@@ -124,8 +129,11 @@ func parseRunnerInputs(blocks hcl.Blocks) (map[string]RunnerInputDefinition, hcl
 				continue
 			}
 
-			// Ensure the default value's type conforms to the declared type.
-			if !val.Type().Equals(ctyType) {
+			// Ensure the default value conforms to the declared type. Literal
+			// collections are converted (a tuple to a list, say), but the
+			// primitive values in them must already have the declared types.
+			converted, err := bggohcl.ConvertToType(val, ctyType, typeDefaults)
+			if err != nil || !primitivesConform(val, ctyType) {
 				diags = append(diags, &hcl.Diagnostic{
 					Severity: hcl.DiagError,
 					Summary:  "Invalid default value type",
@@ -134,16 +142,60 @@ func parseRunnerInputs(blocks hcl.Blocks) (map[string]RunnerInputDefinition, hcl
 				})
 				continue
 			}
-			defaultValue = &val
+			defaultValue = &converted
 		}
 
 		inputs[inputName] = RunnerInputDefinition{
-			Name:        inputName,
-			Type:        ctyType,
-			Description: description,
-			Default:     defaultValue,
+			Name:         inputName,
+			Type:         ctyType,
+			TypeDefaults: typeDefaults,
+			Description:  description,
+			Default:      defaultValue,
 		}
 	}
 
 	return inputs, diags
 }
+
+// primitivesConform reports whether every primitive value in val has exactly
+// the type declared for it by ty, so that, for example, a number is not
+// accepted where a string is declared.
+func primitivesConform(val cty.Value, ty cty.Type) bool {
+	if val.IsNull() || !val.IsKnown() || ty == cty.DynamicPseudoType {
+		return true
+	}
+	switch {
+	case ty.IsPrimitiveType():
+		return val.Type().Equals(ty)
+	case !val.CanIterateElements():
+		return false
+	}
+
+	for it := val.ElementIterator(); it.Next(); {
+		k, v := it.Element()
+		var elemType cty.Type
+		switch {
+		case ty.IsListType(), ty.IsSetType(), ty.IsMapType():
+			elemType = ty.ElementType()
+		case ty.IsTupleType():
+			types := ty.TupleElementTypes()
+			if k.Type() != cty.Number {
+				return false
+			}
+			i, _ := k.AsBigFloat().Int64()
+			if int(i) >= len(types) {
+				return false
+			}
+			elemType = types[i]
+		case ty.IsObjectType():
+			if k.Type() != cty.String || !ty.HasAttribute(k.AsString()) {
+				continue // reported by the conversion
+			}
+			elemType = ty.AttributeType(k.AsString())
+		}
+		if !primitivesConform(v, elemType) {
+			return false
+		}
+	}
+	return true
+}
@@ -28,6 +28,7 @@ import (
 	"fmt"
 
 	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/ext/typeexpr"
 	"github.com/hashicorp/hcl/v2/gohcl"
 	"github.com/specialistvlad/burstgridgo/internal/bggohcl"
 	"github.com/zclconf/go-cty/cty"
@@ -35,9 +36,13 @@ import (
 
 // RunnerOutputDefinition defines a single output value from a runner.
 type RunnerOutputDefinition struct {
-	Name        string
-	Type        cty.Type
-	Description string
+	Name string
+	Type cty.Type
+	// TypeDefaults holds the defaults of optional object attributes in Type,
+	// or is nil if there are none. They fill in the attributes a handler
+	// leaves out of its result.
+	TypeDefaults *typeexpr.Defaults
+	Description  string
 }
 
 // outputBodySchema is the HCL schema for the body of an `output` block.
@@ -76,7 +81,7 @@ func parseRunnerOutputs(blocks hcl.Blocks) (map[string]RunnerOutputDefinition, h
 
 		// The schema enforces that 'type' is required, so we can safely access it.
 		typeAttr := bodyContent.Attributes["type"]
-		ctyType, typeDiags := bggohcl.HCLTypeToCtyType(typeAttr.Expr)
+		ctyType, typeDefaults, typeDiags := bggohcl.HCLTypeToCtyType(typeAttr.Expr)
 		diags = append(diags, typeDiags...)
 		if typeDiags.HasErrors() {
 			continue
@@ -90,9 +95,10 @@ func parseRunnerOutputs(blocks hcl.Blocks) (map[string]RunnerOutputDefinition, h
 		}
 
 		outputs[outputName] = RunnerOutputDefinition{
-			Name:        outputName,
-			Type:        ctyType,
-			Description: description,
+			Name:         outputName,
+			Type:         ctyType,
+			TypeDefaults: typeDefaults,
+			Description:  description,
 		}
 	}
 
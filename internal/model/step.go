@@ -34,6 +34,7 @@ type Step struct {
 	RunnerType    string
 	Name          string
 	FSInformation *FSInfo
+	DeclRange     hcl.Range
 
 	// Core Attributes
 	Enabled     *hcl.Expression
@@ -47,6 +48,9 @@ type Step struct {
 	Count   hcl.Expression
 	ForEach hcl.Expression
 
+	// Explicit ordering
+	DependsOn hcl.Expression
+
 	// Execution Control
 	Priority    hcl.Expression
 	DelayBefore hcl.Expression
@@ -89,9 +93,10 @@ func NewStep() *Step {
 
 // hclStep represents a single 'step' block for initial decoding from HCL.
 type hclStep struct {
-	Type string   `hcl:"type,label"`
-	Name string   `hcl:"name,label"`
-	Body hcl.Body `hcl:",remain"`
+	Type     string    `hcl:"type,label"`
+	Name     string    `hcl:"name,label"`
+	Body     hcl.Body  `hcl:",remain"`
+	DefRange hcl.Range `hcl:",def_range"`
 }
 
 // NewStepFromHCL creates a new Step from a parsed HCL step block.
@@ -99,6 +104,7 @@ func NewStepFromHCL(parsedStep *hclStep, filePath string) (*Step, hcl.Diagnostic
 	step := NewStep()
 	step.RunnerType = parsedStep.Type
 	step.Name = parsedStep.Name
+	step.DeclRange = parsedStep.DefRange
 
 	step.FSInformation = NewFSInfo(filePath)
 
@@ -128,9 +134,10 @@ func NewStepFromHCL(parsedStep *hclStep, filePath string) (*Step, hcl.Diagnostic
 	allDiags = append(allDiags, specialDiags...)
 	step.Expressions.Add(step.ForEach)
 
-	depsExpr, depDiags := parseDependsOn(bodyContent.Attributes)
+	var depDiags hcl.Diagnostics
+	step.DependsOn, depDiags = parseDependsOn(bodyContent.Attributes)
 	allDiags = append(allDiags, depDiags...)
-	step.Expressions.Add(depsExpr)
+	step.Expressions.Add(step.DependsOn)
 
 	// --- Handle nested blocks ---
 	if argBlock, diags := bggohcl.FindUniqueBlock(bodyContent.Blocks, "arguments"); diags.HasErrors() {
@@ -1,23 +1,177 @@
 // SPDX-License-Identifier: MIT
 // Copyright (c) 2025 Vladyslav Kazantsev
 //
-// This file provides a struct to formally recognize the HCL `locals` block.
+// This file defines the Variable structure and the logic for parsing `variable`
+// blocks from a grid.
 //
-// Why recognize but not process this block?
+// Why have variables?
 //
-// The purpose of this struct is to make the HCL parser aware of the `locals`
-// block. This prevents the parser from throwing an "unrecognized block" error,
-// allowing users to include standard HCL constructs in their files. The actual
-// processing and evaluation of locals is handled by the HCL evaluation context
-// at a later stage, not during initial model parsing.
+// A grid often needs to run against different targets: a staging URL today, a
+// production URL tomorrow, a different token on every machine. Variables make
+// those values inputs of the grid rather than edits to it. A `variable` block
+// declares the input's type, an optional default and the rules its value must
+// satisfy; the value itself is supplied at run time and exposed to expressions
+// as `var.<name>`.
+//
+// Only the declaration is parsed here. Values come from outside the grid, so
+// they are resolved against these declarations later (see package variables).
 package model
 
-// (A nearly identical comment would go into `variable.go`)
+import (
+	"fmt"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/ext/typeexpr"
+	"github.com/hashicorp/hcl/v2/gohcl"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/specialistvlad/burstgridgo/internal/bggohcl"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// Variable is a grid input declared by a `variable` block.
+type Variable struct {
+	// Name is the name of the variable, taken from the HCL block label.
+	// For example, in `variable "target" {}`, the Name is "target".
+	Name string
+
+	// Type is the type values are converted to. It is cty.DynamicPseudoType
+	// when the block declares no `type`, accepting a value of any type.
+	Type cty.Type
+
+	// TypeDefaults holds the defaults of optional object attributes in Type,
+	// or is nil if there are none.
+	TypeDefaults *typeexpr.Defaults
+
+	// Default is the value used when none is supplied. If this field is nil,
+	// the variable is required.
+	Default *cty.Value
+
+	// Description is an optional markdown string that describes the variable.
+	Description string
 
-import "github.com/hashicorp/hcl/v2"
+	// Sensitive marks the value as sensitive, redacting it from logs and
+	// reports wherever it is used.
+	Sensitive bool
+
+	// Validations are the rules the value must satisfy, in declaration order.
+	Validations []*VariableValidation
+
+	// DeclRange is the source range of the block header.
+	DeclRange hcl.Range
+}
+
+// VariableValidation is a `validation` block of a variable. Both expressions
+// are evaluated with `var` in scope once the variable's value is known.
+type VariableValidation struct {
+	// Condition must evaluate to true for the value to be accepted.
+	Condition hcl.Expression
+
+	// ErrorMessage is reported when Condition is false.
+	ErrorMessage hcl.Expression
+
+	// DeclRange is the source range of the block header.
+	DeclRange hcl.Range
+}
 
 // hclVariableBlock is a struct to allow the parser to recognize `variable` blocks.
 type hclVariableBlock struct {
 	Name string   `hcl:"name,label"`
 	Body hcl.Body `hcl:",remain"`
+
+	DefRange hcl.Range `hcl:",def_range"`
+}
+
+// variableBodySchema is the HCL schema for the body of a `variable` block.
+var variableBodySchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{Name: "type"},
+		{Name: "default"},
+		{Name: "description"},
+		{Name: "sensitive"},
+	},
+	Blocks: []hcl.BlockHeaderSchema{
+		{Type: "validation"},
+	},
+}
+
+// validationBodySchema is the HCL schema for the body of a `validation` block.
+var validationBodySchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{Name: "condition", Required: true},
+		{Name: "error_message", Required: true},
+	},
+}
+
+// NewVariableFromHCL converts a decoded `variable` block into a Variable.
+func NewVariableFromHCL(block *hclVariableBlock) (*Variable, hcl.Diagnostics) {
+	v := &Variable{
+		Name:      block.Name,
+		Type:      cty.DynamicPseudoType,
+		DeclRange: block.DefRange,
+	}
+	if !hclsyntax.ValidIdentifier(block.Name) {
+		return nil, hcl.Diagnostics{{
+			Severity: hcl.DiagError,
+			Summary:  "Invalid variable name",
+			Detail:   fmt.Sprintf("%q is not a valid variable name: a name must start with a letter or underscore and may contain only letters, digits, underscores, and dashes.", block.Name),
+			Subject:  block.DefRange.Ptr(),
+		}}
+	}
+
+	content, diags := block.Body.Content(variableBodySchema)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	if attr, exists := content.Attributes["type"]; exists {
+		typ, defaults, typeDiags := bggohcl.HCLTypeToCtyType(attr.Expr)
+		diags = append(diags, typeDiags...)
+		if typeDiags.HasErrors() {
+			return nil, diags
+		}
+		v.Type = typ
+		v.TypeDefaults = defaults
+	}
+	if attr, exists := content.Attributes["description"]; exists {
+		diags = append(diags, gohcl.DecodeExpression(attr.Expr, nil, &v.Description)...)
+	}
+	if attr, exists := content.Attributes["sensitive"]; exists {
+		diags = append(diags, gohcl.DecodeExpression(attr.Expr, nil, &v.Sensitive)...)
+	}
+	if attr, exists := content.Attributes["default"]; exists {
+		// A nil eval context is used because defaults must be literal values.
+		val, valDiags := attr.Expr.Value(nil)
+		diags = append(diags, valDiags...)
+		if !valDiags.HasErrors() {
+			converted, err := bggohcl.ConvertToType(val, v.Type, v.TypeDefaults)
+			if err != nil {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Invalid default value type",
+					Detail:   fmt.Sprintf("The default value for '%s' is not compatible with its type, '%s': %s.", v.Name, v.Type.FriendlyName(), err),
+					Subject:  attr.Expr.Range().Ptr(),
+				})
+			} else {
+				v.Default = &converted
+			}
+		}
+	}
+
+	for _, vb := range content.Blocks.OfType("validation") {
+		vc, vDiags := vb.Body.Content(validationBodySchema)
+		diags = append(diags, vDiags...)
+		if vDiags.HasErrors() {
+			continue
+		}
+		v.Validations = append(v.Validations, &VariableValidation{
+			Condition:    vc.Attributes["condition"].Expr,
+			ErrorMessage: vc.Attributes["error_message"].Expr,
+			DeclRange:    vb.DefRange,
+		})
+	}
+
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	return v, diags
 }
@@ -1,7 +1,11 @@
 // Package node defines the core data structures for a single unit of work in the graph.
 package node
 
-import "github.com/specialistvlad/burstgridgo/internal/nodeid"
+import (
+	"github.com/specialistvlad/burstgridgo/internal/model"
+	"github.com/specialistvlad/burstgridgo/internal/nodeid"
+	"github.com/zclconf/go-cty/cty"
+)
 
 // Node represents a single, static definition of a unit of work in the DAG.
 // It contains the information parsed from the HCL configuration but does not
@@ -18,11 +22,41 @@ type Node struct {
 	// which will be decoded and validated by a specific handler.
 	RawConfig map[string]any
 
+	// Config is the parsed step this node was compiled from. Its expressions
+	// are evaluated by the builder when the node becomes ready.
+	Config *model.Step
+
+	// Instance is set when the node is one of several instances created from
+	// a step with `count` or `for_each`. It is nil for singular steps.
+	Instance *Instance
+
+	// Placeholder marks an unexpanded step whose `count` or `for_each` depends
+	// on upstream outputs. When it becomes ready the executor evaluates the
+	// expression and replaces it with concrete instances (see topologystore.Store.Expand).
+	Placeholder bool
+
 	// Dependencies holds the string addresses of the nodes that must be
 	// successfully completed before this node can run.
 	Dependencies []string
 }
 
+// Instance identifies one expansion of a repeated step and carries the values
+// exposed to its expressions as `count.index` or `each.key`/`each.value`.
+type Instance struct {
+	// ForEach reports whether the instance comes from `for_each` rather than `count`.
+	ForEach bool
+
+	// Index is the zero-based position of the instance. For `count` it is the
+	// value of `count.index`; for `for_each` it follows the sorted key order.
+	Index int
+
+	// Key is the `for_each` key (a string). It is cty.NilVal for `count`.
+	Key cty.Value
+
+	// Value is the `for_each` element value. It is cty.NilVal for `count`.
+	Value cty.Value
+}
+
 // Status represents the execution state of a Node during a run.
 type Status string
 
@@ -19,7 +19,12 @@ func (a *Address) String() string {
 			sb.WriteRune('.')
 		}
 		sb.WriteString(segment.Name)
-		if segment.Index != -1 {
+		switch {
+		case segment.Keyed:
+			sb.WriteRune('[')
+			writeQuoted(&sb, segment.Key)
+			sb.WriteRune(']')
+		case segment.Index != -1:
 			sb.WriteString(fmt.Sprintf("[%d]", segment.Index))
 		}
 	}
@@ -34,3 +39,29 @@ func (a *Address) Equal(other *Address) bool {
 	}
 	return reflect.DeepEqual(a.Path, other.Path)
 }
+
+// writeQuoted writes key as a double-quoted string. Quotes, backslashes and
+// control characters are escaped so that Parse can read the key back exactly.
+func writeQuoted(sb *strings.Builder, key string) {
+	sb.WriteRune('"')
+	for _, r := range key {
+		switch r {
+		case '"', '\\':
+			sb.WriteRune('\\')
+			sb.WriteRune(r)
+		case '\n':
+			sb.WriteString(`\n`)
+		case '\r':
+			sb.WriteString(`\r`)
+		case '\t':
+			sb.WriteString(`\t`)
+		default:
+			if r < 0x20 || r == 0x7f {
+				fmt.Fprintf(sb, `\u%04x`, r)
+				continue
+			}
+			sb.WriteRune(r)
+		}
+	}
+	sb.WriteRune('"')
+}
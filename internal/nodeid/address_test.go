@@ -28,6 +28,13 @@ func TestAddress_String(t *testing.T) {
 			},
 			expectedStr: "db.users[0].posts[15]",
 		},
+		{
+			name: "path with string key",
+			addr: &Address{
+				Path: []PathSegment{NewPathSegment("step"), NewPathSegment("http_request"), NewPathSegmentWithKey("call", `us "east"`)},
+			},
+			expectedStr: `step.http_request.call["us \"east\""]`,
+		},
 		{
 			name:        "nil address",
 			addr:        nil,
@@ -47,6 +54,8 @@ func TestAddress_RoundTrip(t *testing.T) {
 		"a.b.c",
 		"db.users[0].posts[15]",
 		"http-client.get[0]",
+		`step.http_request.call["us-east"]`,
+		`step.print.each["a.b"].nested["\\ \" \t \u0001"]`,
 	}
 
 	for _, id := range testIDs {
@@ -70,11 +79,15 @@ func TestAddress_Equal(t *testing.T) {
 	addr3, _ := Parse("a.b[1]")
 	addr4, _ := Parse("a.c[0]")
 	addr5, _ := Parse("a.b[0]")
+	keyed1, _ := Parse(`a.b["0"]`)
+	keyed2, _ := Parse(`a.b["0"]`)
 
 	assert.True(t, addr1.Equal(addr2))
 	assert.False(t, addr1.Equal(addr3))
 	assert.False(t, addr1.Equal(addr4))
 	assert.True(t, addr1.Equal(addr5))
+	assert.True(t, keyed1.Equal(keyed2))
+	assert.False(t, addr1.Equal(keyed1), "an index and a key are never equal")
 	assert.False(t, addr1.Equal(nil))
 	assert.False(t, (*Address)(nil).Equal(addr1))
 	assert.True(t, (*Address)(nil).Equal(nil))
@@ -5,7 +5,10 @@ Package nodeid provides a structured, type-safe representation for node
 identifiers within the system, based on the canonical format `path`.
 
 The format is defined as a dot-separated sequence of segments,
-e.g., `a.b[0].c[1].d`.
+e.g., `a.b[0].c[1].d`. A segment may carry an integer index (`name[0]`) or a
+double-quoted string key (`name["us-east"]`), as produced by `count` and
+`for_each` respectively. Quotes and backslashes inside keys are escaped with
+a backslash, so every Address round-trips through String and Parse.
 
 This package enforces the identifier schema and centralizes all
 formatting and parsing logic, improving maintainability and robustness.
@@ -3,13 +3,15 @@ package nodeid
 
 import (
 	"fmt"
-	"regexp"
 	"strconv"
 	"strings"
+	"unicode/utf8"
 )
 
-// segmentRegex is used to parse a single segment of a path, e.g., `name` or `name[1]`.
-var segmentRegex = regexp.MustCompile(`^([a-zA-Z0-9_.-]+)(?:\[(\d+)\])?$`)
+// isNameChar reports whether c may appear in a segment name.
+func isNameChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-'
+}
 
 // isValidSegmentName checks for undesirable but technically valid names.
 func isValidSegmentName(name string) bool {
@@ -20,38 +22,140 @@ func isValidSegmentName(name string) bool {
 }
 
 // Parse creates a new Address struct by parsing its canonical string representation.
+//
+// Each dot-separated segment is a name optionally followed by an integer index
+// (`name[0]`) or a double-quoted string key (`name["us-east"]`). Keys may
+// contain any character, including dots and brackets; `"` and `\` must be
+// escaped with a backslash, and `\n`, `\r`, `\t` and `\uXXXX` are recognised.
 func Parse(rawID string) (*Address, error) {
 	if rawID == "" {
 		return nil, fmt.Errorf("identifier cannot be empty")
 	}
 
+	p := &parser{src: rawID}
 	addr := &Address{}
-	for _, segmentStr := range strings.Split(rawID, ".") {
-		if segmentStr == "" {
-			return nil, fmt.Errorf("identifier path contains empty segment")
+	for {
+		segment, err := p.segment()
+		if err != nil {
+			return nil, err
 		}
+		addr.Path = append(addr.Path, segment)
 
-		matches := segmentRegex.FindStringSubmatch(segmentStr)
-		if matches == nil {
-			return nil, fmt.Errorf("invalid path segment format: %q", segmentStr)
+		if p.done() {
+			return addr, nil
 		}
+		if p.src[p.pos] != '.' {
+			return nil, fmt.Errorf("invalid path segment format: unexpected %q at offset %d", p.src[p.pos], p.pos)
+		}
+		p.pos++
+	}
+}
+
+// parser is a cursor over the identifier being parsed.
+type parser struct {
+	src string
+	pos int
+}
 
-		name := matches[1]
-		if !isValidSegmentName(name) {
-			return nil, fmt.Errorf("invalid segment name: %q", name)
+func (p *parser) done() bool {
+	return p.pos >= len(p.src)
+}
+
+// segment reads a single `name`, `name[index]` or `name["key"]` segment.
+func (p *parser) segment() (PathSegment, error) {
+	start := p.pos
+	for !p.done() && isNameChar(p.src[p.pos]) {
+		p.pos++
+	}
+	name := p.src[start:p.pos]
+	if name == "" {
+		if p.done() || p.src[p.pos] == '.' {
+			return PathSegment{}, fmt.Errorf("identifier path contains empty segment")
 		}
+		return PathSegment{}, fmt.Errorf("invalid path segment format: unexpected %q at offset %d", p.src[p.pos], p.pos)
+	}
+	if !isValidSegmentName(name) {
+		return PathSegment{}, fmt.Errorf("invalid segment name: %q", name)
+	}
 
-		segment := NewPathSegment(name)
-		if len(matches) > 2 && matches[2] != "" {
-			index, err := strconv.Atoi(matches[2])
-			if err != nil {
-				// Unreachable due to regex `\d+`
-				return nil, fmt.Errorf("internal error parsing index: %w", err)
-			}
-			segment.Index = index
+	if p.done() || p.src[p.pos] != '[' {
+		return NewPathSegment(name), nil
+	}
+	p.pos++
+
+	var segment PathSegment
+	if !p.done() && p.src[p.pos] == '"' {
+		key, err := p.quoted()
+		if err != nil {
+			return PathSegment{}, fmt.Errorf("invalid key in segment %q: %w", name, err)
 		}
-		addr.Path = append(addr.Path, segment)
+		segment = NewPathSegmentWithKey(name, key)
+	} else {
+		digits := p.pos
+		for !p.done() && p.src[p.pos] >= '0' && p.src[p.pos] <= '9' {
+			p.pos++
+		}
+		if digits == p.pos {
+			return PathSegment{}, fmt.Errorf("invalid path segment format: segment %q has an index that is neither a number nor a quoted key", name)
+		}
+		index, err := strconv.Atoi(p.src[digits:p.pos])
+		if err != nil {
+			return PathSegment{}, fmt.Errorf("invalid index in segment %q: %w", name, err)
+		}
+		segment = NewPathSegmentWithIndex(name, index)
 	}
 
-	return addr, nil
+	if p.done() || p.src[p.pos] != ']' {
+		return PathSegment{}, fmt.Errorf("invalid path segment format: segment %q is missing a closing ']'", name)
+	}
+	p.pos++
+	return segment, nil
+}
+
+// quoted reads a double-quoted, backslash-escaped string starting at the
+// opening quote and returns its unescaped contents.
+func (p *parser) quoted() (string, error) {
+	p.pos++ // opening quote
+	var sb strings.Builder
+	for !p.done() {
+		c := p.src[p.pos]
+		switch {
+		case c == '"':
+			p.pos++
+			return sb.String(), nil
+		case c == '\\':
+			if p.pos+1 >= len(p.src) {
+				return "", fmt.Errorf("unterminated escape sequence")
+			}
+			esc := p.src[p.pos+1]
+			p.pos += 2
+			switch esc {
+			case '"', '\\':
+				sb.WriteByte(esc)
+			case 'n':
+				sb.WriteByte('\n')
+			case 'r':
+				sb.WriteByte('\r')
+			case 't':
+				sb.WriteByte('\t')
+			case 'u':
+				if p.pos+4 > len(p.src) {
+					return "", fmt.Errorf("incomplete \\u escape")
+				}
+				r, err := strconv.ParseUint(p.src[p.pos:p.pos+4], 16, 32)
+				if err != nil {
+					return "", fmt.Errorf("invalid \\u escape %q", p.src[p.pos:p.pos+4])
+				}
+				sb.WriteRune(rune(r))
+				p.pos += 4
+			default:
+				return "", fmt.Errorf("unknown escape sequence \\%c", esc)
+			}
+		default:
+			r, size := utf8.DecodeRuneInString(p.src[p.pos:])
+			sb.WriteRune(r)
+			p.pos += size
+		}
+	}
+	return "", fmt.Errorf("unterminated quoted key")
 }
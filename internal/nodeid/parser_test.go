@@ -39,6 +39,50 @@ func TestParse(t *testing.T) {
 				Path: []PathSegment{NewPathSegment("http"), NewPathSegmentWithIndex("request", 0)},
 			},
 		},
+		{
+			name:      "string key",
+			rawID:     `step.http_request.call["us-east"]`,
+			expectErr: false,
+			expectedAddr: &Address{
+				Path: []PathSegment{NewPathSegment("step"), NewPathSegment("http_request"), NewPathSegmentWithKey("call", "us-east")},
+			},
+		},
+		{
+			name:      "string key with dots, brackets and escapes",
+			rawID:     `a.b["x.y[0] \"q\" \\ \n \u00e9"].c`,
+			expectErr: false,
+			expectedAddr: &Address{
+				Path: []PathSegment{NewPathSegment("a"), NewPathSegmentWithKey("b", "x.y[0] \"q\" \\ \n é"), NewPathSegment("c")},
+			},
+		},
+		{
+			name:      "empty string key",
+			rawID:     `a.b[""]`,
+			expectErr: false,
+			expectedAddr: &Address{
+				Path: []PathSegment{NewPathSegment("a"), NewPathSegmentWithKey("b", "")},
+			},
+		},
+		{
+			name:      "error - unterminated key",
+			rawID:     `a.b["x]`,
+			expectErr: true,
+		},
+		{
+			name:      "error - unknown escape",
+			rawID:     `a.b["\x"]`,
+			expectErr: true,
+		},
+		{
+			name:      "error - missing closing bracket",
+			rawID:     `a.b["x"`,
+			expectErr: true,
+		},
+		{
+			name:      "error - trailing characters after key",
+			rawID:     `a.b["x"]c`,
+			expectErr: true,
+		},
 		{
 			name:      "error - empty path segment",
 			rawID:     "a..b",
@@ -1,10 +1,13 @@
 // internal/nodeid/types.go
 package nodeid
 
-// PathSegment represents a single component of an address path, e.g., `name[index]`.
+// PathSegment represents a single component of an address path, e.g.,
+// `name`, `name[index]` or `name["key"]`.
 type PathSegment struct {
 	Name  string
-	Index int // -1 indicates no index is present.
+	Index int    // -1 indicates no index is present.
+	Key   string // Only meaningful when Keyed is true.
+	Keyed bool   // True for string-keyed segments such as `name["us-east"]`.
 }
 
 // NewPathSegment creates a new path segment without an index.
@@ -17,11 +20,22 @@ func NewPathSegmentWithIndex(name string, index int) PathSegment {
 	return PathSegment{Name: name, Index: index}
 }
 
+// NewPathSegmentWithKey creates a new path segment that includes a string key,
+// as used by `for_each` instances.
+func NewPathSegmentWithKey(name, key string) PathSegment {
+	return PathSegment{Name: name, Index: -1, Key: key, Keyed: true}
+}
+
 // HasIndex returns true if the path segment has an explicit index.
 func (ps PathSegment) HasIndex() bool {
 	return ps.Index != -1
 }
 
+// HasKey returns true if the path segment has an explicit string key.
+func (ps PathSegment) HasKey() bool {
+	return ps.Keyed
+}
+
 // Address is the structured representation of a unique node identifier.
 // It is modeled as a path, broken into segments.
 type Address struct {
@@ -122,4 +122,19 @@ type Store interface {
 	//
 	// Thread-safety: Must be safe to call concurrently with SetError calls.
 	GetError(ctx context.Context, id nodeid.Address) (error, error)
+
+	// SetAttempts records how many times the executor has started a node.
+	//
+	// Called by the executor before each attempt of a step with a `retry`
+	// policy, so that reports can show how many tries a node took.
+	//
+	// Thread-safety: Must be safe to call concurrently for different nodes.
+	SetAttempts(ctx context.Context, id nodeid.Address, attempts int) error
+
+	// GetAttempts retrieves the number of attempts recorded for a node.
+	//
+	// Returns 0 if the node has not been attempted yet.
+	//
+	// Thread-safety: Must be safe to call concurrently with SetAttempts calls.
+	GetAttempts(ctx context.Context, id nodeid.Address) (int, error)
 }
@@ -0,0 +1,194 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/specialistvlad/burstgridgo/internal/bggocty"
+	"github.com/specialistvlad/burstgridgo/internal/ctxlog"
+	"github.com/specialistvlad/burstgridgo/internal/model"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// ContractError reports every mismatch found between runner manifests and the
+// Go handlers that implement them (see ADR-005).
+type ContractError struct {
+	Mismatches []string
+}
+
+func (e *ContractError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d manifest/handler contract mismatch(es):", len(e.Mismatches))
+	for _, m := range e.Mismatches {
+		fmt.Fprintf(&b, "\n  - %s", m)
+	}
+	return b.String()
+}
+
+// ValidateContracts checks every loaded runner against its registered handler:
+//
+//   - lifecycle.on_run must name a registered, well-formed handler
+//   - every manifest input must have a Go Input field with a matching bggo tag
+//   - every bggo-tagged Go field must be declared as a manifest input
+//   - the Go field type must be able to hold the input's declared cty type
+//
+// All runners are checked before returning so that a single run reports every
+// mismatch. It returns a *ContractError when any mismatch is found.
+func (r *Registry) ValidateContracts(ctx context.Context) error {
+	logger := ctxlog.FromContext(ctx)
+
+	runners := append([]*model.Runner(nil), r.runnersRegistry...)
+	sort.Slice(runners, func(i, j int) bool { return runners[i].Type < runners[j].Type })
+
+	var mismatches []string
+	for _, rn := range runners {
+		mismatches = append(mismatches, r.runnerMismatches(rn)...)
+	}
+
+	if len(mismatches) > 0 {
+		return &ContractError{Mismatches: mismatches}
+	}
+	logger.Debug("Runner contracts validated.", "runners", len(runners))
+	return nil
+}
+
+// runnerMismatches returns the contract violations of a single runner.
+func (r *Registry) runnerMismatches(rn *model.Runner) []string {
+	where := fmt.Sprintf("runner %q", rn.Type)
+	if rn.FSInformation != nil {
+		where = fmt.Sprintf("runner %q (%s)", rn.Type, rn.FSInformation.FilePath)
+	}
+
+	if rn.Lifecycle.OnRun == "" {
+		return []string{fmt.Sprintf("%s: lifecycle.on_run is not set", where)}
+	}
+	handler, ok := r.handlersRegistry.Get(rn.Lifecycle.OnRun)
+	if !ok {
+		return []string{fmt.Sprintf("%s: on_run handler %q is not registered", where, rn.Lifecycle.OnRun)}
+	}
+	if err := handler.Validate(); err != nil {
+		return []string{fmt.Sprintf("%s: handler %q: %v", where, rn.Lifecycle.OnRun, err)}
+	}
+
+	input, _ := handler.NewInput() // Validate guarantees this succeeds.
+	fields, err := bggocty.InputFields(reflect.TypeOf(input))
+	if err != nil {
+		return []string{fmt.Sprintf("%s: handler %q: %v", where, rn.Lifecycle.OnRun, err)}
+	}
+
+	var mismatches []string
+	byName := make(map[string]bggocty.InputField, len(fields))
+	for _, f := range fields {
+		byName[f.Name] = f
+		if _, declared := rn.Inputs[f.Name]; !declared {
+			mismatches = append(mismatches, fmt.Sprintf("%s: Go field for %q has no matching input block in the manifest", where, f.Name))
+		}
+	}
+
+	names := make([]string, 0, len(rn.Inputs))
+	for name := range rn.Inputs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		def := rn.Inputs[name]
+		f, ok := byName[name]
+		if !ok {
+			mismatches = append(mismatches, fmt.Sprintf("%s: input %q has no Go field tagged `bggo:%q`", where, name, name))
+			continue
+		}
+		if !typeCompatible(def.Type, f.Type) {
+			mismatches = append(mismatches, fmt.Sprintf("%s: input %q is declared as %s but its Go field is %s",
+				where, name, def.Type.FriendlyName(), f.Type))
+		}
+	}
+	return mismatches
+}
+
+var bigFloatType = reflect.TypeOf(big.Float{})
+
+// typeCompatible reports whether a value of cty type ty can be decoded into a
+// Go value of type gt by bggocty.DecodeInputs.
+func typeCompatible(ty cty.Type, gt reflect.Type) bool {
+	for gt.Kind() == reflect.Pointer {
+		gt = gt.Elem()
+	}
+	if gt.Kind() == reflect.Interface {
+		return true
+	}
+
+	switch {
+	case ty == cty.DynamicPseudoType:
+		return false // only an interface field can hold a value of unknown type
+	case ty == cty.String:
+		return gt.Kind() == reflect.String
+	case ty == cty.Bool:
+		return gt.Kind() == reflect.Bool
+	case ty == cty.Number:
+		switch gt.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+			reflect.Float32, reflect.Float64:
+			return true
+		}
+		return gt == bigFloatType
+	case ty.IsListType() || ty.IsSetType():
+		return (gt.Kind() == reflect.Slice || gt.Kind() == reflect.Array) && typeCompatible(ty.ElementType(), gt.Elem())
+	case ty.IsMapType():
+		return gt.Kind() == reflect.Map && gt.Key().Kind() == reflect.String && typeCompatible(ty.ElementType(), gt.Elem())
+	case ty.IsTupleType():
+		if gt.Kind() != reflect.Slice && gt.Kind() != reflect.Array {
+			return false
+		}
+		for _, et := range ty.TupleElementTypes() {
+			if !typeCompatible(et, gt.Elem()) {
+				return false
+			}
+		}
+		return true
+	case ty.IsObjectType():
+		if gt.Kind() == reflect.Map {
+			if gt.Key().Kind() != reflect.String {
+				return false
+			}
+			for _, at := range ty.AttributeTypes() {
+				if !typeCompatible(at, gt.Elem()) {
+					return false
+				}
+			}
+			return true
+		}
+		if gt.Kind() != reflect.Struct {
+			return false
+		}
+		return structCompatible(ty, gt)
+	}
+	return false
+}
+
+// structCompatible checks an object type against a struct decoded by gocty,
+// which maps attributes to fields through `cty` tags.
+func structCompatible(ty cty.Type, gt reflect.Type) bool {
+	fields := make(map[string]reflect.Type)
+	for i := 0; i < gt.NumField(); i++ {
+		f := gt.Field(i)
+		if tag, ok := f.Tag.Lookup(bggocty.OutputTag); ok {
+			name, _, _ := strings.Cut(tag, ",")
+			fields[name] = f.Type
+		}
+	}
+	if len(fields) != len(ty.AttributeTypes()) {
+		return false
+	}
+	for name, at := range ty.AttributeTypes() {
+		ft, ok := fields[name]
+		if !ok || !typeCompatible(at, ft) {
+			return false
+		}
+	}
+	return true
+}
@@ -38,3 +38,13 @@ func (r *Registry) Runners() []*model.Runner {
 func (r *Registry) Handlers() *handlers.Handlers {
 	return &r.handlersRegistry
 }
+
+// Runner returns the loaded runner definition for the given runner type.
+func (r *Registry) Runner(runnerType string) (*model.Runner, bool) {
+	for _, rn := range r.runnersRegistry {
+		if rn.Type == runnerType {
+			return rn, true
+		}
+	}
+	return nil, false
+}
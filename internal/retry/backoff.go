@@ -0,0 +1,63 @@
+package retry
+
+import (
+	"math"
+	"time"
+)
+
+// Strategy names how the delay grows between attempts.
+type Strategy string
+
+const (
+	// StrategyConstant waits Initial before every retry.
+	StrategyConstant Strategy = "constant"
+
+	// StrategyLinear waits Initial, then grows by Initial*Factor per retry.
+	StrategyLinear Strategy = "linear"
+
+	// StrategyExponential waits Initial, then multiplies by Factor per retry.
+	StrategyExponential Strategy = "exponential"
+
+	// StrategyDecorrelatedJitter picks a random delay between Initial and three
+	// times the previous delay, spreading out retries from many callers.
+	StrategyDecorrelatedJitter Strategy = "decorrelated_jitter"
+)
+
+// Backoff is the evaluated form of a `backoff` block.
+type Backoff struct {
+	Strategy Strategy
+	Initial  time.Duration
+	Factor   float64
+	Max      time.Duration // 0 means uncapped
+	Jitter   float64       // fraction of each delay that is randomised, in [0, 1]
+}
+
+// Delay returns how long to wait before the given retry (1 for the first
+// retry, i.e. the second attempt). prev is the delay returned for the previous
+// retry and rnd returns a random number in [0, 1).
+func (b Backoff) Delay(retry int, prev time.Duration, rnd func() float64) time.Duration {
+	var d float64
+	initial := float64(b.Initial)
+	switch b.Strategy {
+	case StrategyConstant:
+		d = initial
+	case StrategyLinear:
+		d = initial * (1 + b.Factor*float64(retry-1))
+	case StrategyDecorrelatedJitter:
+		upper := 3 * float64(max(prev, b.Initial))
+		d = initial + rnd()*(upper-initial)
+	default:
+		d = initial * math.Pow(b.Factor, float64(retry-1))
+	}
+
+	if b.Max > 0 && d > float64(b.Max) {
+		d = float64(b.Max)
+	}
+	if b.Jitter > 0 && b.Strategy != StrategyDecorrelatedJitter {
+		d -= d * b.Jitter * rnd()
+	}
+	if d > math.MaxInt64 {
+		return time.Duration(math.MaxInt64)
+	}
+	return time.Duration(d)
+}
@@ -0,0 +1,21 @@
+// Package retry turns a step's `retry` block into a Policy and computes the
+// delay before each new attempt using the block's backoff strategy.
+//
+//	retry {
+//	  attempts     = 4
+//	  retry_on     = ["timeout", "http_5xx"]
+//	  abort_on     = ["http_4xx"]
+//	  max_duration = "2m"
+//	  backoff {
+//	    strategy = "exponential" # constant | linear | exponential | decorrelated_jitter
+//	    initial  = "500ms"
+//	    factor   = 2
+//	    max      = "30s"
+//	    jitter   = "full"        # none | equal | full, a bool, or a fraction in [0, 1]
+//	  }
+//	}
+//
+// `retry_on` and `abort_on` match the class of the failing error, as reported
+// by handlers.ErrorClass. `abort_on` always wins; when `retry_on` is omitted
+// every other class is retried.
+package retry
@@ -0,0 +1,180 @@
+package retry
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/specialistvlad/burstgridgo/internal/bggoexpr"
+	"github.com/specialistvlad/burstgridgo/internal/handlers"
+	"github.com/specialistvlad/burstgridgo/internal/model"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+)
+
+// Defaults applied to attributes omitted from a `retry` block.
+const (
+	DefaultAttempts = 3
+	DefaultInitial  = time.Second
+)
+
+// Policy is the evaluated form of a step's `retry` block.
+type Policy struct {
+	// Attempts is the total number of attempts, including the first one.
+	Attempts int
+
+	// RetryOn lists the error classes that may be retried. Empty means all.
+	RetryOn []string
+
+	// AbortOn lists the error classes that are never retried.
+	AbortOn []string
+
+	// MaxDuration bounds the time spent on all attempts together, measured
+	// from the start of the first one. Zero means unbounded.
+	MaxDuration time.Duration
+
+	Backoff Backoff
+}
+
+// None is the policy of a step without a `retry` block: a single attempt.
+var None = Policy{Attempts: 1}
+
+// Retryable reports whether a failure with err may be retried. It does not
+// consider the number of attempts left.
+func (p Policy) Retryable(err error) bool {
+	class := handlers.ErrorClass(err)
+	if class == handlers.ClassCancelled || slices.Contains(p.AbortOn, class) {
+		return false
+	}
+	return len(p.RetryOn) == 0 || slices.Contains(p.RetryOn, class)
+}
+
+// Evaluate resolves a `retry` block against ctx. A nil block yields None.
+func Evaluate(r *model.Retry, ctx *hcl.EvalContext) (Policy, hcl.Diagnostics) {
+	if r == nil {
+		return None, nil
+	}
+	var diags hcl.Diagnostics
+	p := Policy{
+		Attempts: DefaultAttempts,
+		Backoff: Backoff{
+			Strategy: StrategyExponential,
+			Initial:  DefaultInitial,
+		},
+	}
+
+	n, ok, d := bggoexpr.EvalInt(r.Attempts, ctx, "attempts")
+	diags = append(diags, d...)
+	if ok {
+		if n < 1 {
+			diags = diags.Append(invalidDiag(r.Attempts, "attempts", fmt.Sprintf("must be at least 1, got %d", n)))
+		}
+		p.Attempts = n
+	}
+
+	p.RetryOn, _, d = bggoexpr.EvalStrings(r.RetryOn, ctx, "retry_on")
+	diags = append(diags, d...)
+	p.AbortOn, _, d = bggoexpr.EvalStrings(r.AbortOn, ctx, "abort_on")
+	diags = append(diags, d...)
+	p.MaxDuration, _, d = bggoexpr.EvalDuration(r.MaxDuration, ctx, "max_duration")
+	diags = append(diags, d...)
+
+	if b := r.Backoff; b != nil {
+		diags = append(diags, evaluateBackoff(b, ctx, &p.Backoff)...)
+	}
+	if p.Backoff.Factor == 0 {
+		p.Backoff.Factor = defaultFactor(p.Backoff.Strategy)
+	}
+	return p, diags
+}
+
+func evaluateBackoff(b *model.Backoff, ctx *hcl.EvalContext, out *Backoff) hcl.Diagnostics {
+	var diags hcl.Diagnostics
+
+	s, ok, d := bggoexpr.EvalString(b.Strategy, ctx, "strategy")
+	diags = append(diags, d...)
+	if ok {
+		switch Strategy(s) {
+		case StrategyConstant, StrategyLinear, StrategyExponential, StrategyDecorrelatedJitter:
+			out.Strategy = Strategy(s)
+		default:
+			diags = diags.Append(invalidDiag(b.Strategy, "strategy", fmt.Sprintf("must be one of %q, %q, %q or %q, got %q",
+				StrategyConstant, StrategyLinear, StrategyExponential, StrategyDecorrelatedJitter, s)))
+		}
+	}
+
+	initial, ok, d := bggoexpr.EvalDuration(b.Initial, ctx, "initial")
+	diags = append(diags, d...)
+	if ok {
+		out.Initial = initial
+	}
+
+	f, ok, d := bggoexpr.EvalNumber(b.Factor, ctx, "factor")
+	diags = append(diags, d...)
+	if ok {
+		if f <= 0 {
+			diags = diags.Append(invalidDiag(b.Factor, "factor", fmt.Sprintf("must be greater than 0, got %g", f)))
+		}
+		out.Factor = f
+	}
+
+	out.Max, _, d = bggoexpr.EvalDuration(b.Max, ctx, "max")
+	diags = append(diags, d...)
+
+	out.Jitter, d = evaluateJitter(b.Jitter, ctx)
+	diags = append(diags, d...)
+	return diags
+}
+
+// evaluateJitter accepts "none", "equal" or "full", a bool, or a fraction.
+func evaluateJitter(expr hcl.Expression, ctx *hcl.EvalContext) (float64, hcl.Diagnostics) {
+	if expr == nil {
+		return 0, nil
+	}
+	val, diags := expr.Value(ctx)
+	if diags.HasErrors() || val.IsNull() {
+		return 0, diags
+	}
+	val, _ = val.UnmarkDeep()
+	switch val.Type() {
+	case cty.Bool:
+		if val.True() {
+			return 1, diags
+		}
+		return 0, diags
+	case cty.String:
+		switch val.AsString() {
+		case "none":
+			return 0, diags
+		case "equal":
+			return 0.5, diags
+		case "full":
+			return 1, diags
+		}
+	default:
+		if num, err := convert.Convert(val, cty.Number); err == nil {
+			f, _ := num.AsBigFloat().Float64()
+			if f >= 0 && f <= 1 {
+				return f, diags
+			}
+		}
+	}
+	return 0, diags.Append(invalidDiag(expr, "jitter", `must be "none", "equal", "full", a bool, or a number between 0 and 1`))
+}
+
+func defaultFactor(s Strategy) float64 {
+	if s == StrategyLinear {
+		return 1
+	}
+	return 2
+}
+
+func invalidDiag(expr hcl.Expression, name, detail string) *hcl.Diagnostic {
+	return &hcl.Diagnostic{
+		Severity: hcl.DiagError,
+		Summary:  fmt.Sprintf("Invalid %s value", name),
+		Detail:   fmt.Sprintf("Inappropriate value: %s.", detail),
+		Subject:  expr.Range().Ptr(),
+	}
+}
@@ -0,0 +1,151 @@
+package retry
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/gohcl"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/specialistvlad/burstgridgo/internal/handlers"
+	"github.com/specialistvlad/burstgridgo/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// parseRetry decodes the body of a `retry` block.
+func parseRetry(t *testing.T, src string) *model.Retry {
+	t.Helper()
+	file, diags := hclsyntax.ParseConfig([]byte(src), "test.hcl", hcl.Pos{Line: 1, Column: 1})
+	require.False(t, diags.HasErrors(), diags.Error())
+	r := &model.Retry{}
+	diags = gohcl.DecodeBody(file.Body, nil, r)
+	require.False(t, diags.HasErrors(), diags.Error())
+	return r
+}
+
+func TestEvaluate(t *testing.T) {
+	p, diags := Evaluate(parseRetry(t, `
+		attempts     = 5
+		retry_on     = ["timeout", "http_5xx"]
+		abort_on     = ["http_4xx"]
+		max_duration = "2m"
+		backoff {
+			strategy = "linear"
+			initial  = 0.25
+			max      = "10s"
+			jitter   = "equal"
+		}
+	`), nil)
+	require.False(t, diags.HasErrors(), diags.Error())
+	assert.Equal(t, Policy{
+		Attempts:    5,
+		RetryOn:     []string{"timeout", "http_5xx"},
+		AbortOn:     []string{"http_4xx"},
+		MaxDuration: 2 * time.Minute,
+		Backoff: Backoff{
+			Strategy: StrategyLinear,
+			Initial:  250 * time.Millisecond,
+			Factor:   1,
+			Max:      10 * time.Second,
+			Jitter:   0.5,
+		},
+	}, p)
+}
+
+func TestEvaluate_Defaults(t *testing.T) {
+	p, diags := Evaluate(parseRetry(t, ``), nil)
+	require.False(t, diags.HasErrors(), diags.Error())
+	assert.Equal(t, DefaultAttempts, p.Attempts)
+	assert.Equal(t, StrategyExponential, p.Backoff.Strategy)
+	assert.Equal(t, DefaultInitial, p.Backoff.Initial)
+	assert.Equal(t, 2.0, p.Backoff.Factor)
+
+	p, diags = Evaluate(nil, nil)
+	require.False(t, diags.HasErrors())
+	assert.Equal(t, None, p)
+}
+
+func TestEvaluate_Errors(t *testing.T) {
+	testCases := []struct {
+		name    string
+		src     string
+		summary string
+	}{
+		{name: "zero attempts", src: `attempts = 0`, summary: "Invalid attempts value"},
+		{name: "fractional attempts", src: `attempts = 1.5`, summary: "Invalid attempts value"},
+		{name: "bad duration", src: `max_duration = "soon"`, summary: "Invalid max_duration value"},
+		{name: "unknown strategy", src: `
+			backoff {
+				strategy = "fibonacci"
+			}`, summary: "Invalid strategy value"},
+		{name: "bad jitter", src: `
+			backoff {
+				jitter = 2
+			}`, summary: "Invalid jitter value"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, diags := Evaluate(parseRetry(t, tc.src), nil)
+			require.True(t, diags.HasErrors())
+			assert.Equal(t, tc.summary, diags[0].Summary)
+		})
+	}
+}
+
+func TestBackoff_Delay(t *testing.T) {
+	half := func() float64 { return 0.5 }
+	testCases := []struct {
+		name    string
+		backoff Backoff
+		want    []time.Duration
+	}{
+		{
+			name:    "constant",
+			backoff: Backoff{Strategy: StrategyConstant, Initial: time.Second},
+			want:    []time.Duration{time.Second, time.Second, time.Second},
+		},
+		{
+			name:    "linear",
+			backoff: Backoff{Strategy: StrategyLinear, Initial: time.Second, Factor: 1},
+			want:    []time.Duration{time.Second, 2 * time.Second, 3 * time.Second},
+		},
+		{
+			name:    "exponential capped",
+			backoff: Backoff{Strategy: StrategyExponential, Initial: time.Second, Factor: 2, Max: 3 * time.Second},
+			want:    []time.Duration{time.Second, 2 * time.Second, 3 * time.Second},
+		},
+		{
+			name:    "exponential with jitter",
+			backoff: Backoff{Strategy: StrategyExponential, Initial: time.Second, Factor: 2, Jitter: 1},
+			want:    []time.Duration{500 * time.Millisecond, time.Second, 2 * time.Second},
+		},
+		{
+			// Each delay is halfway between Initial and three times the previous one.
+			name:    "decorrelated jitter",
+			backoff: Backoff{Strategy: StrategyDecorrelatedJitter, Initial: time.Second},
+			want:    []time.Duration{2 * time.Second, 3500 * time.Millisecond, 5750 * time.Millisecond},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var prev time.Duration
+			for i, want := range tc.want {
+				prev = tc.backoff.Delay(i+1, prev, half)
+				assert.Equal(t, want, prev, "retry %d", i+1)
+			}
+		})
+	}
+}
+
+func TestPolicy_Retryable(t *testing.T) {
+	p := Policy{RetryOn: []string{handlers.ClassTimeout, "http_5xx"}, AbortOn: []string{"http_5xx"}}
+	assert.True(t, p.Retryable(fmt.Errorf("wrapped: %w", handlers.Classify(handlers.ClassTimeout, errors.New("slow")))))
+	assert.False(t, p.Retryable(handlers.Classify("http_5xx", errors.New("bad gateway"))), "abort_on wins over retry_on")
+	assert.False(t, p.Retryable(errors.New("plain")), "unlisted class")
+
+	all := Policy{}
+	assert.True(t, all.Retryable(errors.New("plain")), "empty retry_on retries everything")
+}
@@ -16,11 +16,11 @@
 // # How It Works
 //
 // The scheduler follows a continuous cycle:
-//   1. Query graph for all nodes and their current status
-//   2. Find nodes where all dependencies are Completed
-//   3. Emit those nodes via ReadyNodes() channel
-//   4. Wait for executor to mark nodes as Running/Completed/Failed
-//   5. Repeat until no more nodes are ready (all Completed/Failed or waiting on dependencies)
+//  1. Query graph for all nodes and their current status
+//  2. Find nodes where all dependencies are Completed
+//  3. Emit those nodes via ReadyNodes() channel
+//  4. Wait for executor to mark nodes as Running/Completed/Failed
+//  5. Repeat until no more nodes are ready (all Completed/Failed or waiting on dependencies)
 //
 // # Relationship with Other Components
 //
@@ -30,11 +30,17 @@
 //
 // # Typical Implementation
 //
-// See DefaultScheduler for the reference implementation. A complete implementation
-// would run a background goroutine that watches the graph and emits ready nodes.
+// See DefaultScheduler for the reference implementation. It runs a background
+// goroutine that rescans the graph whenever the executor calls Notify().
 package scheduler
 
-import "github.com/specialistvlad/burstgridgo/internal/node"
+import (
+	"context"
+	"time"
+
+	"github.com/specialistvlad/burstgridgo/internal/node"
+	"github.com/specialistvlad/burstgridgo/internal/nodeid"
+)
 
 // Scheduler analyzes the dependency graph and node execution state to determine
 // which nodes are ready for execution.
@@ -49,9 +55,9 @@ import "github.com/specialistvlad/burstgridgo/internal/node"
 //
 // The executor consumes the ReadyNodes() channel in a loop:
 //
-//	for node := range scheduler.ReadyNodes() {
-//	    // Execute node in a goroutine
-//	    go executor.executeNode(node)
+//	for node := range scheduler.ReadyNodes(ctx) {
+//	    executor.executeNode(node)   // marks the node Running/Completed/Failed
+//	    scheduler.Notify()           // lets the scheduler re-evaluate the graph
 //	}
 //
 // # Terminal States
@@ -61,6 +67,9 @@ import "github.com/specialistvlad/burstgridgo/internal/node"
 //   - **Partial Failure:** Some nodes Failed, remaining nodes can't run (dependencies unsatisfied)
 //   - **Deadlock:** No nodes are ready, but some are still Pending (cyclic dependency or bug)
 //
+// The channel is also closed when the context passed to ReadyNodes() is cancelled.
+// After the channel is closed, Result() reports which of these states was reached.
+//
 // # Thread-Safety
 //
 // The ReadyNodes() channel provides thread-safe communication between scheduler and executor.
@@ -70,23 +79,72 @@ type Scheduler interface {
 	//
 	// A node is "ready" when:
 	//   - Its status is Pending (not yet started)
-	//   - All of its dependencies have status Completed (successfully finished)
+	//   - All of its dependencies have status Completed (successfully finished), or
+	//     its step sets `continue_on_failure` and all of its dependencies are final
+	//     (Completed, Failed or Skipped); see ContinuesOnFailure
 	//
 	// The scheduler runs in a background goroutine and continuously:
 	//   1. Scans the graph for ready nodes
 	//   2. Emits them via the returned channel
-	//   3. Waits for state changes (nodes completing/failing)
+	//   3. Waits for state changes (signalled via Notify)
 	//   4. Repeats until terminal state
 	//
 	// The channel is **closed by the scheduler** when the graph reaches a terminal state
-	// (all nodes completed/failed, or no more nodes can run).
+	// (all nodes completed/failed, or no more nodes can run), or when ctx is cancelled.
 	//
-	// The executor MUST consume this channel promptly to avoid blocking the scheduler.
+	// Each node is emitted at most once. ReadyNodes must be called only once per scheduler.
+	ReadyNodes(ctx context.Context) <-chan *node.Node
+
+	// Notify signals that the state of one or more nodes has changed.
 	//
-	// # Current Implementation Note
+	// The executor calls this after every MarkCompleted/MarkFailed/MarkSkipped so the
+	// scheduler can re-evaluate which nodes have become ready. Calls are coalesced and
+	// never block.
+	//
+	// Thread-safety: Must be safe to call concurrently from multiple workers.
+	Notify()
+
+	// ReadyAt reports when a node emitted by ReadyNodes() became ready, i.e.
+	// when the scheduler found all of its dependencies Completed.
 	//
-	// DefaultScheduler currently returns an immediately-closed channel (placeholder).
-	// A complete implementation would run a goroutine that watches the graph and emits
-	// nodes dynamically.
-	ReadyNodes() <-chan *node.Node
+	// Executors use it to enforce a step's `start` and `queue` timeouts.
+	// Returns false for nodes that have not been found ready.
+	//
+	// Thread-safety: Must be safe to call concurrently from multiple workers.
+	ReadyAt(id nodeid.Address) (time.Time, bool)
+
+	// Result returns the terminal state reached by the scheduler.
+	//
+	// Before the ReadyNodes() channel is closed, the outcome is OutcomeRunning.
+	Result() Result
+}
+
+// Outcome describes how a scheduling run ended.
+type Outcome string
+
+const (
+	// OutcomeRunning indicates the scheduler has not reached a terminal state yet.
+	OutcomeRunning Outcome = "running"
+
+	// OutcomeSuccess indicates every node finished without failures.
+	OutcomeSuccess Outcome = "success"
+
+	// OutcomePartialFailure indicates at least one node Failed. Nodes listed in
+	// Result.Stalled could not run because of it.
+	OutcomePartialFailure Outcome = "partial_failure"
+
+	// OutcomeDeadlock indicates nothing failed, yet some nodes are still Pending
+	// with no way to make progress (a cycle or an inconsistent graph).
+	OutcomeDeadlock Outcome = "deadlock"
+
+	// OutcomeCancelled indicates the context passed to ReadyNodes() was cancelled.
+	OutcomeCancelled Outcome = "cancelled"
+)
+
+// Result is the terminal state of a scheduling run.
+type Result struct {
+	Outcome Outcome
+
+	// Stalled lists the nodes that were still Pending when the scheduler stopped.
+	Stalled []nodeid.Address
 }
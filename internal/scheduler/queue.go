@@ -0,0 +1,59 @@
+package scheduler
+
+import (
+	"container/heap"
+
+	"github.com/specialistvlad/burstgridgo/internal/node"
+)
+
+// readyQueue orders ready nodes by descending priority. Nodes of equal
+// priority keep the order in which they were pushed.
+type readyQueue struct {
+	items []queued
+	seq   int
+}
+
+type queued struct {
+	n        *node.Node
+	priority int
+	seq      int
+}
+
+func (q *readyQueue) Len() int { return len(q.items) }
+
+func (q *readyQueue) Less(i, j int) bool {
+	a, b := q.items[i], q.items[j]
+	if a.priority != b.priority {
+		return a.priority > b.priority
+	}
+	return a.seq < b.seq
+}
+
+func (q *readyQueue) Swap(i, j int) { q.items[i], q.items[j] = q.items[j], q.items[i] }
+
+func (q *readyQueue) Push(x any) { q.items = append(q.items, x.(queued)) }
+
+func (q *readyQueue) Pop() any {
+	last := q.items[len(q.items)-1]
+	q.items = q.items[:len(q.items)-1]
+	return last
+}
+
+// push adds a ready node with the given priority.
+func (q *readyQueue) push(n *node.Node, priority int) {
+	q.seq++
+	heap.Push(q, queued{n: n, priority: priority, seq: q.seq})
+}
+
+// peek returns the node that should be dispatched next, or nil.
+func (q *readyQueue) peek() *node.Node {
+	if len(q.items) == 0 {
+		return nil
+	}
+	return q.items[0].n
+}
+
+// pop removes the node returned by peek.
+func (q *readyQueue) pop() {
+	heap.Pop(q)
+}
@@ -2,68 +2,296 @@ package scheduler
 
 import (
 	"context"
+	"sort"
+	"sync"
+	"time"
 
+	"github.com/specialistvlad/burstgridgo/internal/bggoexpr"
 	"github.com/specialistvlad/burstgridgo/internal/ctxlog"
 	"github.com/specialistvlad/burstgridgo/internal/graph"
 	"github.com/specialistvlad/burstgridgo/internal/node"
+	"github.com/specialistvlad/burstgridgo/internal/nodeid"
 )
 
 // DefaultScheduler is the reference implementation of the Scheduler interface.
 //
-// # Current Status: Stubbed
+// # Algorithm
 //
-// This implementation currently returns an immediately-closed channel and does not
-// perform actual scheduling. A complete implementation would:
+// ReadyNodes starts a single background goroutine that owns all scheduling state:
 //
-//  1. Store reference to graph (currently unused)
-//  2. Run a background goroutine when ReadyNodes() is called
-//  3. Continuously scan graph for nodes where:
-//     - Status is Pending
-//     - All dependencies have status Completed
-//  4. Emit ready nodes via channel
-//  5. Use a ticker or graph event notifications to detect state changes
-//  6. Close channel when terminal state reached (all nodes done or deadlock detected)
+//  1. Scan the graph for nodes that are Pending, have not been emitted yet, and
+//     whose dependencies are all Completed (or all final, for a node that sets
+//     `continue_on_failure`). Push them onto the ready queue.
+//  2. Offer the head of the ready queue on the output channel. The queue is
+//     ordered by priority (see WithPriority), highest first; nodes of equal
+//     priority keep the order in which they became ready.
+//  3. Block until either the executor receives the node, Notify() signals a
+//     state change (which triggers a rescan), or the context is cancelled.
+//  4. Stop when the ready queue is empty and no emitted node is still in flight.
 //
-// # Algorithm Outline
-//
-// The scheduling algorithm would follow this pattern:
-//
-//	func (s *DefaultScheduler) ReadyNodes() <-chan *node.Node {
-//	    ch := make(chan *node.Node)
-//	    go func() {
-//	        defer close(ch)
-//	        for {
-//	            readyNodes := s.findReadyNodes() // Query graph
-//	            for _, n := range readyNodes {
-//	                ch <- n
-//	            }
-//	            if s.isTerminal() { break } // All done or deadlock
-//	            <-s.waitForStateChange() // Sleep or wait for notification
-//	        }
-//	    }()
-//	    return ch
-//	}
+// A node is considered "in flight" from the moment it is emitted until its
+// status becomes Completed, Failed or Skipped. Tracking emitted nodes locally
+// (instead of relying solely on the Running status) prevents a node from being
+// emitted twice in the window between the executor receiving it and calling
+// MarkRunning.
 //
 // # Thread-Safety
 //
 // Thread-safety is guaranteed by:
-//   - Channel-based communication (channels are thread-safe)
-//   - Delegating to thread-safe graph interface for queries
-type DefaultScheduler struct{}
+//   - Confining the scheduling state to the background goroutine
+//   - Channel-based communication with the executor
+//   - Delegating to the thread-safe graph interface for queries
+//   - Guarding the terminal Result and the ready timestamps with a mutex
+type DefaultScheduler struct {
+	graph    graph.Graph
+	priority PriorityFunc
+
+	// wake is a 1-buffered channel used to coalesce Notify() calls.
+	wake chan struct{}
+
+	mu      sync.Mutex
+	result  Result
+	readyAt map[string]time.Time
+}
+
+// PriorityFunc returns the priority of a node that has just become ready.
+// Higher priorities are dispatched first.
+type PriorityFunc func(ctx context.Context, n *node.Node) (int, error)
+
+// Option configures a DefaultScheduler.
+type Option func(*DefaultScheduler)
+
+// WithPriority sets the function used to order the ready queue. Without it
+// every node has priority 0 and nodes are dispatched in the order they
+// became ready.
+func WithPriority(fn PriorityFunc) Option {
+	return func(s *DefaultScheduler) {
+		s.priority = fn
+	}
+}
 
 // New creates a new default scheduler. It requires the graph it will be analyzing.
-func New(g graph.Graph) Scheduler {
-	return &DefaultScheduler{}
+func New(g graph.Graph, opts ...Option) Scheduler {
+	s := &DefaultScheduler{
+		graph:   g,
+		wake:    make(chan struct{}, 1),
+		result:  Result{Outcome: OutcomeRunning},
+		readyAt: make(map[string]time.Time),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
 // ReadyNodes implements the Scheduler interface.
-func (s *DefaultScheduler) ReadyNodes() <-chan *node.Node {
-	// This method is special as it returns a channel and runs in the background.
-	// Using context.Background() here is acceptable for a top-level goroutine
-	// within the scheduler, but a real implementation would need a way to be cancelled.
-	logger := ctxlog.FromContext(context.Background())
-	logger.Debug("scheduler.ReadyNodes called (placeholder)")
-	ch := make(chan *node.Node)
-	close(ch)
-	return ch
+func (s *DefaultScheduler) ReadyNodes(ctx context.Context) <-chan *node.Node {
+	out := make(chan *node.Node)
+	go s.run(ctx, out)
+	return out
+}
+
+// Notify implements the Scheduler interface.
+func (s *DefaultScheduler) Notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+		// A wake-up is already pending; the next scan will observe this change too.
+	}
+}
+
+// Result implements the Scheduler interface.
+func (s *DefaultScheduler) Result() Result {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.result
+}
+
+// ReadyAt implements the Scheduler interface.
+func (s *DefaultScheduler) ReadyAt(id nodeid.Address) (time.Time, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, ok := s.readyAt[id.String()]
+	return t, ok
+}
+
+// run is the scheduling loop. It owns the emitted set and the ready queue.
+func (s *DefaultScheduler) run(ctx context.Context, out chan<- *node.Node) {
+	defer close(out)
+	logger := ctxlog.FromContext(ctx)
+	logger.Debug("Scheduler started.")
+
+	emitted := make(map[string]struct{})
+	var queue readyQueue
+	dirty := true
+
+	for {
+		if dirty {
+			sc := s.scan(ctx, emitted)
+			now := time.Now()
+			for _, n := range sc.ready {
+				emitted[n.ID.String()] = struct{}{}
+				s.setReadyAt(n.ID, now)
+				priority := s.priorityOf(ctx, n)
+				queue.push(n, priority)
+				logger.Debug("Node is ready.", "node", n.ID.String(), "priority", priority)
+			}
+			dirty = false
+
+			if queue.Len() == 0 && sc.inFlight == 0 {
+				res := sc.result()
+				s.setResult(res)
+				logger.Debug("Scheduler reached a terminal state.", "outcome", res.Outcome, "stalled", len(res.Stalled))
+				return
+			}
+		}
+
+		// A nil channel blocks forever, which disables the send case when
+		// there is nothing to offer.
+		var send chan<- *node.Node
+		next := queue.peek()
+		if next != nil {
+			send = out
+		}
+
+		select {
+		case send <- next:
+			queue.pop()
+			// With nothing left to offer we must rescan to detect termination,
+			// since the node we just handed over may already be finished.
+			dirty = queue.Len() == 0
+		case <-s.wake:
+			dirty = true
+		case <-ctx.Done():
+			s.setResult(Result{Outcome: OutcomeCancelled})
+			logger.Debug("Scheduler cancelled.", "error", ctx.Err())
+			return
+		}
+	}
+}
+
+// priorityOf returns the priority of a ready node, or 0 when it has none or
+// it cannot be evaluated.
+func (s *DefaultScheduler) priorityOf(ctx context.Context, n *node.Node) int {
+	if s.priority == nil {
+		return 0
+	}
+	priority, err := s.priority(ctx, n)
+	if err != nil {
+		ctxlog.FromContext(ctx).Warn("Failed to evaluate node priority.", "node", n.ID.String(), "error", err)
+		return 0
+	}
+	return priority
+}
+
+// scanResult is a snapshot of the graph taken by a single scan.
+type scanResult struct {
+	ready    []*node.Node     // Pending, not yet emitted, dependencies satisfied
+	inFlight int              // Emitted and not yet in a final status
+	pending  []nodeid.Address // Pending, not emitted and not ready
+	failed   int              // Nodes in Failed status
+}
+
+// result classifies a terminal snapshot.
+func (sc scanResult) result() Result {
+	switch {
+	case sc.failed > 0:
+		return Result{Outcome: OutcomePartialFailure, Stalled: sc.pending}
+	case len(sc.pending) > 0:
+		return Result{Outcome: OutcomeDeadlock, Stalled: sc.pending}
+	default:
+		return Result{Outcome: OutcomeSuccess}
+	}
+}
+
+// scan walks every node in the graph and classifies it.
+func (s *DefaultScheduler) scan(ctx context.Context, emitted map[string]struct{}) scanResult {
+	logger := ctxlog.FromContext(ctx)
+	var sc scanResult
+
+	for _, n := range s.graph.AllNodes(ctx) {
+		status, _ := s.graph.NodeStatus(ctx, n.ID)
+		_, wasEmitted := emitted[n.ID.String()]
+
+		switch {
+		case status == node.StatusFailed:
+			sc.failed++
+			continue
+		case isFinal(status):
+			continue
+		case wasEmitted:
+			sc.inFlight++
+			continue
+		case status == node.StatusRunning:
+			// Running but never emitted by us; someone else owns it.
+			sc.inFlight++
+			continue
+		}
+
+		ready, err := s.dependenciesSatisfied(ctx, n)
+		if err != nil {
+			logger.Warn("Failed to resolve node dependencies.", "node", n.ID.String(), "error", err)
+		}
+		if ready {
+			sc.ready = append(sc.ready, n)
+		} else {
+			sc.pending = append(sc.pending, n.ID)
+		}
+	}
+
+	// AllNodes makes no ordering guarantees; sort for deterministic output.
+	sort.Slice(sc.ready, func(i, j int) bool { return sc.ready[i].ID.String() < sc.ready[j].ID.String() })
+	sort.Slice(sc.pending, func(i, j int) bool { return sc.pending[i].String() < sc.pending[j].String() })
+	return sc
+}
+
+// dependenciesSatisfied reports whether n may run: every dependency is
+// Completed, or every dependency is final and n continues on failure.
+func (s *DefaultScheduler) dependenciesSatisfied(ctx context.Context, n *node.Node) (bool, error) {
+	deps, err := s.graph.DependenciesOf(ctx, n.ID)
+	if err != nil {
+		return false, err
+	}
+	completed := true
+	for _, dep := range deps {
+		status, _ := s.graph.NodeStatus(ctx, dep.ID)
+		if !isFinal(status) {
+			return false, nil
+		}
+		completed = completed && status == node.StatusCompleted
+	}
+	return completed || ContinuesOnFailure(n), nil
+}
+
+// ContinuesOnFailure reports whether a node's step sets `continue_on_failure`,
+// which lets it run once its dependencies are final even if some of them
+// Failed or were Skipped. The attribute must be a constant boolean; any other
+// value counts as false.
+func ContinuesOnFailure(n *node.Node) bool {
+	if n.Config == nil {
+		return false
+	}
+	ok, _, diags := bggoexpr.EvalBool(n.Config.ContinueOnFailure, nil, "continue_on_failure")
+	return ok && !diags.HasErrors()
+}
+
+func (s *DefaultScheduler) setReadyAt(id nodeid.Address, t time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.readyAt[id.String()] = t
+}
+
+func (s *DefaultScheduler) setResult(r Result) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.result = r
+}
+
+// isFinal reports whether a node status can no longer change.
+func isFinal(status node.Status) bool {
+	switch status {
+	case node.StatusCompleted, node.StatusFailed, node.StatusSkipped:
+		return true
+	}
+	return false
 }
@@ -0,0 +1,285 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/specialistvlad/burstgridgo/internal/ctxlog"
+	"github.com/specialistvlad/burstgridgo/internal/graph"
+	"github.com/specialistvlad/burstgridgo/internal/inmemorystore"
+	"github.com/specialistvlad/burstgridgo/internal/inmemorytopology"
+	"github.com/specialistvlad/burstgridgo/internal/model"
+	"github.com/specialistvlad/burstgridgo/internal/node"
+	"github.com/specialistvlad/burstgridgo/internal/nodeid"
+	"github.com/specialistvlad/burstgridgo/internal/topologystore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testContext returns a context carrying a discarding logger.
+func testContext() context.Context {
+	return ctxlog.WithLogger(context.Background(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+// buildGraph creates a graph from a list of node IDs and "from->to" edges.
+func buildGraph(t *testing.T, ids []string, edges [][2]string) graph.Graph {
+	t.Helper()
+	topo := inmemorytopology.New()
+	addToTopology(t, topo, ids, edges)
+	return graph.New(topo, inmemorystore.New())
+}
+
+func addToTopology(t *testing.T, topo topologystore.Store, ids []string, edges [][2]string) {
+	t.Helper()
+	ctx := testContext()
+	for _, id := range ids {
+		require.NoError(t, topo.AddNode(ctx, &node.Node{ID: mustParse(t, id)}))
+	}
+	for _, e := range edges {
+		require.NoError(t, topo.AddDependency(ctx, mustParse(t, e[0]), mustParse(t, e[1])))
+	}
+}
+
+func mustParse(t *testing.T, id string) nodeid.Address {
+	t.Helper()
+	addr, err := nodeid.Parse(id)
+	require.NoError(t, err)
+	return *addr
+}
+
+// drain consumes the ready channel like a single-worker executor would,
+// completing every node except those listed in fail. Errors from the graph are
+// collected by the consuming goroutine and checked once it has finished, since
+// only the test goroutine may stop the test.
+func drain(t *testing.T, ctx context.Context, s Scheduler, g graph.Graph, fail map[string]bool) []string {
+	t.Helper()
+	var order []string
+	var errs []error
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for n := range s.ReadyNodes(ctx) {
+			order = append(order, n.ID.String())
+			if err := g.MarkRunning(ctx, n.ID); err != nil {
+				errs = append(errs, err)
+			}
+			var err error
+			if fail[n.ID.String()] {
+				err = g.MarkFailed(ctx, n.ID, assert.AnError)
+			} else {
+				err = g.MarkCompleted(ctx, n.ID, nil)
+			}
+			if err != nil {
+				errs = append(errs, err)
+			}
+			s.Notify()
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("scheduler did not close the ready channel")
+	}
+	require.NoError(t, errors.Join(errs...))
+	return order
+}
+
+func TestScheduler_EmptyGraph(t *testing.T) {
+	g := buildGraph(t, nil, nil)
+	s := New(g)
+
+	order := drain(t, testContext(), s, g, nil)
+
+	assert.Empty(t, order)
+	assert.Equal(t, OutcomeSuccess, s.Result().Outcome)
+}
+
+func TestScheduler_RespectsDependencies(t *testing.T) {
+	// a -> b -> d, a -> c -> d
+	g := buildGraph(t,
+		[]string{"step.a", "step.b", "step.c", "step.d"},
+		[][2]string{{"step.a", "step.b"}, {"step.a", "step.c"}, {"step.b", "step.d"}, {"step.c", "step.d"}},
+	)
+	s := New(g)
+
+	order := drain(t, testContext(), s, g, nil)
+
+	require.Len(t, order, 4)
+	assert.Equal(t, "step.a", order[0])
+	assert.ElementsMatch(t, []string{"step.b", "step.c"}, order[1:3])
+	assert.Equal(t, "step.d", order[3])
+	assert.Equal(t, OutcomeSuccess, s.Result().Outcome)
+}
+
+func TestScheduler_EmitsIndependentNodesTogether(t *testing.T) {
+	g := buildGraph(t, []string{"step.a", "step.b", "step.c"}, nil)
+	s := New(g)
+	ctx := testContext()
+
+	// Receive every node without completing any of them: all three must be
+	// emitted up front because none has dependencies.
+	ready := s.ReadyNodes(ctx)
+	var got []string
+	for i := 0; i < 3; i++ {
+		select {
+		case n := <-ready:
+			got = append(got, n.ID.String())
+		case <-time.After(time.Second):
+			t.Fatalf("expected 3 ready nodes, got %v", got)
+		}
+	}
+	assert.Equal(t, []string{"step.a", "step.b", "step.c"}, got)
+
+	for _, id := range got {
+		require.NoError(t, g.MarkCompleted(ctx, mustParse(t, id), nil))
+	}
+	s.Notify()
+
+	_, open := <-ready
+	assert.False(t, open, "channel should close once every node is completed")
+	assert.Equal(t, OutcomeSuccess, s.Result().Outcome)
+}
+
+func TestScheduler_PartialFailure(t *testing.T) {
+	// a fails, so b (depends on a) can never run; c is independent.
+	g := buildGraph(t,
+		[]string{"step.a", "step.b", "step.c"},
+		[][2]string{{"step.a", "step.b"}},
+	)
+	s := New(g)
+
+	order := drain(t, testContext(), s, g, map[string]bool{"step.a": true})
+
+	assert.ElementsMatch(t, []string{"step.a", "step.c"}, order)
+	res := s.Result()
+	assert.Equal(t, OutcomePartialFailure, res.Outcome)
+	require.Len(t, res.Stalled, 1)
+	assert.Equal(t, "step.b", res.Stalled[0].String())
+}
+
+func TestScheduler_ContinueOnFailure(t *testing.T) {
+	// a fails; b depends on a and continues on failure, c depends on b.
+	topo := inmemorytopology.New()
+	ctx := testContext()
+	expr, diags := hclsyntax.ParseExpression([]byte("true"), "test.hcl", hcl.InitialPos)
+	require.False(t, diags.HasErrors())
+	require.NoError(t, topo.AddNode(ctx, &node.Node{
+		ID:     mustParse(t, "step.b"),
+		Config: &model.Step{ContinueOnFailure: expr},
+	}))
+	addToTopology(t, topo, []string{"step.a", "step.c"}, [][2]string{{"step.a", "step.b"}, {"step.b", "step.c"}})
+	g := graph.New(topo, inmemorystore.New())
+	s := New(g)
+
+	order := drain(t, ctx, s, g, map[string]bool{"step.a": true})
+
+	assert.Equal(t, []string{"step.a", "step.b", "step.c"}, order)
+	res := s.Result()
+	assert.Equal(t, OutcomePartialFailure, res.Outcome)
+	assert.Empty(t, res.Stalled)
+}
+
+func TestScheduler_Priority(t *testing.T) {
+	// d depends on a; once a completes, d outranks the remaining nodes.
+	g := buildGraph(t,
+		[]string{"step.a", "step.b", "step.c", "step.d"},
+		[][2]string{{"step.a", "step.d"}},
+	)
+	priorities := map[string]int{"step.a": 5, "step.b": 1, "step.c": 3, "step.d": 10}
+	s := New(g, WithPriority(func(ctx context.Context, n *node.Node) (int, error) {
+		return priorities[n.ID.String()], nil
+	}))
+
+	ctx := testContext()
+	ready := s.ReadyNodes(ctx)
+	recv := func() string {
+		select {
+		case n := <-ready:
+			return n.ID.String()
+		case <-time.After(time.Second):
+			t.Fatal("expected a ready node")
+			return ""
+		}
+	}
+
+	assert.Equal(t, "step.a", recv())
+	require.NoError(t, g.MarkCompleted(ctx, mustParse(t, "step.a"), nil))
+	s.Notify()
+	// Let the scheduler rescan before the next receive so d is queued.
+	time.Sleep(20 * time.Millisecond)
+	order := []string{recv(), recv(), recv()}
+	assert.Equal(t, []string{"step.d", "step.c", "step.b"}, order)
+}
+
+func TestScheduler_PriorityErrorDefaultsToZero(t *testing.T) {
+	g := buildGraph(t, []string{"step.a", "step.b"}, nil)
+	s := New(g, WithPriority(func(ctx context.Context, n *node.Node) (int, error) {
+		if n.ID.String() == "step.a" {
+			return 0, errors.New("bad priority")
+		}
+		return 1, nil
+	}))
+
+	order := drain(t, testContext(), s, g, nil)
+	assert.Equal(t, []string{"step.b", "step.a"}, order)
+}
+
+func TestScheduler_Deadlock(t *testing.T) {
+	// a and b depend on each other; nothing can ever become ready.
+	g := buildGraph(t,
+		[]string{"step.a", "step.b", "step.c"},
+		[][2]string{{"step.a", "step.b"}, {"step.b", "step.a"}},
+	)
+	s := New(g)
+
+	order := drain(t, testContext(), s, g, nil)
+
+	assert.Equal(t, []string{"step.c"}, order)
+	res := s.Result()
+	assert.Equal(t, OutcomeDeadlock, res.Outcome)
+	assert.Len(t, res.Stalled, 2)
+}
+
+func TestScheduler_Cancellation(t *testing.T) {
+	g := buildGraph(t, []string{"step.a", "step.b"}, [][2]string{{"step.a", "step.b"}})
+	s := New(g)
+	ctx, cancel := context.WithCancel(testContext())
+
+	ready := s.ReadyNodes(ctx)
+	n := <-ready
+	assert.Equal(t, "step.a", n.ID.String())
+
+	// Never complete step.a; cancelling must still close the channel.
+	cancel()
+	select {
+	case _, open := <-ready:
+		assert.False(t, open)
+	case <-time.After(time.Second):
+		t.Fatal("scheduler ignored context cancellation")
+	}
+	assert.Equal(t, OutcomeCancelled, s.Result().Outcome)
+}
+
+func TestScheduler_RecordsReadyTime(t *testing.T) {
+	g := buildGraph(t, []string{"step.a", "step.b"}, [][2]string{{"step.a", "step.b"}})
+	s := New(g)
+	_, ok := s.ReadyAt(mustParse(t, "step.a"))
+	assert.False(t, ok, "nothing is ready before scheduling starts")
+
+	before := time.Now()
+	drain(t, testContext(), s, g, nil)
+
+	readyA, ok := s.ReadyAt(mustParse(t, "step.a"))
+	require.True(t, ok)
+	readyB, ok := s.ReadyAt(mustParse(t, "step.b"))
+	require.True(t, ok)
+	assert.False(t, readyA.Before(before))
+	assert.False(t, readyB.Before(readyA), "b becomes ready only after a completes")
+}
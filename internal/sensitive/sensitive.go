@@ -0,0 +1,153 @@
+// Package sensitive marks values that must not be shown and redacts them from
+// logs and reports.
+//
+// A value is sensitive when it carries the Mark cty mark: the output of a step
+// with `sensitive = true`, or a variable declared sensitive. cty propagates
+// marks through expression evaluation, so anything derived from a sensitive
+// value is sensitive too. Handlers receive plain Go values, which cannot carry
+// marks; instead the text of their sensitive inputs (strings, and numbers in
+// their decimal form) is collected as Secrets and redacted from whatever the
+// handler logs or returns as an error. Booleans are not collected, as their
+// text would match unrelated words; handlers that show their inputs as they
+// are check handlers.InputSensitive instead.
+package sensitive
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+// Redacted replaces sensitive values in logs and reports.
+const Redacted = "(sensitive value)"
+
+type marker string
+
+// Mark is the cty mark carried by sensitive values.
+const Mark = marker("sensitive")
+
+// Marked reports whether val, or any value nested in it, is sensitive.
+func Marked(val cty.Value) bool {
+	_, pvms := val.UnmarkDeepWithPaths()
+	for _, pvm := range pvms {
+		if _, ok := pvm.Marks[Mark]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Unmark returns val without any marks, together with the strings and numbers
+// nested in its sensitive parts.
+func Unmark(val cty.Value) (cty.Value, Secrets) {
+	unmarked, pvms := val.UnmarkDeepWithPaths()
+	var secrets Secrets
+	for _, pvm := range pvms {
+		if _, ok := pvm.Marks[Mark]; !ok {
+			continue
+		}
+		part, err := pvm.Path.Apply(unmarked)
+		if err != nil {
+			continue
+		}
+		secrets = secrets.collect(part)
+	}
+	return unmarked, secrets
+}
+
+// Redact returns val with each of its sensitive parts replaced by Redacted.
+func Redact(val cty.Value) cty.Value {
+	if !Marked(val) {
+		return val
+	}
+	if val.HasMark(Mark) {
+		return cty.StringVal(Redacted)
+	}
+	val, marks := val.Unmark()
+	ty := val.Type()
+	switch {
+	case val.IsNull() || !val.IsKnown():
+	case ty.IsObjectType() || ty.IsMapType():
+		attrs := make(map[string]cty.Value, val.LengthInt())
+		for it := val.ElementIterator(); it.Next(); {
+			k, v := it.Element()
+			attrs[k.AsString()] = Redact(v)
+		}
+		val = cty.ObjectVal(attrs)
+	case ty.IsListType() || ty.IsTupleType() || ty.IsSetType():
+		elems := make([]cty.Value, 0, val.LengthInt())
+		for it := val.ElementIterator(); it.Next(); {
+			_, v := it.Element()
+			elems = append(elems, Redact(v))
+		}
+		val = cty.TupleVal(elems)
+	}
+	return val.WithMarks(marks)
+}
+
+// Secrets are the text of sensitive values, redacted wherever they occur in
+// text.
+type Secrets []string
+
+// collect appends the non-empty strings, and the decimal form of the numbers,
+// nested in val.
+func (s Secrets) collect(val cty.Value) Secrets {
+	val, _ = val.UnmarkDeep()
+	if val.IsNull() || !val.IsKnown() {
+		return s
+	}
+	ty := val.Type()
+	switch {
+	case ty == cty.String:
+		if str := val.AsString(); str != "" {
+			s = append(s, str)
+		}
+	case ty == cty.Number:
+		s = append(s, val.AsBigFloat().Text('f', -1))
+	case ty.IsObjectType() || ty.IsMapType() || ty.IsListType() || ty.IsTupleType() || ty.IsSetType():
+		for it := val.ElementIterator(); it.Next(); {
+			_, v := it.Element()
+			s = s.collect(v)
+		}
+	}
+	return s
+}
+
+// Contains reports whether text contains any secret.
+func (s Secrets) Contains(text string) bool {
+	for _, secret := range s {
+		if strings.Contains(text, secret) {
+			return true
+		}
+	}
+	return false
+}
+
+// Redact replaces every secret in text with Redacted, longest first so that
+// a secret containing another is replaced whole.
+func (s Secrets) Redact(text string) string {
+	sorted := slices.SortedFunc(slices.Values(s), func(a, b string) int { return cmp.Compare(len(b), len(a)) })
+	for _, secret := range sorted {
+		text = strings.ReplaceAll(text, secret, Redacted)
+	}
+	return text
+}
+
+// RedactError returns err with secrets redacted from its message. The
+// original error stays reachable through errors.Is and errors.As.
+func (s Secrets) RedactError(err error) error {
+	if err == nil || !s.Contains(err.Error()) {
+		return err
+	}
+	return &redactedError{msg: s.Redact(err.Error()), err: err}
+}
+
+type redactedError struct {
+	msg string
+	err error
+}
+
+func (e *redactedError) Error() string { return e.msg }
+func (e *redactedError) Unwrap() error { return e.err }
@@ -0,0 +1,74 @@
+package sensitive
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestUnmark(t *testing.T) {
+	val := cty.ObjectVal(map[string]cty.Value{
+		"user":  cty.StringVal("alice"),
+		"token": cty.StringVal("s3cr3t").Mark(Mark),
+		"keys":  cty.ListVal([]cty.Value{cty.StringVal("k1"), cty.StringVal("k2")}).Mark(Mark),
+		"port":  cty.NumberIntVal(5432).Mark(Mark),
+		"ratio": cty.NumberFloatVal(0.25).Mark(Mark),
+		"tls":   cty.True.Mark(Mark),
+		"count": cty.NumberIntVal(7),
+	})
+	assert.True(t, Marked(val))
+
+	unmarked, secrets := Unmark(val)
+	assert.False(t, unmarked.ContainsMarked())
+	assert.ElementsMatch(t, Secrets{"s3cr3t", "k1", "k2", "5432", "0.25"}, secrets,
+		"numbers are collected in decimal form; booleans are not")
+
+	plain, secrets := Unmark(cty.StringVal("public"))
+	assert.Equal(t, cty.StringVal("public"), plain)
+	assert.Empty(t, secrets)
+}
+
+func TestRedact(t *testing.T) {
+	testCases := []struct {
+		name string
+		in   cty.Value
+		want cty.Value
+	}{
+		{name: "unmarked", in: cty.StringVal("public"), want: cty.StringVal("public")},
+		{name: "marked", in: cty.NumberIntVal(1).Mark(Mark), want: cty.StringVal(Redacted)},
+		{
+			name: "nested",
+			in: cty.ObjectVal(map[string]cty.Value{
+				"user":  cty.StringVal("alice"),
+				"token": cty.StringVal("s3cr3t").Mark(Mark),
+			}),
+			want: cty.ObjectVal(map[string]cty.Value{
+				"user":  cty.StringVal("alice"),
+				"token": cty.StringVal(Redacted),
+			}),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, Redact(tc.in))
+		})
+	}
+}
+
+func TestSecrets(t *testing.T) {
+	secrets := Secrets{"abc", "abcdef"}
+	assert.True(t, secrets.Contains("key=abcdef"))
+	assert.False(t, secrets.Contains("key=ab"))
+	assert.Equal(t, "key="+Redacted+" other="+Redacted, secrets.Redact("key=abcdef other=abc"))
+
+	base := errors.New("login failed for abc")
+	err := secrets.RedactError(base)
+	assert.Equal(t, "login failed for "+Redacted, err.Error())
+	assert.ErrorIs(t, err, base)
+
+	clean := errors.New("timeout")
+	assert.Same(t, clean, secrets.RedactError(clean))
+	assert.NoError(t, secrets.RedactError(nil))
+}
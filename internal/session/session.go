@@ -6,8 +6,8 @@ import (
 	"context"
 
 	"github.com/specialistvlad/burstgridgo/internal/executor"
-	"github.com/specialistvlad/burstgridgo/internal/handlers"
 	"github.com/specialistvlad/burstgridgo/internal/model"
+	"github.com/specialistvlad/burstgridgo/internal/registry"
 )
 
 // SessionFactory creates an execution Session. Different implementations can
@@ -16,7 +16,7 @@ type SessionFactory interface {
 	NewSession(
 		ctx context.Context,
 		cfg *model.Grid,
-		reg *handlers.Handlers,
+		reg *registry.Registry,
 	) (Session, error)
 }
 
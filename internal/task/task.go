@@ -1,6 +1,10 @@
 package task
 
-import "github.com/specialistvlad/burstgridgo/internal/node"
+import (
+	"github.com/hashicorp/hcl/v2"
+	"github.com/specialistvlad/burstgridgo/internal/node"
+	"github.com/zclconf/go-cty/cty"
+)
 
 // Task represents a node that is fully prepared for execution.
 // It is the output of a builder.Builder and the input for a component
@@ -11,5 +15,9 @@ type Task struct {
 
 	// ResolvedInputs contains the final, computed input values for the handler,
 	// with all dependencies and references resolved.
-	ResolvedInputs map[string]any
+	ResolvedInputs map[string]cty.Value
+
+	// EvalContext is the context the inputs were evaluated in. It is kept so
+	// that other step attributes can be resolved against the same values.
+	EvalContext *hcl.EvalContext
 }
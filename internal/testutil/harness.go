@@ -122,6 +122,10 @@ func RunIntegrationTestWithContext(ctx context.Context, t *testing.T, files map[
 	} else if err := testApp.LoadGrids(); err != nil {
 		// We run LoadGrids() as well to get a complete "load phase" test.
 		runErr = err
+	} else if err := testApp.ResolveVariables(); err != nil {
+		runErr = err
+	} else if err := testApp.EvaluateLocals(); err != nil {
+		runErr = err
 	}
 
 	if os.Getenv("BGGO_TEST_LOGS") == "true" {
@@ -18,7 +18,8 @@
 // The topology store is:
 //   1. **Created** once per execution session (ephemeral, not persistent across runs)
 //   2. **Populated** during graph construction phase (nodes + dependencies added)
-//   3. **Read-only** during execution phase (scheduler queries dependencies, executor looks up nodes)
+//   3. **Read-mostly** during execution phase (scheduler queries dependencies, executor looks up nodes);
+//      the only write is Expand, which replaces a dynamic placeholder with its instances
 //   4. **Discarded** when the session ends
 //
 // The topology is write-once-read-many after the graph construction phase completes.
@@ -112,4 +113,22 @@ type Store interface {
 	// Thread-safety: Must be safe to call concurrently, as the scheduler queries this
 	// heavily during parallel execution.
 	DependenciesOf(ctx context.Context, id nodeid.Address) ([]nodeid.Address, error)
+
+	// Expand inserts the runtime instances of a placeholder node.
+	//
+	// This is how a step with a dynamic `count` or `for_each` is expanded once
+	// its inputs are known. In a single atomic update, implementations must:
+	//   - add every instance node
+	//   - make each instance depend on everything the placeholder depends on
+	//   - make everything that depends on the placeholder also depend on each instance
+	//
+	// The placeholder itself stays in the topology. Callers mark it completed
+	// afterwards, at which point dependents wait on the instances instead.
+	//
+	// Returns an error if the placeholder doesn't exist or an instance ID is
+	// already taken.
+	//
+	// Thread-safety: Must be safe to call concurrently with reads; no reader may
+	// observe an instance without its dependency edges.
+	Expand(ctx context.Context, placeholder nodeid.Address, instances []*node.Node) error
 }
@@ -0,0 +1,284 @@
+// Package variables resolves the values of a grid's `variable` blocks.
+//
+// Values come from four sources, each overriding the ones before it:
+//
+//  1. the `default` of the declaration
+//  2. BGGO_VAR_<name> environment variables
+//  3. `--var name=value` command-line flags, later flags winning
+//  4. `--var-file` files, later files winning
+//
+// Values given as strings, on the command line or in the environment, are
+// taken literally for primitive types and parsed as HCL expressions (e.g.,
+// `["a", "b"]`) for complex ones. Var files are HCL (or, with a ".json"
+// extension, JSON) files of `name = value` attributes.
+//
+// Every value is converted to the declared type and checked against the
+// declaration's `validation` blocks. The values of sensitive variables are
+// marked with sensitive.Mark, so anything derived from them is redacted.
+package variables
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/specialistvlad/burstgridgo/internal/bggohcl"
+	"github.com/specialistvlad/burstgridgo/internal/funcs"
+	"github.com/specialistvlad/burstgridgo/internal/model"
+	"github.com/specialistvlad/burstgridgo/internal/sensitive"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+)
+
+// EnvPrefix prefixes the names of environment variables that set variables.
+const EnvPrefix = "BGGO_VAR_"
+
+// Sources holds the values given to a run, lowest precedence first.
+type Sources struct {
+	// Env is the process environment as returned by os.Environ. Entries
+	// without EnvPrefix, or naming undeclared variables, are ignored.
+	Env []string
+
+	// Vars are the "name=value" assignments of --var flags.
+	Vars []string
+
+	// Files are the paths of --var-file files.
+	Files []string
+}
+
+// given is a value supplied for a variable, before conversion.
+type given struct {
+	val cty.Value
+	rng *hcl.Range // nil when the value was not read from a file
+}
+
+// Resolve determines the value of every declared variable from src and
+// returns them as an object suitable for the `var` variable of an
+// hcl.EvalContext.
+func Resolve(decls []*model.Variable, src Sources) (cty.Value, hcl.Diagnostics) {
+	declared := make(map[string]*model.Variable, len(decls))
+	for _, d := range decls {
+		declared[d.Name] = d
+	}
+
+	var diags hcl.Diagnostics
+	values := make(map[string]given)
+
+	for _, entry := range src.Env {
+		key, raw, _ := strings.Cut(entry, "=")
+		name, ok := strings.CutPrefix(key, EnvPrefix)
+		if !ok {
+			continue
+		}
+		d, ok := declared[name]
+		if !ok {
+			continue
+		}
+		val, valDiags := parseString(d, raw, key)
+		diags = append(diags, valDiags...)
+		if !valDiags.HasErrors() {
+			values[name] = given{val: val}
+		}
+	}
+
+	for _, assignment := range src.Vars {
+		name, raw, ok := strings.Cut(assignment, "=")
+		if !ok {
+			diags = diags.Append(&hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid --var option",
+				Detail:   fmt.Sprintf("The option %q is not of the form name=value.", assignment),
+			})
+			continue
+		}
+		d, ok := declared[name]
+		if !ok {
+			diags = diags.Append(undeclared(name, nil))
+			continue
+		}
+		val, valDiags := parseString(d, raw, "--var "+name)
+		diags = append(diags, valDiags...)
+		if !valDiags.HasErrors() {
+			values[name] = given{val: val}
+		}
+	}
+
+	for _, path := range src.Files {
+		fileDiags := readFile(path, declared, values)
+		diags = append(diags, fileDiags...)
+	}
+
+	if diags.HasErrors() {
+		return cty.NilVal, diags
+	}
+
+	vars := make(map[string]cty.Value, len(decls))
+	for _, d := range decls {
+		g, ok := values[d.Name]
+		if !ok {
+			if d.Default == nil {
+				diags = diags.Append(&hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Missing required variable",
+					Detail: fmt.Sprintf("The variable %q has no default, so a value must be given with --var, --var-file or the %s%s environment variable.",
+						d.Name, EnvPrefix, d.Name),
+					Subject: d.DeclRange.Ptr(),
+				})
+				continue
+			}
+			g = given{val: *d.Default}
+		}
+
+		val, err := bggohcl.ConvertToType(g.val, d.Type, d.TypeDefaults)
+		if err != nil {
+			subject := g.rng
+			if subject == nil {
+				subject = d.DeclRange.Ptr()
+			}
+			diags = diags.Append(&hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid value for variable",
+				Detail:   fmt.Sprintf("Inappropriate value for variable %q: %s is required: %s.", d.Name, d.Type.FriendlyName(), err),
+				Subject:  subject,
+			})
+			continue
+		}
+		if d.Sensitive {
+			val = val.Mark(sensitive.Mark)
+		}
+		vars[d.Name] = val
+	}
+	if diags.HasErrors() {
+		return cty.NilVal, diags
+	}
+
+	obj := cty.ObjectVal(vars)
+	evalCtx := funcs.EvalContext(map[string]cty.Value{"var": obj})
+	for _, d := range decls {
+		for _, v := range d.Validations {
+			diags = append(diags, validate(d, v, evalCtx)...)
+		}
+	}
+	if diags.HasErrors() {
+		return cty.NilVal, diags
+	}
+	return obj, diags
+}
+
+// parseString turns a value given as a string into a cty.Value for d: the
+// string itself for a primitive or unconstrained type, or the value of the
+// string parsed as an HCL expression for a complex type.
+func parseString(d *model.Variable, raw, source string) (cty.Value, hcl.Diagnostics) {
+	if d.Type.IsPrimitiveType() || d.Type == cty.DynamicPseudoType {
+		return cty.StringVal(raw), nil
+	}
+	expr, diags := hclsyntax.ParseExpression([]byte(raw), source, hcl.InitialPos)
+	if diags.HasErrors() {
+		return cty.NilVal, diags
+	}
+	return expr.Value(nil)
+}
+
+// readFile reads the assignments of a var file into values.
+func readFile(path string, declared map[string]*model.Variable, values map[string]given) hcl.Diagnostics {
+	parser := hclparse.NewParser()
+	var (
+		file  *hcl.File
+		diags hcl.Diagnostics
+	)
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		file, diags = parser.ParseJSONFile(path)
+	} else {
+		file, diags = parser.ParseHCLFile(path)
+	}
+	if diags.HasErrors() {
+		return diags
+	}
+
+	attrs, attrDiags := file.Body.JustAttributes()
+	diags = append(diags, attrDiags...)
+	names := make([]string, 0, len(attrs))
+	for name := range attrs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		attr := attrs[name]
+		if _, ok := declared[name]; !ok {
+			diags = diags.Append(undeclared(name, attr.NameRange.Ptr()))
+			continue
+		}
+		// A nil eval context is used because var files must hold literal values.
+		val, valDiags := attr.Expr.Value(nil)
+		diags = append(diags, valDiags...)
+		if !valDiags.HasErrors() {
+			values[name] = given{val: val, rng: attr.Expr.Range().Ptr()}
+		}
+	}
+	return diags
+}
+
+// validate evaluates a `validation` block of d against evalCtx.
+func validate(d *model.Variable, v *model.VariableValidation, evalCtx *hcl.EvalContext) hcl.Diagnostics {
+	cond, diags := v.Condition.Value(evalCtx)
+	if diags.HasErrors() {
+		return diags
+	}
+	cond, _ = cond.UnmarkDeep()
+	cond, err := convert.Convert(cond, cty.Bool)
+	if err != nil || cond.IsNull() || !cond.IsKnown() {
+		detail := "The condition must evaluate to true or false."
+		if err != nil {
+			detail = fmt.Sprintf("The condition must evaluate to true or false: %s.", err)
+		}
+		return diags.Append(&hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Invalid validation condition",
+			Detail:   detail,
+			Subject:  v.Condition.Range().Ptr(),
+		})
+	}
+	if cond.True() {
+		return diags
+	}
+
+	msg, msgDiags := v.ErrorMessage.Value(evalCtx)
+	diags = append(diags, msgDiags...)
+	if msgDiags.HasErrors() {
+		return diags
+	}
+	detail := sensitive.Redacted
+	if !sensitive.Marked(msg) {
+		str, err := convert.Convert(msg, cty.String)
+		if err != nil || str.IsNull() || !str.IsKnown() {
+			return diags.Append(&hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid validation error message",
+				Detail:   "The error message must evaluate to a string.",
+				Subject:  v.ErrorMessage.Range().Ptr(),
+			})
+		}
+		detail = str.AsString()
+	}
+	return diags.Append(&hcl.Diagnostic{
+		Severity: hcl.DiagError,
+		Summary:  "Invalid value for variable",
+		Detail:   fmt.Sprintf("%s\n\nThis was checked by the validation rule at %s.", detail, v.DeclRange.String()),
+		Subject:  d.DeclRange.Ptr(),
+	})
+}
+
+// undeclared reports a value given for a variable the grid does not declare.
+func undeclared(name string, rng *hcl.Range) *hcl.Diagnostic {
+	return &hcl.Diagnostic{
+		Severity: hcl.DiagError,
+		Summary:  "Undeclared variable",
+		Detail:   fmt.Sprintf("A value was given for the variable %q, which is not declared by any `variable` block in the grid.", name),
+		Subject:  rng,
+	}
+}
@@ -0,0 +1,287 @@
+package variables
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/specialistvlad/burstgridgo/internal/ctxlog"
+	"github.com/specialistvlad/burstgridgo/internal/model"
+	"github.com/specialistvlad/burstgridgo/internal/sensitive"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// declare parses the `variable` blocks in src.
+func declare(t *testing.T, src string) []*model.Variable {
+	t.Helper()
+	ctx := ctxlog.WithLogger(context.Background(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.hcl"), []byte(src), 0644))
+	grid, err := model.LoadGridsRecursively(ctx, dir)
+	require.NoError(t, err)
+	return grid.Variables
+}
+
+// varFile writes a var file and returns its path.
+func varFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+const declarations = `
+variable "host" {
+  type    = string
+  default = "localhost"
+}
+
+variable "port" {
+  type    = number
+  default = 80
+}
+
+variable "debug" {
+  type    = bool
+  default = false
+}
+`
+
+func TestResolve_Precedence(t *testing.T) {
+	decls := declare(t, declarations)
+
+	testCases := []struct {
+		name string
+		src  func(t *testing.T) Sources
+		want map[string]cty.Value
+	}{
+		{
+			name: "defaults",
+			src:  func(t *testing.T) Sources { return Sources{} },
+			want: map[string]cty.Value{"host": cty.StringVal("localhost"), "port": cty.NumberIntVal(80), "debug": cty.False},
+		},
+		{
+			name: "environment overrides defaults",
+			src: func(t *testing.T) Sources {
+				return Sources{Env: []string{"PATH=/bin", "BGGO_VAR_host=env.example.com", "BGGO_VAR_port=8080", "BGGO_VAR_unknown=x"}}
+			},
+			want: map[string]cty.Value{"host": cty.StringVal("env.example.com"), "port": cty.NumberIntVal(8080), "debug": cty.False},
+		},
+		{
+			name: "flags override environment, later flags win",
+			src: func(t *testing.T) Sources {
+				return Sources{
+					Env:  []string{"BGGO_VAR_host=env.example.com", "BGGO_VAR_debug=true"},
+					Vars: []string{"host=flag.example.com", "host=last.example.com", "port=443"},
+				}
+			},
+			want: map[string]cty.Value{"host": cty.StringVal("last.example.com"), "port": cty.NumberIntVal(443), "debug": cty.True},
+		},
+		{
+			name: "files override flags, later files win",
+			src: func(t *testing.T) Sources {
+				return Sources{
+					Vars: []string{"host=flag.example.com", "port=443"},
+					Files: []string{
+						varFile(t, "first.hcl", "host = \"file.example.com\"\nport = 8443\n"),
+						varFile(t, "second.json", `{"port": 9443}`),
+					},
+				}
+			},
+			want: map[string]cty.Value{"host": cty.StringVal("file.example.com"), "port": cty.NumberIntVal(9443), "debug": cty.False},
+		},
+		{
+			name: "values containing equals signs",
+			src:  func(t *testing.T) Sources { return Sources{Vars: []string{"host=a=b"}} },
+			want: map[string]cty.Value{"host": cty.StringVal("a=b"), "port": cty.NumberIntVal(80), "debug": cty.False},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, diags := Resolve(decls, tc.src(t))
+			require.False(t, diags.HasErrors(), diags.Error())
+			assert.True(t, cty.ObjectVal(tc.want).RawEquals(got), "got %#v", got)
+		})
+	}
+}
+
+func TestResolve_Errors(t *testing.T) {
+	testCases := []struct {
+		name        string
+		decls       string
+		src         func(t *testing.T) Sources
+		wantSummary string
+		wantDetail  string
+	}{
+		{
+			name:        "missing required value",
+			decls:       `variable "token" {}`,
+			src:         func(t *testing.T) Sources { return Sources{} },
+			wantSummary: "Missing required variable",
+			wantDetail:  "BGGO_VAR_token",
+		},
+		{
+			name:        "undeclared flag",
+			decls:       declarations,
+			src:         func(t *testing.T) Sources { return Sources{Vars: []string{"colour=blue"}} },
+			wantSummary: "Undeclared variable",
+			wantDetail:  `"colour"`,
+		},
+		{
+			name:        "undeclared file attribute",
+			decls:       declarations,
+			src:         func(t *testing.T) Sources { return Sources{Files: []string{varFile(t, "vars.hcl", `colour = "blue"`)}} },
+			wantSummary: "Undeclared variable",
+			wantDetail:  `"colour"`,
+		},
+		{
+			name:        "malformed flag",
+			decls:       declarations,
+			src:         func(t *testing.T) Sources { return Sources{Vars: []string{"host"}} },
+			wantSummary: "Invalid --var option",
+		},
+		{
+			name:        "value of the wrong type",
+			decls:       declarations,
+			src:         func(t *testing.T) Sources { return Sources{Env: []string{"BGGO_VAR_port=eighty"}} },
+			wantSummary: "Invalid value for variable",
+			wantDetail:  `"port"`,
+		},
+		{
+			name: "failed validation",
+			decls: `
+				variable "port" {
+				  type = number
+				  validation {
+				    condition     = var.port > 0 && var.port < 65536
+				    error_message = "The port must be between 1 and 65535, got ${var.port}."
+				  }
+				}`,
+			src:         func(t *testing.T) Sources { return Sources{Vars: []string{"port=70000"}} },
+			wantSummary: "Invalid value for variable",
+			wantDetail:  "The port must be between 1 and 65535, got 70000.",
+		},
+		{
+			name: "sensitive values are redacted from validation messages",
+			decls: `
+				variable "token" {
+				  sensitive = true
+				  validation {
+				    condition     = var.token != "s3cr3t"
+				    error_message = "Token ${var.token} is not allowed."
+				  }
+				}`,
+			src:         func(t *testing.T) Sources { return Sources{Vars: []string{"token=s3cr3t"}} },
+			wantSummary: "Invalid value for variable",
+			wantDetail:  sensitive.Redacted,
+		},
+		{
+			name: "condition that is not a bool",
+			decls: `
+				variable "host" {
+				  default = "localhost"
+				  validation {
+				    condition     = "maybe"
+				    error_message = "unreachable"
+				  }
+				}`,
+			src:         func(t *testing.T) Sources { return Sources{} },
+			wantSummary: "Invalid validation condition",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, diags := Resolve(declare(t, tc.decls), tc.src(t))
+			require.True(t, diags.HasErrors())
+			require.Equal(t, tc.wantSummary, diags[0].Summary, diags.Error())
+			assert.Contains(t, diags[0].Detail, tc.wantDetail)
+		})
+	}
+}
+
+func TestResolve_ValidationPasses(t *testing.T) {
+	decls := declare(t, `
+		variable "port" {
+		  type = number
+		  validation {
+		    condition     = var.port > 0
+		    error_message = "The port must be positive."
+		  }
+		}`)
+	got, diags := Resolve(decls, Sources{Vars: []string{"port=8080"}})
+	require.False(t, diags.HasErrors(), diags.Error())
+	assert.True(t, cty.NumberIntVal(8080).Equals(got.GetAttr("port")).True())
+}
+
+func TestResolve_Sensitive(t *testing.T) {
+	decls := declare(t, `
+		variable "token" {
+		  type      = string
+		  sensitive = true
+		}`)
+	got, diags := Resolve(decls, Sources{Env: []string{"BGGO_VAR_token=s3cr3t"}})
+	require.False(t, diags.HasErrors(), diags.Error())
+	token := got.GetAttr("token")
+	assert.True(t, token.HasMark(sensitive.Mark))
+	unmarked, _ := token.Unmark()
+	assert.Equal(t, cty.StringVal("s3cr3t"), unmarked)
+}
+
+func TestResolve_ComplexTypesParseStrings(t *testing.T) {
+	decls := []*model.Variable{
+		{Name: "regions", Type: cty.List(cty.String)},
+		{Name: "raw", Type: cty.DynamicPseudoType},
+	}
+	got, diags := Resolve(decls, Sources{Vars: []string{`regions=["eu", "us"]`, `raw=["eu"]`}})
+	require.False(t, diags.HasErrors(), diags.Error())
+	assert.Equal(t, cty.ListVal([]cty.Value{cty.StringVal("eu"), cty.StringVal("us")}), got.GetAttr("regions"))
+	assert.Equal(t, cty.StringVal(`["eu"]`), got.GetAttr("raw"), "values of unconstrained variables are taken literally")
+
+	_, diags = Resolve(decls, Sources{Vars: []string{`regions=["eu"`}})
+	require.True(t, diags.HasErrors())
+	assert.Equal(t, hcl.DiagError, diags[0].Severity)
+}
+
+func TestResolve_TypeConstraints(t *testing.T) {
+	decls := declare(t, `
+		variable "targets" {
+		  type = list(object({
+		    url     = string
+		    retries = optional(number, 3)
+		  }))
+		  default = [{ url = "https://default.example.com" }]
+		}
+
+		variable "headers" {
+		  type    = map(string)
+		  default = {}
+		}
+	`)
+
+	got, diags := Resolve(decls, Sources{})
+	require.False(t, diags.HasErrors(), diags.Error())
+	target := got.GetAttr("targets").Index(cty.NumberIntVal(0))
+	assert.Equal(t, cty.StringVal("https://default.example.com"), target.GetAttr("url"))
+	assert.True(t, cty.NumberIntVal(3).Equals(target.GetAttr("retries")).True(), "defaults fill omitted optional attributes")
+
+	got, diags = Resolve(decls, Sources{
+		Vars:  []string{`headers={ Accept = "application/json" }`},
+		Files: []string{varFile(t, "vars.hcl", `targets = [{ url = "https://a.example.com", retries = 5 }, { url = "https://b.example.com" }]`)},
+	})
+	require.False(t, diags.HasErrors(), diags.Error())
+	targets := got.GetAttr("targets")
+	assert.True(t, cty.NumberIntVal(5).Equals(targets.Index(cty.NumberIntVal(0)).GetAttr("retries")).True())
+	assert.True(t, cty.NumberIntVal(3).Equals(targets.Index(cty.NumberIntVal(1)).GetAttr("retries")).True())
+	assert.Equal(t, cty.MapVal(map[string]cty.Value{"Accept": cty.StringVal("application/json")}), got.GetAttr("headers"))
+
+	_, diags = Resolve(decls, Sources{Vars: []string{`targets=[{ retries = 1 }]`}})
+	require.True(t, diags.HasErrors())
+	assert.Equal(t, "Invalid value for variable", diags[0].Summary)
+	assert.Contains(t, diags[0].Detail, `attribute "url" is required`)
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/specialistvlad/burstgridgo/internal/ctxlog"
 	"github.com/specialistvlad/burstgridgo/internal/handlers"
+	"github.com/specialistvlad/burstgridgo/internal/sensitive"
 )
 
 // Input defines the arguments for the print runner. It accepts any single value.
@@ -17,12 +18,18 @@ type Deps struct{}
 
 // OnRunPrint is the handler for the 'print' runner's on_run lifecycle event.
 // It logs the provided input value using the contextual structured logger.
+// A sensitive value is logged as sensitive.Redacted.
 func OnRunPrint(ctx context.Context, deps *Deps, input *Input) (any, error) {
 	logger := ctxlog.FromContext(ctx)
 
+	value := input.Value
+	if handlers.InputSensitive(ctx, "input") {
+		value = sensitive.Redacted
+	}
+
 	// Log the received value. slog handles formatting for various types
 	// (primitives, maps, slices, structs) automatically.
-	logger.Info("Printing input value", "value", input.Value)
+	logger.Info("Printing input value", "value", value)
 
 	return nil, nil
 }